@@ -36,30 +36,7 @@ func main() {
 			continue
 		}
 
-		fmt.Printf("Stripped sequence: %s\n", seq.ToStrippedString())
-		fmt.Printf("Length: %d\n", seq.GetLength())
-
-		if charge := seq.GetCharge(); charge != nil {
-			fmt.Printf("Charge: %d\n", *charge)
-		}
-
-		if species := seq.GetIonicSpecies(); species != nil {
-			fmt.Printf("Ionic species: %s\n", *species)
-		}
-
-		if seq.IsChimeric() {
-			fmt.Printf("Chimeric peptidoforms: %d\n", len(seq.GetPeptidoforms()))
-		}
-
-		if seq.IsMultiChain() {
-			fmt.Printf("Multi-chain sequences: %d\n", len(seq.GetChains()))
-		}
-
-		// Show global modifications
-		globalMods := seq.GetGlobalMods()
-		if len(globalMods) > 0 {
-			fmt.Printf("Global modifications: %d\n", len(globalMods))
-		}
+		fmt.Print(seq.Summary())
 
 		// Regenerate ProForma
 		regenerated := seq.ToProforma()
@@ -0,0 +1,126 @@
+package sequal
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Adduct is one ion of the ionic species of a charge state, such as the "+2Na+" in
+// "/2[+2Na+,+H+]". Count is negative for a lost ion ("-H+"), and Charge is the charge of a
+// single ion.
+type Adduct struct {
+	Count  int
+	Ion    string
+	Charge int
+}
+
+// TotalCharge returns the charge contributed by the adduct, Count times Charge
+func (a Adduct) TotalCharge() int {
+	return a.Count * a.Charge
+}
+
+// String returns the adduct in ProForma notation, e.g. "+2Na+" or "-H+"
+func (a Adduct) String() string {
+	sign := func(value int) string {
+		if value < 0 {
+			return "-"
+		}
+		return "+"
+	}
+	magnitude := func(value int) string {
+		if value < 0 {
+			value = -value
+		}
+		if value == 1 {
+			return ""
+		}
+		return strconv.Itoa(value)
+	}
+	return sign(a.Count) + magnitude(a.Count) + a.Ion + sign(a.Charge) + magnitude(a.Charge)
+}
+
+// adductPattern matches an adduct: an optional sign and count, the ion and its charge
+var adductPattern = regexp.MustCompile(`^([+-]?)(\d*)([A-Z][A-Za-z0-9]*|e)([+-])(\d*)$`)
+
+// ParseIonicSpecies parses a comma separated ionic species such as "+2Na+,+H+" into its
+// adducts. A missing sign counts as an added ion and a missing count or charge magnitude
+// as 1, so "I-" is one iodide and "+Fe+3" one Fe3+ ion. An error is returned for a part
+// that is not an adduct.
+//
+// Example:
+//
+//	adducts, _ := sequal.ParseIonicSpecies("+2Na+,-H+")
+//	fmt.Println(adducts[0].Count, adducts[0].Ion, adducts[1].Count) // 2 Na -1
+func ParseIonicSpecies(species string) ([]Adduct, error) {
+	adducts := make([]Adduct, 0)
+	for _, part := range strings.Split(species, ",") {
+		match := adductPattern.FindStringSubmatch(strings.TrimSpace(part))
+		if match == nil {
+			return nil, fmt.Errorf("invalid adduct '%s' in ionic species '%s'", part, species)
+		}
+
+		count := 1
+		if match[2] != "" {
+			count, _ = strconv.Atoi(match[2])
+		}
+		if match[1] == "-" {
+			count = -count
+		}
+		charge := 1
+		if match[5] != "" {
+			charge, _ = strconv.Atoi(match[5])
+		}
+		if match[4] == "-" {
+			charge = -charge
+		}
+
+		adducts = append(adducts, Adduct{Count: count, Ion: match[3], Charge: charge})
+	}
+	return adducts, nil
+}
+
+// GetAdducts returns the adducts of the ionic species (see ParseIonicSpecies), or nil when
+// the sequence has no ionic species or it cannot be parsed. The ionic species string is kept
+// as written for GetIonicSpecies and ToProforma.
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("PEPTIDE/2[+2Na+]")
+//	adduct := seq.GetAdducts()[0]
+//	fmt.Println(adduct.Count, adduct.Ion, adduct.TotalCharge()) // 2 Na 2
+func (s *Sequence) GetAdducts() []Adduct {
+	if s.ionicSpecies == nil {
+		return nil
+	}
+	adducts, err := ParseIonicSpecies(*s.ionicSpecies)
+	if err != nil {
+		return nil
+	}
+	return adducts
+}
+
+// GetEffectiveCharge returns the charge to use for m/z calculations. It is the charge given
+// after "/" when that is set and not zero; otherwise the net charge of the adducts (see
+// GetAdducts), as for "PEPTIDE/0[+2Na+,+H+]" or a sequence built with an ionic species but no
+// charge. When neither gives a charge, GetCharge is returned unchanged and may be nil.
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("PEPTIDE/0[+2Na+,+H+]")
+//	fmt.Println(*seq.GetCharge(), *seq.GetEffectiveCharge()) // 0 3
+func (s *Sequence) GetEffectiveCharge() *int {
+	if s.charge != nil && *s.charge != 0 {
+		return s.charge
+	}
+	adducts := s.GetAdducts()
+	if len(adducts) == 0 {
+		return s.charge
+	}
+	charge := 0
+	for _, adduct := range adducts {
+		charge += adduct.TotalCharge()
+	}
+	return &charge
+}
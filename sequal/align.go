@@ -0,0 +1,95 @@
+package sequal
+
+// AlignOpKind describes an operation of a residue alignment
+type AlignOpKind string
+
+// Constants for the operations returned by AlignSequences
+const (
+	AlignMatch      AlignOpKind = "match"
+	AlignSubstitute AlignOpKind = "substitute"
+	AlignInsert     AlignOpKind = "insert"
+	AlignDelete     AlignOpKind = "delete"
+)
+
+// AlignOp is one step of an alignment from sequence a to sequence b.
+// PositionA and PositionB are residue indices in a and b; an insertion has no residue in a
+// and a deletion has no residue in b, in which case the position is -1 and the residue "".
+type AlignOp struct {
+	Kind      AlignOpKind
+	PositionA int
+	PositionB int
+	ResidueA  string
+	ResidueB  string
+}
+
+// AlignSequences aligns the stripped residues of two sequences with a minimal edit distance
+// (Levenshtein) alignment, where substitutions, insertions and deletions each cost one.
+// The operations are returned in sequence order; going from a to b, a residue only present
+// in b is an insertion. When several alignments are minimal, substitutions are preferred
+// over deletions and deletions over insertions.
+//
+// Example:
+//
+//	a, _ := sequal.FromProforma("PEPTIDEK")
+//	b, _ := sequal.FromProforma("PEPSIDERK")
+//	for _, op := range sequal.AlignSequences(a, b) {
+//		if op.Kind != sequal.AlignMatch {
+//			fmt.Println(op.Kind, op.PositionA, op.PositionB, op.ResidueA, op.ResidueB)
+//		}
+//	}
+//	// substitute 3 3 T S
+//	// insert -1 7  R
+func AlignSequences(a, b *Sequence) []AlignOp {
+	aLength, bLength := len(a.seq), len(b.seq)
+
+	// distance[i][j] is the edit distance between the first i residues of a and the first j of b
+	distance := make([][]int, aLength+1)
+	for i := range distance {
+		distance[i] = make([]int, bLength+1)
+		distance[i][0] = i
+	}
+	for j := 0; j <= bLength; j++ {
+		distance[0][j] = j
+	}
+
+	for i := 1; i <= aLength; i++ {
+		for j := 1; j <= bLength; j++ {
+			cost := 1
+			if a.seq[i-1].GetValue() == b.seq[j-1].GetValue() {
+				cost = 0
+			}
+			distance[i][j] = min(distance[i-1][j-1]+cost, distance[i-1][j]+1, distance[i][j-1]+1)
+		}
+	}
+
+	// Trace back from the end, then reverse into sequence order
+	ops := make([]AlignOp, 0, max(aLength, bLength))
+	i, j := aLength, bLength
+	for i > 0 || j > 0 {
+		if i > 0 && j > 0 {
+			residueA, residueB := a.seq[i-1].GetValue(), b.seq[j-1].GetValue()
+			if residueA == residueB && distance[i][j] == distance[i-1][j-1] {
+				ops = append(ops, AlignOp{Kind: AlignMatch, PositionA: i - 1, PositionB: j - 1, ResidueA: residueA, ResidueB: residueB})
+				i, j = i-1, j-1
+				continue
+			}
+			if distance[i][j] == distance[i-1][j-1]+1 {
+				ops = append(ops, AlignOp{Kind: AlignSubstitute, PositionA: i - 1, PositionB: j - 1, ResidueA: residueA, ResidueB: residueB})
+				i, j = i-1, j-1
+				continue
+			}
+		}
+		if i > 0 && distance[i][j] == distance[i-1][j]+1 {
+			ops = append(ops, AlignOp{Kind: AlignDelete, PositionA: i - 1, PositionB: -1, ResidueA: a.seq[i-1].GetValue()})
+			i--
+			continue
+		}
+		ops = append(ops, AlignOp{Kind: AlignInsert, PositionA: -1, PositionB: j - 1, ResidueB: b.seq[j-1].GetValue()})
+		j--
+	}
+
+	for left, right := 0, len(ops)-1; left < right; left, right = left+1, right-1 {
+		ops[left], ops[right] = ops[right], ops[left]
+	}
+	return ops
+}
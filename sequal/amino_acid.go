@@ -12,7 +12,8 @@ import (
 // that can be applied to the residue.
 type AminoAcid struct {
 	BaseBlock
-	mods []*Modification
+	mods    []*Modification
+	unknown bool
 }
 
 // NewAminoAcid creates a new AminoAcid instance with the specified value, position, and optional mass.
@@ -50,6 +51,57 @@ func NewAminoAcid(value string, position *int, mass *float64) (*AminoAcid, error
 	}, nil
 }
 
+// newResidue creates an amino acid like NewAminoAcid, but keeps an unrecognized uppercase
+// letter without a mass, such as the ambiguity codes B, J and Z, as an unknown residue
+// instead of failing
+func newResidue(value string, position *int, mass *float64) (*AminoAcid, error) {
+	aa, err := NewAminoAcid(value, position, mass)
+	if err == nil {
+		return aa, nil
+	}
+	if len(value) != 1 || value[0] < 'A' || value[0] > 'Z' {
+		return nil, err
+	}
+	return &AminoAcid{
+		BaseBlock: NewBaseBlock(value, position, false, nil),
+		mods:      []*Modification{},
+		unknown:   true,
+	}, nil
+}
+
+// copyResidue returns a copy of the residue with its value, position, mass and unknown flag
+// but no modifications. Unlike newResidue it cannot fail, so copying a sequence never drops
+// a residue.
+func (aa *AminoAcid) copyResidue() *AminoAcid {
+	var position *int
+	if aa.GetPosition() != nil {
+		p := *aa.GetPosition()
+		position = &p
+	}
+	var mass *float64
+	if aa.GetMass() != nil {
+		m := *aa.GetMass()
+		mass = &m
+	}
+	return &AminoAcid{
+		BaseBlock: NewBaseBlock(aa.GetValue(), position, false, mass),
+		mods:      []*Modification{},
+		unknown:   aa.unknown,
+	}
+}
+
+// IsUnknown reports whether the residue is a letter with no known mass, such as the
+// ambiguity codes B (D or N), J (I or L) and Z (E or Q). Parsing keeps these residues with a
+// nil mass, so mass calculations over them return an error.
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("PEBTIDE")
+//	fmt.Println(seq.GetSeq()[2].IsUnknown()) // true
+func (aa *AminoAcid) IsUnknown() bool {
+	return aa.unknown
+}
+
 // GetMods returns a copy of the modifications list to prevent external mutation.
 func (aa *AminoAcid) GetMods() []*Modification {
 	modsCopy := make([]*Modification, len(aa.mods))
@@ -148,6 +200,47 @@ func (aa *AminoAcid) GetTotalMass() float64 {
 	return total
 }
 
+// GetComposition returns the elemental composition of the residue from AAComposition merged
+// with the formulas of its modifications (e.g. "[Formula:HPO3]"). Isotopes are keyed by
+// mass number and symbol as in ParseFormula. An error is returned for residues without a
+// known composition and for modifications that are not expressed as a formula.
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("S[Formula:HPO3]")
+//	composition, _ := seq.GetSeq()[0].GetComposition()
+//	fmt.Println(composition) // map[C:3 H:6 N:1 O:5 P:1]
+func (aa *AminoAcid) GetComposition() (map[string]int, error) {
+	residue, ok := AAComposition[aa.GetValue()]
+	if !ok {
+		return nil, fmt.Errorf("no composition for residue '%s'", aa.GetValue())
+	}
+
+	composition := make(map[string]int, len(residue))
+	for element, count := range residue {
+		composition[element] = count
+	}
+
+	for _, mod := range aa.mods {
+		formula := mod.getFormula()
+		if formula == "" {
+			return nil, fmt.Errorf("modification '%s' on residue '%s' has no formula", mod.GetValue(), aa.GetValue())
+		}
+		counts, err := ParseFormula(formula)
+		if err != nil {
+			return nil, err
+		}
+		for element, count := range counts {
+			composition[element] += count
+			if composition[element] == 0 {
+				delete(composition, element)
+			}
+		}
+	}
+
+	return composition, nil
+}
+
 // ToMap converts the amino acid to a map representation suitable for serialization.
 // Includes base block properties, modifications, and total mass.
 func (aa *AminoAcid) ToMap() map[string]interface{} {
@@ -207,6 +300,52 @@ func (aa *AminoAcid) Equal(other BaseBlock) bool {
 	return true
 }
 
+// EqualIgnoringModOrder compares two amino acids like Equal, but treats their modifications
+// as a multiset, so "S[Phospho][Acetyl]" and "S[Acetyl][Phospho]" are considered equal.
+func (aa *AminoAcid) EqualIgnoringModOrder(other *AminoAcid) bool {
+	if other == nil {
+		return false
+	}
+
+	if aa.GetValue() != other.GetValue() {
+		return false
+	}
+	if !equalPtr(aa.GetPosition(), other.GetPosition()) {
+		return false
+	}
+	if (aa.GetMass() == nil) != (other.GetMass() == nil) {
+		return false
+	}
+	if aa.GetMass() != nil && *aa.GetMass() != *other.GetMass() {
+		return false
+	}
+
+	if len(aa.mods) != len(other.mods) {
+		return false
+	}
+
+	counts := make(map[string]int)
+	for _, mod := range aa.mods {
+		modHash, err := mod.Hash()
+		if err != nil {
+			return false
+		}
+		counts[modHash]++
+	}
+	for _, mod := range other.mods {
+		modHash, err := mod.Hash()
+		if err != nil {
+			return false
+		}
+		if counts[modHash] == 0 {
+			return false
+		}
+		counts[modHash]--
+	}
+
+	return true
+}
+
 // Hash generates a SHA-256 hash for the amino acid including all modifications.
 // The hash is computed from the JSON representation of the amino acid's map.
 func (aa *AminoAcid) Hash() (string, error) {
@@ -1,6 +1,7 @@
 package sequal
 
 import (
+	"math"
 	"testing"
 )
 
@@ -32,6 +33,24 @@ func TestAminoAcidCreation(t *testing.T) {
 			expectedMass:  87.032028,
 			shouldError:   false,
 		},
+		{
+			name:          "Selenocysteine",
+			value:         "U",
+			position:      IntPtr(2),
+			mass:          nil,
+			expectedValue: "U",
+			expectedMass:  150.953636,
+			shouldError:   false,
+		},
+		{
+			name:          "Pyrrolysine",
+			value:         "O",
+			position:      IntPtr(3),
+			mass:          nil,
+			expectedValue: "O",
+			expectedMass:  237.147727,
+			shouldError:   false,
+		},
 		{
 			name:          "Custom mass",
 			value:         "X",
@@ -237,6 +256,61 @@ func TestAminoAcidTotalMass(t *testing.T) {
 	}
 }
 
+func TestAminoAcidComposition(t *testing.T) {
+	// Residue compositions must agree with the residue masses
+	for _, code := range []string{"A", "R", "N", "D", "C", "E", "Q", "G", "H", "I", "L", "K", "M", "F", "P", "S", "T", "W", "Y", "V", "O", "U"} {
+		mass := 0.0
+		for element, count := range AAComposition[code] {
+			mass += ElementMass[element] * float64(count)
+		}
+		if math.Abs(mass-AAMass[code]) > 0.001 {
+			t.Errorf("Composition mass of '%s' is %f, expected %f", code, mass, AAMass[code])
+		}
+	}
+
+	testCases := []struct {
+		proforma string
+		expected map[string]int
+	}{
+		{"S", map[string]int{"C": 3, "H": 5, "N": 1, "O": 2}},
+		{"S[Formula:HPO3]", map[string]int{"C": 3, "H": 6, "N": 1, "O": 5, "P": 1}},
+		{"M[Formula:O]", map[string]int{"C": 5, "H": 9, "N": 1, "O": 2, "S": 1}},
+		{"K[Formula:[13C2]H-2]", map[string]int{"C": 6, "13C": 2, "H": 10, "N": 2, "O": 1}},
+		{"S[Formula:N-1]", map[string]int{"C": 3, "H": 5, "O": 2}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.proforma, func(t *testing.T) {
+			seq, err := FromProforma(tc.proforma)
+			if err != nil {
+				t.Fatalf("Failed to parse '%s': %v", tc.proforma, err)
+			}
+			composition, err := seq.GetSeq()[0].GetComposition()
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(composition) != len(tc.expected) {
+				t.Errorf("Expected %v, got %v", tc.expected, composition)
+			}
+			for element, count := range tc.expected {
+				if composition[element] != count {
+					t.Errorf("Expected %d %s, got %d", count, element, composition[element])
+				}
+			}
+		})
+	}
+
+	seq, _ := FromProforma("S[Phospho]")
+	if _, err := seq.GetSeq()[0].GetComposition(); err == nil {
+		t.Error("Expected error for modification without a formula")
+	}
+
+	aa, _ := NewAminoAcid("X", IntPtr(0), nil)
+	if _, err := aa.GetComposition(); err == nil {
+		t.Error("Expected error for residue without a composition")
+	}
+}
+
 func TestAminoAcidToMap(t *testing.T) {
 	aa, err := NewAminoAcid("S", IntPtr(5), nil)
 	if err != nil {
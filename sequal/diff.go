@@ -0,0 +1,183 @@
+package sequal
+
+import "fmt"
+
+// SequenceDiffKind describes the kind of difference found between two sequences
+type SequenceDiffKind string
+
+// Constants for the kinds of differences reported by DiffSequences
+const (
+	SequenceDiffSubstitution        SequenceDiffKind = "substitution"
+	SequenceDiffLengthMismatch      SequenceDiffKind = "length_mismatch"
+	SequenceDiffModificationAdded   SequenceDiffKind = "modification_added"
+	SequenceDiffModificationRemoved SequenceDiffKind = "modification_removed"
+	SequenceDiffChargeChanged       SequenceDiffKind = "charge_changed"
+)
+
+// SequenceDiff describes a single difference between two sequences.
+// Position is the residue index, or the -1 to -5 slot for terminal, labile and
+// unknown-position modifications as reported by Side. Charge differences are
+// sequence-level and use position 0 with an empty Side.
+type SequenceDiff struct {
+	Position    int
+	Side        TerminalSide
+	Kind        SequenceDiffKind
+	Description string
+}
+
+// String returns a string representation of the difference
+func (sd SequenceDiff) String() string {
+	return fmt.Sprintf("%s at position %d: %s", sd.Kind, sd.Position, sd.Description)
+}
+
+// DiffSequences compares two peptidoforms and reports residue substitutions, added and
+// removed modifications by position, and charge changes. Residues are aligned by index;
+// when the sequences differ in length the mismatch is reported at the first unmatched
+// index and the extra residues' modifications are reported as added or removed.
+// Modifications are compared by their ProForma representation, ignoring their order.
+// Going from a to b, a modification only present in b is "added".
+//
+// Example:
+//
+//	a, _ := sequal.FromProforma("PEPTIDE/2")
+//	b, _ := sequal.FromProforma("[Acetyl]-PEPS[Phospho]IDE/3")
+//	for _, diff := range sequal.DiffSequences(a, b) {
+//		fmt.Println(diff)
+//	}
+//	// modification_added at position -1: added N-terminal modification 'Acetyl'
+//	// substitution at position 3: residue 'T' replaced by 'S'
+//	// modification_added at position 3: added modification 'Phospho' on residue 'S'
+//	// charge_changed at position 0: charge changed from 2 to 3
+func DiffSequences(a, b *Sequence) []SequenceDiff {
+	diffs := make([]SequenceDiff, 0)
+
+	for _, position := range []int{-4, -5, -3, -1} {
+		diffs = append(diffs, diffModifications(position, "", "", a.mods[position], b.mods[position])...)
+	}
+
+	aLength, bLength := len(a.seq), len(b.seq)
+	minLength := aLength
+	if bLength < minLength {
+		minLength = bLength
+	}
+
+	for i := 0; i < minLength; i++ {
+		aResidue, bResidue := a.seq[i].GetValue(), b.seq[i].GetValue()
+		if aResidue != bResidue {
+			diffs = append(diffs, SequenceDiff{
+				Position:    i,
+				Side:        TerminalSideResidue,
+				Kind:        SequenceDiffSubstitution,
+				Description: fmt.Sprintf("residue '%s' replaced by '%s'", aResidue, bResidue),
+			})
+		}
+		diffs = append(diffs, diffModifications(i, aResidue, bResidue, a.seq[i].GetMods(), b.seq[i].GetMods())...)
+	}
+
+	if aLength != bLength {
+		diffs = append(diffs, SequenceDiff{
+			Position:    minLength,
+			Side:        TerminalSideResidue,
+			Kind:        SequenceDiffLengthMismatch,
+			Description: fmt.Sprintf("sequence length changed from %d to %d", aLength, bLength),
+		})
+		for i := minLength; i < aLength; i++ {
+			diffs = append(diffs, diffModifications(i, a.seq[i].GetValue(), "", a.seq[i].GetMods(), nil)...)
+		}
+		for i := minLength; i < bLength; i++ {
+			diffs = append(diffs, diffModifications(i, "", b.seq[i].GetValue(), nil, b.seq[i].GetMods())...)
+		}
+	}
+
+	diffs = append(diffs, diffModifications(-2, "", "", a.mods[-2], b.mods[-2])...)
+
+	if !equalCharge(a.charge, b.charge) {
+		diffs = append(diffs, SequenceDiff{
+			Kind:        SequenceDiffChargeChanged,
+			Description: fmt.Sprintf("charge changed from %s to %s", formatCharge(a.charge), formatCharge(b.charge)),
+		})
+	}
+
+	return diffs
+}
+
+// diffModifications compares the modifications at one position as multisets.
+// The residues are empty for terminal, labile and unknown-position slots.
+func diffModifications(position int, aResidue, bResidue string, aMods, bMods []*Modification) []SequenceDiff {
+	var diffs []SequenceDiff
+	side := TerminalSideForPosition(position)
+
+	location := ""
+	switch side {
+	case TerminalSideNTerm:
+		location = "N-terminal "
+	case TerminalSideCTerm:
+		location = "C-terminal "
+	case TerminalSideLabile:
+		location = "labile "
+	case TerminalSideUnknown:
+		location = "unknown-position "
+	case TerminalSideUnknownTerminus:
+		location = "unknown-terminus "
+	}
+	describe := func(action string, residue string, mod *Modification) string {
+		if residue != "" {
+			return fmt.Sprintf("%s %smodification '%s' on residue '%s'", action, location, mod.ToProforma(), residue)
+		}
+		return fmt.Sprintf("%s %smodification '%s'", action, location, mod.ToProforma())
+	}
+
+	remaining := make(map[string]int)
+	for _, mod := range bMods {
+		remaining[mod.ToProforma()]++
+	}
+	for _, mod := range aMods {
+		key := mod.ToProforma()
+		if remaining[key] > 0 {
+			remaining[key]--
+			continue
+		}
+		diffs = append(diffs, SequenceDiff{
+			Position:    position,
+			Side:        side,
+			Kind:        SequenceDiffModificationRemoved,
+			Description: describe("removed", aResidue, mod),
+		})
+	}
+
+	unmatched := make(map[string]int)
+	for _, mod := range aMods {
+		unmatched[mod.ToProforma()]++
+	}
+	for _, mod := range bMods {
+		key := mod.ToProforma()
+		if unmatched[key] > 0 {
+			unmatched[key]--
+			continue
+		}
+		diffs = append(diffs, SequenceDiff{
+			Position:    position,
+			Side:        side,
+			Kind:        SequenceDiffModificationAdded,
+			Description: describe("added", bResidue, mod),
+		})
+	}
+
+	return diffs
+}
+
+// equalCharge reports whether two optional charges are the same
+func equalCharge(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// formatCharge formats an optional charge for diff descriptions
+func formatCharge(charge *int) string {
+	if charge == nil {
+		return "none"
+	}
+	return fmt.Sprintf("%d", *charge)
+}
@@ -0,0 +1,75 @@
+package sequal
+
+import "fmt"
+
+// ParseErrorKind identifies the category of a ProForma parse failure
+type ParseErrorKind string
+
+// Constants for the kinds of parse errors returned by ProFormaParser.Parse
+const (
+	ParseErrorUnclosedBracket           ParseErrorKind = "unclosed_bracket"
+	ParseErrorUnclosedBrace             ParseErrorKind = "unclosed_brace"
+	ParseErrorUnclosedAngleBracket      ParseErrorKind = "unclosed_angle_bracket"
+	ParseErrorUnclosedParenthesis       ParseErrorKind = "unclosed_parenthesis"
+	ParseErrorUnmatchedParenthesis      ParseErrorKind = "unmatched_parenthesis"
+	ParseErrorInvalidGlobalModification ParseErrorKind = "invalid_global_modification"
+	ParseErrorInvalidMultiplier         ParseErrorKind = "invalid_multiplier"
+	ParseErrorUnsupportedFeature        ParseErrorKind = "unsupported_feature"
+	ParseErrorInvalidResidue            ParseErrorKind = "invalid_residue"
+	ParseErrorEmptyModification         ParseErrorKind = "empty_modification"
+)
+
+// ParseError describes a ProForma parse failure. Offset is the byte offset of the
+// offending character within the string passed to the parser, so editors can highlight it.
+// Use errors.As to retrieve it from the error returned by the parsing functions.
+//
+// Example:
+//
+//	_, err := sequal.FromProforma("ELVIS[Phospho")
+//	var parseErr *sequal.ParseError
+//	if errors.As(err, &parseErr) {
+//		fmt.Println(parseErr.Kind, parseErr.Offset) // unclosed_bracket 5
+//	}
+type ParseError struct {
+	Kind    ParseErrorKind
+	Offset  int
+	Message string
+	// detail and note are the parts of the message before and after the position, so the
+	// message can be rebuilt when the offset changes
+	detail string
+	note   string
+}
+
+// newParseError creates a ParseError whose message is detail, the position and note, such as
+// "unclosed bracket at position 5"
+func newParseError(kind ParseErrorKind, offset int, detail string, note string) *ParseError {
+	e := &ParseError{Kind: kind, detail: detail, note: note}
+	e.setOffset(offset)
+	return e
+}
+
+// newInvalidMultiplierError creates a ParseError for a '^' not followed by a positive count
+func newInvalidMultiplierError(offset int) *ParseError {
+	return newParseError(ParseErrorInvalidMultiplier, offset,
+		"invalid multiplier", ", expected '^' followed by a positive count")
+}
+
+// newEmptyModificationError creates a ParseError for brackets or braces with no modification
+// inside, such as the "[]" of "PEP[]TIDE"
+func newEmptyModificationError(offset int) *ParseError {
+	return newParseError(ParseErrorEmptyModification, offset, "empty modification", "")
+}
+
+// setOffset moves the error to offset, rebuilding the message so it reports the new position
+func (e *ParseError) setOffset(offset int) {
+	e.Offset = offset
+	e.Message = e.Error()
+}
+
+// Error returns the human-readable message of the parse error, reporting its Offset
+func (e *ParseError) Error() string {
+	if e.detail == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%s at position %d%s", e.detail, e.Offset, e.note)
+}
@@ -0,0 +1,206 @@
+package sequal
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ParseFormula parses a ProForma chemical formula into element counts.
+// Elements are keyed by symbol (e.g. "C") and isotopes by mass number and symbol (e.g. "13C").
+// Counts may be negative, and isotopes may be written as "[13C2]" or "[13C]2".
+// Spaces between elements are ignored.
+//
+// Example:
+//
+//	counts, _ := sequal.ParseFormula("C2H3[13C1]O-1")
+//	fmt.Println(counts["C"], counts["13C"], counts["O"]) // 2 1 -1
+func ParseFormula(formula string) (map[string]int, error) {
+	formulaNoSpaces := strings.ReplaceAll(formula, " ", "")
+	if formulaNoSpaces == "" {
+		return nil, fmt.Errorf("empty formula")
+	}
+
+	counts := make(map[string]int)
+	i := 0
+	for i < len(formulaNoSpaces) {
+		var symbol string
+		count := 1
+
+		if formulaNoSpaces[i] == '[' {
+			endBracket := strings.Index(formulaNoSpaces[i:], "]")
+			if endBracket == -1 {
+				return nil, fmt.Errorf("unclosed isotope bracket in formula '%s'", formula)
+			}
+			endBracket += i
+
+			isotopePart := formulaNoSpaces[i+1 : endBracket]
+			j := 0
+			for j < len(isotopePart) && unicode.IsDigit(rune(isotopePart[j])) {
+				j++
+			}
+			if j == 0 || j >= len(isotopePart) || !unicode.IsUpper(rune(isotopePart[j])) {
+				return nil, fmt.Errorf("invalid isotope '%s' in formula '%s'", isotopePart, formula)
+			}
+			k := j + 1
+			if k < len(isotopePart) && unicode.IsLower(rune(isotopePart[k])) {
+				k++
+			}
+			symbol = isotopePart[:k]
+
+			if k < len(isotopePart) {
+				innerCount, err := strconv.Atoi(isotopePart[k:])
+				if err != nil {
+					return nil, fmt.Errorf("invalid isotope count '%s' in formula '%s'", isotopePart[k:], formula)
+				}
+				count = innerCount
+			}
+			i = endBracket + 1
+		} else if unicode.IsUpper(rune(formulaNoSpaces[i])) {
+			start := i
+			i++
+			if i < len(formulaNoSpaces) && unicode.IsLower(rune(formulaNoSpaces[i])) {
+				i++
+			}
+			symbol = formulaNoSpaces[start:i]
+		} else {
+			return nil, fmt.Errorf("unexpected character '%c' in formula '%s'", formulaNoSpaces[i], formula)
+		}
+
+		if i < len(formulaNoSpaces) && (formulaNoSpaces[i] == '-' || unicode.IsDigit(rune(formulaNoSpaces[i]))) {
+			j := i
+			if formulaNoSpaces[j] == '-' {
+				j++
+			}
+			for j < len(formulaNoSpaces) && unicode.IsDigit(rune(formulaNoSpaces[j])) {
+				j++
+			}
+			outerCount, err := strconv.Atoi(formulaNoSpaces[i:j])
+			if err != nil {
+				return nil, fmt.Errorf("invalid count '%s' in formula '%s'", formulaNoSpaces[i:j], formula)
+			}
+			count *= outerCount
+			i = j
+		}
+
+		counts[symbol] += count
+	}
+
+	return counts, nil
+}
+
+// CalculateFormulaMass calculates the monoisotopic mass of a ProForma chemical formula
+// using ElementMass and IsotopeMass. It returns an error for unknown elements or isotopes.
+//
+// Example:
+//
+//	mass, _ := sequal.CalculateFormulaMass("H2O")
+//	fmt.Printf("%.4f\n", mass) // 18.0106
+func CalculateFormulaMass(formula string) (float64, error) {
+	counts, err := ParseFormula(formula)
+	if err != nil {
+		return 0, err
+	}
+	mass, err := compositionMass(counts)
+	if err != nil {
+		return 0, fmt.Errorf("%w in formula '%s'", err, formula)
+	}
+	return mass, nil
+}
+
+// compositionMass sums the monoisotopic masses of the element and isotope counts
+func compositionMass(counts map[string]int) (float64, error) {
+	mass := 0.0
+	for symbol, count := range counts {
+		if unicode.IsDigit(rune(symbol[0])) {
+			isotopeMass, ok := IsotopeMass[symbol]
+			if !ok {
+				return 0, fmt.Errorf("unknown isotope '%s'", symbol)
+			}
+			mass += isotopeMass * float64(count)
+			continue
+		}
+
+		elementMass, ok := ElementMass[symbol]
+		if !ok {
+			return 0, fmt.Errorf("unknown element '%s'", symbol)
+		}
+		mass += elementMass * float64(count)
+	}
+
+	return mass, nil
+}
+
+// isotopeLabel returns the element and isotope of a global isotope label such as "15N",
+// with "D" for deuterium
+func isotopeLabel(label string) (string, string, error) {
+	if label == "D" {
+		return "H", "2H", nil
+	}
+	i := 0
+	for i < len(label) && unicode.IsDigit(rune(label[i])) {
+		i++
+	}
+	if _, ok := IsotopeMass[label]; i == 0 || !ok {
+		return "", "", fmt.Errorf("unknown isotope label '%s'", label)
+	}
+	return label[i:], label, nil
+}
+
+// hillFormula writes element counts in Hill system order, with each isotope after its
+// element. A count of one is left out.
+func hillFormula(counts map[string]int) string {
+	element := func(symbol string) string {
+		return strings.TrimLeftFunc(symbol, unicode.IsDigit)
+	}
+	hasCarbon := false
+	symbols := make([]string, 0, len(counts))
+	for symbol := range counts {
+		symbols = append(symbols, symbol)
+		if element(symbol) == "C" {
+			hasCarbon = true
+		}
+	}
+
+	rank := func(symbol string) int {
+		if hasCarbon {
+			switch element(symbol) {
+			case "C":
+				return 0
+			case "H":
+				return 1
+			}
+		}
+		return 2
+	}
+	sort.Slice(symbols, func(i, j int) bool {
+		a, b := symbols[i], symbols[j]
+		if rank(a) != rank(b) {
+			return rank(a) < rank(b)
+		}
+		if element(a) != element(b) {
+			return element(a) < element(b)
+		}
+		// The plain element comes before its isotopes, which are ordered by mass number
+		if len(a) != len(b) {
+			return len(a) < len(b)
+		}
+		return a < b
+	})
+
+	var sb strings.Builder
+	for _, symbol := range symbols {
+		count := strconv.Itoa(counts[symbol])
+		if counts[symbol] == 1 {
+			count = ""
+		}
+		if symbol != element(symbol) {
+			sb.WriteString("[" + symbol + count + "]")
+		} else {
+			sb.WriteString(symbol + count)
+		}
+	}
+	return sb.String()
+}
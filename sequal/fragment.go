@@ -0,0 +1,170 @@
+package sequal
+
+import (
+	"fmt"
+	"math"
+)
+
+// FragmentIonType identifies the backbone fragment series of a FragmentIon
+type FragmentIonType string
+
+// Constants for the fragment ion series returned by ComplementaryPair
+const (
+	FragmentIonB FragmentIonType = "b"
+	FragmentIonY FragmentIonType = "y"
+)
+
+// FragmentIon is a backbone fragment of a peptide: a b ion holds the first Number residues
+// and a y ion the last Number residues, with their modifications. NeutralMass is the mass of
+// the fragment without the added protons, and Mz its m/z at Charge.
+type FragmentIon struct {
+	Type        FragmentIonType
+	Number      int
+	Charge      int
+	NeutralMass float64
+	Mz          float64
+}
+
+// ComplementaryPair returns the b and y ions of the backbone cleavage after the given
+// number of residues, each with the given charge. The neutral masses of the pair add up to
+// the neutral mass of the peptide (see GetNeutralMass): the b ion takes the N-terminal group
+// less a hydrogen, the y ion the C-terminal group plus a hydrogen, so with free termini the
+// y ion carries the water.
+//
+// Residue, terminal and fixed global modifications go to the fragment holding their site. An
+// error is returned for multi-chain and chimeric sequences, isotope labels, modifications
+// without a position (labile, unknown position or unknown terminus), range modifications and
+// crosslinks spanning the cleavage, a position outside 1 to length-1 and a charge below 1.
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("PEPTIDE")
+//	b, y, _ := seq.ComplementaryPair(3, 1)
+//	fmt.Printf("b%d %.4f y%d %.4f\n", b.Number, b.Mz, y.Number, y.Mz) // b3 324.1554 y4 477.2191
+func (s *Sequence) ComplementaryPair(position int, charge int) (FragmentIon, FragmentIon, error) {
+	return s.ComplementaryPairWithConfig(position, charge, DefaultMassConfig())
+}
+
+// ComplementaryPairWithConfig returns the b and y ions of ComplementaryPair with their m/z
+// calculated from the masses of config (see MassConfig.Mz). The neutral masses do not depend
+// on config.
+func (s *Sequence) ComplementaryPairWithConfig(position int, charge int, config MassConfig) (FragmentIon, FragmentIon, error) {
+	var b, y FragmentIon
+	if s.isMultiChain || s.isChimeric {
+		return b, y, fmt.Errorf("fragment ions need a single peptidoform")
+	}
+	if charge < 1 {
+		return b, y, fmt.Errorf("charge must be positive, got %d", charge)
+	}
+	if position < 1 || position >= len(s.seq) {
+		return b, y, fmt.Errorf("cleavage position %d out of range for sequence of length %d", position, len(s.seq))
+	}
+	for _, gm := range s.globalMods {
+		if gm.GetGlobalModType() == "isotope" {
+			return b, y, fmt.Errorf("fragment ions of isotope labeled sequences are not supported")
+		}
+	}
+	for _, pos := range []int{-3, -4, -5} {
+		if len(s.mods[pos]) > 0 {
+			return b, y, fmt.Errorf("modification '%s' has no position to assign to a fragment", s.mods[pos][0].GetValue())
+		}
+	}
+
+	modMass := func(mod *Modification, pos int) (float64, error) {
+		mass := mod.GetResolvedMass()
+		if mass == nil {
+			return 0, fmt.Errorf("cannot resolve mass of modification '%s' at position %d", mod.GetValue(), pos)
+		}
+		return *mass, nil
+	}
+
+	nTerm, cTerm := s.GetTerminalMasses()
+	nMass, cMass := nTerm-H, cTerm+H
+	for _, mod := range s.mods[-1] {
+		mass, err := modMass(mod, -1)
+		if err != nil {
+			return b, y, err
+		}
+		nMass += mass
+	}
+	for _, mod := range s.mods[-2] {
+		mass, err := modMass(mod, -2)
+		if err != nil {
+			return b, y, err
+		}
+		cMass += mass
+	}
+
+	// crosslinkSides records on which side of the cleavage each crosslink has a residue
+	crosslinkSides := make(map[string][2]bool)
+	counted := make(map[*Modification]bool)
+	for i, aa := range s.seq {
+		residueMass := aa.GetMass()
+		if residueMass == nil {
+			return b, y, fmt.Errorf("no mass for residue '%s' at position %d", aa.GetValue(), i)
+		}
+		mass := *residueMass
+		side := 0
+		if i >= position {
+			side = 1
+		}
+
+		for _, mod := range aa.mods {
+			if id := mod.GetCrosslinkID(); id != nil {
+				sides := crosslinkSides[*id]
+				sides[side] = true
+				crosslinkSides[*id] = sides
+			}
+			if counted[mod] || mod.IsCrosslinkRef() || mod.IsAmbiguityRef() {
+				continue
+			}
+			counted[mod] = true
+			if isRangeMod(mod, len(s.seq)) && *mod.GetRangeStart() < position && *mod.GetRangeEnd() >= position {
+				return b, y, fmt.Errorf("range modification '%s' spans the cleavage after residue %d", mod.GetValue(), position)
+			}
+			delta, err := modMass(mod, i)
+			if err != nil {
+				return b, y, err
+			}
+			mass += delta
+		}
+
+		if side == 0 {
+			nMass += mass
+		} else {
+			cMass += mass
+		}
+	}
+	for id, sides := range crosslinkSides {
+		if sides[0] && sides[1] {
+			return b, y, fmt.Errorf("crosslink '%s' spans the cleavage after residue %d", id, position)
+		}
+	}
+
+	for pos, globalMods := range s.GetGlobalModSites() {
+		for _, gm := range globalMods {
+			mass := gm.GetMass()
+			if mass == nil {
+				return b, y, fmt.Errorf("cannot resolve mass of global modification '%s' at position %d", gm.GetValue(), pos)
+			}
+			if pos < position {
+				nMass += *mass
+			} else {
+				cMass += *mass
+			}
+		}
+	}
+
+	// The pair must account for the whole peptide
+	total, err := s.GetNeutralMass()
+	if err != nil {
+		return b, y, err
+	}
+	if math.Abs(nMass+cMass-total) > 1e-6 {
+		return b, y, fmt.Errorf("fragment masses %f and %f do not add up to the neutral mass %f", nMass, cMass, total)
+	}
+
+	b = FragmentIon{Type: FragmentIonB, Number: position, Charge: charge, NeutralMass: nMass, Mz: config.Mz(nMass, charge)}
+	y = FragmentIon{Type: FragmentIonY, Number: len(s.seq) - position, Charge: charge, NeutralMass: cMass, Mz: config.Mz(cMass, charge)}
+	return b, y, nil
+}
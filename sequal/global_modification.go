@@ -10,6 +10,8 @@ type GlobalModification struct {
 	Modification
 	targetResidues []string
 	globalModType  string
+	// bare records a fixed modification written without brackets, as in "<+57.021@C>"
+	bare bool
 }
 
 // NewGlobalModification creates a new GlobalModification instance.
@@ -31,35 +33,73 @@ func NewGlobalModification(value string, targetResidues []string, modType string
 		nil, // regexPattern
 		nil, // fullName
 		"global",
-		false,              // labile
-		0,                  // labilNumber
-		0,                  // mass
-		false,              // allFilled
-		nil,                // crosslinkID
-		false,              // isCrosslinkRef
-		false,              // isBranchRef
-		false,              // isBranch
-		nil,                // ambiguityGroup
-		false,              // isAmbiguityRef
-		false,              // inRange
-		nil,                // rangeStart
-		nil,                // rangeEnd
-		nil,                // localizationScore
-		nil,                // modValue
-		positionConstraint, // positionConstraint (ProForma 2.1)
-		limitPerPosition,   // limitPerPosition (ProForma 2.1)
-		colocalizeKnown,    // colocalizeKnown (ProForma 2.1)
-		colocalizeUnknown,  // colocalizeUnknown (ProForma 2.1)
-		false,              // isIonType (ProForma 2.1)
+		false,                            // labile
+		0,                                // labilNumber
+		0,                                // mass
+		false,                            // allFilled
+		nil,                              // crosslinkID
+		false,                            // isCrosslinkRef
+		false,                            // isBranchRef
+		false,                            // isBranch
+		nil,                              // ambiguityGroup
+		false,                            // isAmbiguityRef
+		false,                            // inRange
+		nil,                              // rangeStart
+		nil,                              // rangeEnd
+		nil,                              // localizationScore
+		NewModificationValue(value, nil), // modValue without a mass, so names resolve
+		positionConstraint,               // positionConstraint (ProForma 2.1)
+		limitPerPosition,                 // limitPerPosition (ProForma 2.1)
+		colocalizeKnown,                  // colocalizeKnown (ProForma 2.1)
+		colocalizeUnknown,                // colocalizeUnknown (ProForma 2.1)
+		false,                            // isIonType (ProForma 2.1)
 	)
 
 	return &GlobalModification{
 		Modification:   *mod,
 		targetResidues: targetResidues,
 		globalModType:  modType,
+		bare:           strings.HasPrefix(value, "+") || strings.HasPrefix(value, "-"),
 	}
 }
 
+// GetMass returns the mass a fixed global modification adds at each site: its mass shift,
+// its formula mass or the mass of its name in the embedded Unimod table, as resolved by
+// Modification.GetResolvedMass. It returns nil for isotope labels and unresolvable names.
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("<[Carbamidomethyl]@C>PEPTCDE")
+//	fmt.Println(*seq.GetGlobalMods()[0].GetMass()) // 57.021464
+func (gm *GlobalModification) GetMass() *float64 {
+	if gm.globalModType == "isotope" {
+		return nil
+	}
+	return gm.GetResolvedMass()
+}
+
+// GetIsotopeLabels returns the labels of an isotope global modification, such as "15N".
+// ProForma writes each label in its own angle brackets, as in "<13C><15N>"; a comma
+// separated list such as "<13C,15N>" is also accepted, kept as one global modification and
+// written back unchanged. Fixed global modifications have no isotope labels.
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("<13C,15N>PEPTIDE")
+//	fmt.Println(seq.GetGlobalMods()[0].GetIsotopeLabels()) // [13C 15N]
+func (gm *GlobalModification) GetIsotopeLabels() []string {
+	if gm.globalModType != "isotope" {
+		return nil
+	}
+	labels := make([]string, 0)
+	for _, label := range strings.Split(gm.GetValue(), ",") {
+		if label = strings.TrimSpace(label); label != "" {
+			labels = append(labels, label)
+		}
+	}
+	return labels
+}
+
 // GetTargetResidues returns the target residue types
 func (gm *GlobalModification) GetTargetResidues() []string {
 	return gm.targetResidues
@@ -70,20 +110,103 @@ func (gm *GlobalModification) GetGlobalModType() string {
 	return gm.globalModType
 }
 
+// FindSites returns the residue positions in seq that a fixed global modification applies to.
+// A residue is a candidate when it matches one of the target residues (or a terminal
+// target such as "N-term:Q") and, if a position constraint is set (ProForma 2.1), also
+// matches the constraint. The constraint may list residues or the "N-term" and "C-term"
+// keywords.
+//
+// When a limit is set and more candidate sites exist than the limit allows, the first N
+// candidates ordered by position are kept. Isotope modifications have no sites.
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("<[Oxidation|Position:M|Limit:1]@M>MMMM")
+//	fmt.Println(seq.GetGlobalMods()[0].FindSites("MMMM")) // [0]
+func (gm *GlobalModification) FindSites(seq string) []int {
+	sites := make([]int, 0)
+	if gm.globalModType != "fixed" {
+		return sites
+	}
+
+	for i, residue := range seq {
+		aa := string(residue)
+		if !gm.matchesTarget(aa, i, len(seq)) {
+			continue
+		}
+		if len(gm.positionConstraint) > 0 && !gm.matchesPositionConstraint(aa, i, len(seq)) {
+			continue
+		}
+		sites = append(sites, i)
+	}
+
+	if gm.limitPerPosition != nil && *gm.limitPerPosition >= 0 && len(sites) > *gm.limitPerPosition {
+		sites = sites[:*gm.limitPerPosition]
+	}
+
+	return sites
+}
+
+// matchesTarget checks a residue at the given position against the target residues,
+// including terminal targets such as "N-term", "C-term" and "N-term:Q"
+func (gm *GlobalModification) matchesTarget(residue string, position int, seqLength int) bool {
+	for _, target := range gm.targetResidues {
+		terminal, aa, hasResidue := strings.Cut(target, ":")
+		switch terminal {
+		case "N-term":
+			if position == 0 && (!hasResidue || aa == residue) {
+				return true
+			}
+		case "C-term":
+			if position == seqLength-1 && (!hasResidue || aa == residue) {
+				return true
+			}
+		default:
+			if target == residue {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchesPositionConstraint checks a residue at the given position against the position constraint
+func (gm *GlobalModification) matchesPositionConstraint(residue string, position int, seqLength int) bool {
+	for _, constraint := range gm.positionConstraint {
+		switch constraint {
+		case "N-term":
+			if position == 0 {
+				return true
+			}
+		case "C-term":
+			if position == seqLength-1 {
+				return true
+			}
+		default:
+			if constraint == residue {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // ToProforma converts the modification to ProForma notation
 func (gm *GlobalModification) ToProforma() string {
+	return gm.ToProformaWithOptions(DefaultProformaOptions())
+}
+
+// ToProformaWithOptions converts the global modification to ProForma notation using the
+// given formatting options for mass shifts
+func (gm *GlobalModification) ToProformaWithOptions(opts ProformaOptions) string {
 	if gm.globalModType == "isotope" {
-		return fmt.Sprintf("<%s>", gm.Modification.ToProforma())
+		return fmt.Sprintf("<%s>", gm.Modification.ToProformaWithOptions(opts))
 	} else {
-		modValue := gm.Modification.ToProforma()
-		var modStr string
-		// Check if the modification is a mass (starts with + or -)
-		// If not, it's a named modification and needs brackets
-		if strings.HasPrefix(modValue, "+") || strings.HasPrefix(modValue, "-") {
-			modStr = modValue
-		} else {
-			// Named modification - wrap in brackets
-			modStr = fmt.Sprintf("[%s]", modValue)
+		// Brackets are kept or left out as parsed; NewGlobalModification leaves them out
+		// for mass shifts only
+		modStr := gm.Modification.ToProformaWithOptions(opts)
+		if !gm.bare {
+			modStr = fmt.Sprintf("[%s]", modStr)
 		}
 		targets := strings.Join(gm.targetResidues, ",")
 		return fmt.Sprintf("<%s@%s>", modStr, targets)
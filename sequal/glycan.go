@@ -0,0 +1,232 @@
+package sequal
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// glycanBlockAliases maps monosaccharide names accepted by the glycan grammar that are not
+// in GlycanBlockDict to the blocks they are composed of
+var glycanBlockAliases = map[string][]string{
+	"dHex":    {"Fuc"},
+	"Pen":     {"Pent"},
+	"HexS":    {"Hex", "Sulfo"},
+	"HexP":    {"Hex", "Phospho"},
+	"HexNAcS": {"HexNAc", "Sulfo"},
+}
+
+// glycanBlockPattern matches one monosaccharide of a glycan composition with its optional
+// count, either a standard name (longest first) or a custom "{Formula}" or "{Formula:z+N}" block
+var glycanBlockPattern = regexp.MustCompile(`^(?:(HexNAcS|HexNAc|NeuAc|NeuGc|HexS|HexP|dHex|Hex|Pen|Fuc)|\{([A-Za-z0-9\[\]]+(?::z[+-]\d+)?)\})(?:\((\d+)\)|(\d+))?`)
+
+// glycanBlockMass returns the monosaccharide mass of a glycan block from GlycanBlockDict,
+// resolving the names in glycanBlockAliases to their component blocks
+func glycanBlockMass(name string) (float64, bool) {
+	if mass, ok := GlycanBlockDict[name]; ok {
+		return mass, true
+	}
+	components, ok := glycanBlockAliases[name]
+	if !ok {
+		return 0, false
+	}
+	total := 0.0
+	for _, component := range components {
+		total += GlycanBlockDict[component]
+	}
+	return total, true
+}
+
+// parseGlycanComposition parses a glycan composition such as "HexNAc2Hex5Fuc" into
+// monosaccharide counts. Custom blocks are keyed by the formula inside their braces,
+// including any charge (e.g. "C8H13N1O5:z+1").
+func parseGlycanComposition(glycan string) (map[string]int, error) {
+	glycanClean := strings.ReplaceAll(glycan, " ", "")
+	if glycanClean == "" {
+		return nil, fmt.Errorf("empty glycan composition")
+	}
+
+	composition := make(map[string]int)
+	i := 0
+	for i < len(glycanClean) {
+		match := glycanBlockPattern.FindStringSubmatch(glycanClean[i:])
+		if match == nil {
+			return nil, fmt.Errorf("invalid monosaccharide at position %d in glycan '%s'", i, glycan)
+		}
+
+		name := match[1]
+		if name == "" {
+			name = match[2]
+		}
+		count := 1
+		if countStr := match[3] + match[4]; countStr != "" {
+			value, err := strconv.Atoi(countStr)
+			if err != nil || !validGlycanCount(countStr) {
+				return nil, fmt.Errorf("invalid count '%s' in glycan '%s'", countStr, glycan)
+			}
+			count = value
+		}
+
+		composition[name] += count
+		i += len(match[0])
+	}
+
+	return composition, nil
+}
+
+// glycanMonosaccharideMass returns the mass of one monosaccharide of a parsed glycan
+// composition, which is either a standard block or a custom formula block
+func glycanMonosaccharideMass(name string) (float64, error) {
+	if mass, ok := glycanBlockMass(name); ok {
+		return mass, nil
+	}
+
+	formula := name
+	charge := 0
+	if idx := strings.Index(name, ":z"); idx != -1 {
+		value, err := strconv.Atoi(name[idx+2:])
+		if err != nil {
+			return 0, fmt.Errorf("invalid charge in monosaccharide '%s'", name)
+		}
+		formula, charge = name[:idx], value
+	}
+	mass, err := CalculateFormulaMass(formula)
+	if err != nil {
+		return 0, err
+	}
+	return mass - float64(charge)*ElectronMass, nil
+}
+
+// getGlycanComposition returns the composition of a "Glycan:" modification, or nil if the
+// modification is not a valid glycan composition
+func (m *Modification) getGlycanComposition() map[string]int {
+	if m.modValue == nil {
+		return nil
+	}
+	composition, err := m.modValue.GetGlycanComposition()
+	if err != nil {
+		return nil
+	}
+	return composition
+}
+
+// isGlycan reports whether the modification is a glycan, written as a "Glycan:" composition
+// or a "GNO:" accession
+func (m *Modification) isGlycan() bool {
+	if m.modValue == nil {
+		return false
+	}
+	for _, pv := range m.modValue.pipeValues {
+		if pv.GetType() == PipeValueTypeGlycan {
+			return true
+		}
+		if source := pv.GetSource(); source != nil {
+			switch strings.ToUpper(*source) {
+			case "GLYCAN", "GNO", "G":
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// GetGlycanComposition returns the monosaccharide counts of a "Glycan:" modification.
+// Both implicit and explicit counts are accepted, and custom blocks are keyed by the
+// formula inside their braces. An error is returned if the value is not a valid glycan.
+//
+// Example:
+//
+//	mv := sequal.NewModificationValue("Glycan:HexNAc2Hex(3)Fuc", nil)
+//	composition, _ := mv.GetGlycanComposition()
+//	fmt.Println(composition) // map[Fuc:1 Hex:3 HexNAc:2]
+func (mv *ModificationValue) GetGlycanComposition() (map[string]int, error) {
+	for _, pv := range mv.pipeValues {
+		if pv.GetType() == PipeValueTypeGlycan && pv.IsValidGlycan() {
+			if composition, err := parseGlycanComposition(pv.GetValue()); err == nil {
+				return composition, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("modification '%s' is not a glycan composition", mv.primaryValue)
+}
+
+// GlycanToExpanded returns the glycan composition in its expanded form: standard
+// monosaccharides in sorted order followed by custom blocks, each with an explicit count
+// in parentheses. Compositions written differently but with the same monosaccharides give
+// the same result. An error is returned if the value is not a valid glycan.
+//
+// Example:
+//
+//	mv := sequal.NewModificationValue("Glycan:HexNAc2Hex3Fuc", nil)
+//	expanded, _ := mv.GlycanToExpanded()
+//	fmt.Println(expanded) // "Fuc(1)Hex(3)HexNAc(2)"
+func (mv *ModificationValue) GlycanToExpanded() (string, error) {
+	composition, err := mv.GetGlycanComposition()
+	if err != nil {
+		return "", err
+	}
+	return formatGlycanComposition(composition, true), nil
+}
+
+// GlycanToCompact returns the glycan composition in its compact form, ordered as in
+// GlycanToExpanded with counts written without parentheses. A count of one is only left
+// out on a final standard monosaccharide; custom blocks always keep their count.
+//
+// Example:
+//
+//	mv := sequal.NewModificationValue("Glycan:HexNAc(2)Hex(3)", nil)
+//	compact, _ := mv.GlycanToCompact()
+//	fmt.Println(compact) // "Hex3HexNAc2"
+func (mv *ModificationValue) GlycanToCompact() (string, error) {
+	composition, err := mv.GetGlycanComposition()
+	if err != nil {
+		return "", err
+	}
+	return formatGlycanComposition(composition, false), nil
+}
+
+// formatGlycanComposition writes a composition in expanded or compact notation, with the
+// standard monosaccharides sorted by name before the custom blocks
+func formatGlycanComposition(composition map[string]int, expanded bool) string {
+	var standard, custom []string
+	for _, name := range sortedGlycanBlocks(composition) {
+		if _, ok := glycanBlockMass(name); ok {
+			standard = append(standard, name)
+		} else {
+			custom = append(custom, name)
+		}
+	}
+
+	var sb strings.Builder
+	for i, name := range standard {
+		count := composition[name]
+		sb.WriteString(name)
+		switch {
+		case expanded:
+			fmt.Fprintf(&sb, "(%d)", count)
+		case count != 1 || i < len(standard)-1 || len(custom) > 0:
+			sb.WriteString(strconv.Itoa(count))
+		}
+	}
+	for _, name := range custom {
+		sb.WriteString("{" + name + "}")
+		if expanded {
+			fmt.Fprintf(&sb, "(%d)", composition[name])
+		} else {
+			sb.WriteString(strconv.Itoa(composition[name]))
+		}
+	}
+	return sb.String()
+}
+
+// sortedGlycanBlocks returns the monosaccharide names of a composition in sorted order
+func sortedGlycanBlocks(composition map[string]int) []string {
+	names := make([]string, 0, len(composition))
+	for name := range composition {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,570 @@
+package sequal
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Water is the monoisotopic mass of the H2O added by the peptide termini
+const Water = 2*H + O
+
+// Hydroxyl is the monoisotopic mass of the OH of a free C-terminus
+const Hydroxyl = O + H
+
+// SetTerminalMasses overrides the masses of the N-terminal and C-terminal groups used by
+// GetNeutralMass, which default to H and Hydroxyl for a free amine and carboxylic acid.
+// Terminal modifications such as "-[Amidated]" are still added on top of these groups, so
+// either describe a non-standard terminus with a modification or with its group mass, not
+// both. For example a head-to-tail cyclic peptide has no terminal groups, and an amidated
+// C-terminus without a modification has a group mass of NH2 (N + 2*H). On a multi-chain
+// sequence the masses are set for every chain.
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("PEPTIDE")
+//	seq.SetTerminalMasses(0, 0)
+//	mass, _ := seq.GetNeutralMass()
+//	fmt.Printf("%.4f\n", mass) // 781.3494
+func (s *Sequence) SetTerminalMasses(nTerm, cTerm float64) {
+	s.nTermMass = &nTerm
+	s.cTermMass = &cTerm
+	if s.isMultiChain {
+		for _, chain := range s.chains {
+			chain.SetTerminalMasses(nTerm, cTerm)
+		}
+	}
+}
+
+// GetTerminalMasses returns the N-terminal and C-terminal group masses used by GetNeutralMass
+func (s *Sequence) GetTerminalMasses() (float64, float64) {
+	nTerm, cTerm := H, Hydroxyl
+	if s.nTermMass != nil {
+		nTerm = *s.nTermMass
+	}
+	if s.cTermMass != nil {
+		cTerm = *s.cTermMass
+	}
+	return nTerm, cTerm
+}
+
+// GetNeutralMass calculates the monoisotopic neutral mass of the sequence: the residue
+// masses, the terminal groups (one water unless changed with SetTerminalMasses), every
+// modification with a resolvable mass (see Modification.GetResolvedMass) and the fixed
+// global modifications at the sites they apply to. Labile and unknown-position modifications
+// are included. A range modification is counted once, and crosslink and ambiguity references
+// are not counted again.
+//
+// With isotope global modifications such as "<15N>" the mass is calculated from the labeled
+// composition (see GetComposition), so every modification must then be given as a formula.
+//
+// For multi-chain sequences the masses of all chains are summed, each with its own
+// terminal groups. An error is returned when a modification mass cannot be resolved.
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("PEPTIDE")
+//	mass, _ := seq.GetNeutralMass()
+//	fmt.Printf("%.4f\n", mass) // 799.3600
+//
+//	labeled, _ := sequal.FromProforma("<15N>PEPTIDE")
+//	mass, _ = labeled.GetNeutralMass()
+//	fmt.Printf("%.4f\n", mass) // 806.3392
+func (s *Sequence) GetNeutralMass() (float64, error) {
+	if s.isMultiChain && len(s.chains) > 0 {
+		total := 0.0
+		for i, chain := range s.chains {
+			mass, err := chain.GetNeutralMass()
+			if err != nil {
+				return 0, fmt.Errorf("chain %d: %w", i, err)
+			}
+			total += mass
+		}
+		return total, nil
+	}
+
+	for _, gm := range s.globalMods {
+		if gm.GetGlobalModType() == "isotope" {
+			composition, err := s.GetComposition()
+			if err != nil {
+				return 0, fmt.Errorf("isotope labeled mass needs a composition: %w", err)
+			}
+			return compositionMass(composition)
+		}
+	}
+
+	nTerm, cTerm := s.GetTerminalMasses()
+	total := nTerm + cTerm
+	for i, aa := range s.seq {
+		mass := aa.GetMass()
+		if mass == nil {
+			return 0, fmt.Errorf("no mass for residue '%s' at position %d", aa.GetValue(), i)
+		}
+		total += *mass
+	}
+
+	counted := make(map[*Modification]bool)
+	for _, loc := range s.GetModificationsByType("") {
+		mod := loc.Modification
+		if counted[mod] || mod.IsCrosslinkRef() || mod.IsAmbiguityRef() {
+			continue
+		}
+		counted[mod] = true
+		mass := mod.GetResolvedMass()
+		if mass == nil {
+			return 0, fmt.Errorf("cannot resolve mass of modification '%s' at position %d", mod.GetValue(), loc.Position)
+		}
+		total += *mass
+	}
+
+	for pos, globalMods := range s.GetGlobalModSites() {
+		for _, gm := range globalMods {
+			mass := gm.GetResolvedMass()
+			if mass == nil {
+				return 0, fmt.Errorf("cannot resolve mass of global modification '%s' at position %d", gm.GetValue(), pos)
+			}
+			total += *mass
+		}
+	}
+
+	return total, nil
+}
+
+// GetComposition returns the elemental composition of the neutral sequence: the residues from
+// AAComposition, one water for the termini and the formulas of the modifications and fixed
+// global modifications counted as in GetNeutralMass. Isotopes are keyed by mass number and
+// symbol as in ParseFormula, and isotope global modifications such as "<13C>" move all atoms
+// of their element to the isotope. An error is returned for residues without a known
+// composition, for modifications that are not expressed as a formula (e.g. "[+79.966]" or
+// "[Phospho]"), for unknown isotope labels and when terminal masses were set with
+// SetTerminalMasses.
+// For multi-chain sequences the compositions of all chains are summed.
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("PEPS[Formula:HPO3]")
+//	composition, _ := seq.GetComposition()
+//	fmt.Println(composition) // map[C:18 H:29 N:4 O:11 P:1]
+func (s *Sequence) GetComposition() (map[string]int, error) {
+	composition := make(map[string]int)
+	add := func(counts map[string]int) {
+		for element, count := range counts {
+			composition[element] += count
+			if composition[element] == 0 {
+				delete(composition, element)
+			}
+		}
+	}
+
+	if s.isMultiChain && len(s.chains) > 0 {
+		for i, chain := range s.chains {
+			counts, err := chain.GetComposition()
+			if err != nil {
+				return nil, fmt.Errorf("chain %d: %w", i, err)
+			}
+			add(counts)
+		}
+		return composition, nil
+	}
+
+	if s.nTermMass != nil || s.cTermMass != nil {
+		return nil, fmt.Errorf("terminal groups set by mass have no composition")
+	}
+
+	add(map[string]int{"H": 2, "O": 1})
+	for i, aa := range s.seq {
+		residue, ok := AAComposition[aa.GetValue()]
+		if !ok {
+			return nil, fmt.Errorf("no composition for residue '%s' at position %d", aa.GetValue(), i)
+		}
+		add(residue)
+	}
+
+	addFormula := func(mod *Modification, position int) error {
+		formula := mod.getFormula()
+		if formula == "" {
+			return fmt.Errorf("modification '%s' at position %d has no formula", mod.GetValue(), position)
+		}
+		counts, err := ParseFormula(formula)
+		if err != nil {
+			return err
+		}
+		add(counts)
+		return nil
+	}
+
+	counted := make(map[*Modification]bool)
+	for _, loc := range s.GetModificationsByType("") {
+		mod := loc.Modification
+		if counted[mod] || mod.IsCrosslinkRef() || mod.IsAmbiguityRef() {
+			continue
+		}
+		counted[mod] = true
+		if err := addFormula(mod, loc.Position); err != nil {
+			return nil, err
+		}
+	}
+
+	for pos, globalMods := range s.GetGlobalModSites() {
+		for _, gm := range globalMods {
+			if err := addFormula(&gm.Modification, pos); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Isotope labels replace every atom of their element, e.g. "<15N>" turns N into 15N
+	for _, gm := range s.globalMods {
+		for _, label := range gm.GetIsotopeLabels() {
+			element, isotope, err := isotopeLabel(label)
+			if err != nil {
+				return nil, err
+			}
+			if count, ok := composition[element]; ok {
+				delete(composition, element)
+				add(map[string]int{isotope: count})
+			}
+		}
+	}
+
+	return composition, nil
+}
+
+// GetFormulaString returns the composition from GetComposition as a Hill system formula:
+// carbon first, then hydrogen, then the other elements in alphabetical order. Without carbon
+// all elements, hydrogen included, are alphabetical. Isotopes follow their element and are
+// written in the ProForma form "[13C2]".
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("PEPTIDE")
+//	formula, _ := seq.GetFormulaString()
+//	fmt.Println(formula) // "C34H53N7O15"
+func (s *Sequence) GetFormulaString() (string, error) {
+	composition, err := s.GetComposition()
+	if err != nil {
+		return "", err
+	}
+	return hillFormula(composition), nil
+}
+
+// MassConfig holds the masses used to turn a neutral mass into an m/z. An ion of charge z
+// has m/z = (M + z*(ChargeCarrierMass - ElectronMass)) / z, where M is the neutral mass.
+type MassConfig struct {
+	// ChargeCarrierMass is the mass added for each charge: Proton by default, or H for
+	// tools that add a hydrogen atom per charge.
+	ChargeCarrierMass float64
+
+	// ElectronMass is removed for each charge. It is zero by default since Proton carries no
+	// electron; set it to ElectronMass together with a ChargeCarrierMass of H to correct a
+	// hydrogen atom for its electron.
+	ElectronMass float64
+}
+
+// DefaultMassConfig returns the configuration used by the m/z methods that take no
+// MassConfig, such as GetPrecursorMz, adding Proton for each charge
+func DefaultMassConfig() MassConfig {
+	return MassConfig{ChargeCarrierMass: Proton}
+}
+
+// Mz returns the m/z of an ion of the given neutral mass and charge,
+// (mass + charge*(ChargeCarrierMass - ElectronMass)) / charge
+//
+// Example:
+//
+//	hydrogen := sequal.MassConfig{ChargeCarrierMass: sequal.H}
+//	fmt.Printf("%.6f\n", hydrogen.Mz(799.36, 2)-sequal.DefaultMassConfig().Mz(799.36, 2)) // 0.000548
+func (c MassConfig) Mz(mass float64, charge int) float64 {
+	z := float64(charge)
+	return (mass + z*(c.ChargeCarrierMass-c.ElectronMass)) / z
+}
+
+// GetPrecursorMz calculates the m/z of the protonated precursor ion at the given charge,
+// (M + z*Proton) / z, where M is the neutral mass from GetNeutralMass.
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("PEPTIDE")
+//	mz, _ := seq.GetPrecursorMz(2)
+//	fmt.Printf("%.4f\n", mz) // 400.6873
+func (s *Sequence) GetPrecursorMz(charge int) (float64, error) {
+	return s.GetPrecursorMzWithConfig(charge, DefaultMassConfig())
+}
+
+// GetPrecursorMzWithConfig calculates the m/z of the precursor ion at the given charge with
+// the masses of config (see MassConfig.Mz).
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("PEPTIDE")
+//	corrected := sequal.MassConfig{ChargeCarrierMass: sequal.H, ElectronMass: sequal.ElectronMass}
+//	mz, _ := seq.GetPrecursorMzWithConfig(2, corrected)
+//	fmt.Printf("%.4f\n", mz) // 400.6873
+func (s *Sequence) GetPrecursorMzWithConfig(charge int, config MassConfig) (float64, error) {
+	if charge <= 0 {
+		return 0, fmt.Errorf("charge must be positive, got %d", charge)
+	}
+	mass, err := s.GetNeutralMass()
+	if err != nil {
+		return 0, err
+	}
+	return config.Mz(mass, charge), nil
+}
+
+// GetMzForCharges calculates the m/z of the protonated precursor ion for each charge from
+// minZ to maxZ inclusive, as in GetPrecursorMz, keyed by charge. The neutral mass is computed
+// once. An error is returned if minZ is not positive, maxZ is below minZ or the neutral mass
+// cannot be calculated.
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("PEPTIDE")
+//	mzs, _ := seq.GetMzForCharges(2, 4)
+//	fmt.Printf("%.4f %.4f %.4f\n", mzs[2], mzs[3], mzs[4]) // 400.6873 267.4606 200.8473
+func (s *Sequence) GetMzForCharges(minZ, maxZ int) (map[int]float64, error) {
+	return s.GetMzForChargesWithConfig(minZ, maxZ, DefaultMassConfig())
+}
+
+// GetMzForChargesWithConfig calculates the precursor m/z for each charge from minZ to maxZ
+// inclusive, as in GetMzForCharges, with the masses of config (see MassConfig.Mz).
+func (s *Sequence) GetMzForChargesWithConfig(minZ, maxZ int, config MassConfig) (map[int]float64, error) {
+	if minZ <= 0 {
+		return nil, fmt.Errorf("charge must be positive, got %d", minZ)
+	}
+	if maxZ < minZ {
+		return nil, fmt.Errorf("invalid charge range %d to %d", minZ, maxZ)
+	}
+	mass, err := s.GetNeutralMass()
+	if err != nil {
+		return nil, err
+	}
+
+	mzs := make(map[int]float64, maxZ-minZ+1)
+	for charge := minZ; charge <= maxZ; charge++ {
+		mzs[charge] = config.Mz(mass, charge)
+	}
+	return mzs, nil
+}
+
+// MatchesPrecursor reports whether the theoretical precursor m/z of the sequence at the
+// given charge is within tolerancePpm of observedMz, along with the ppm error
+// (observed - theoretical) / theoretical * 1e6. If the theoretical m/z cannot be
+// calculated it returns false and NaN.
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("PEPTIDE")
+//	ok, ppm := seq.MatchesPrecursor(400.6875, 2, 10)
+//	fmt.Printf("%v %.2f\n", ok, ppm) // true 0.60
+func (s *Sequence) MatchesPrecursor(observedMz float64, charge int, tolerancePpm float64) (bool, float64) {
+	return s.MatchesPrecursorWithConfig(observedMz, charge, tolerancePpm, DefaultMassConfig())
+}
+
+// MatchesPrecursorWithConfig is MatchesPrecursor with the theoretical m/z calculated from
+// the masses of config, as in GetPrecursorMzWithConfig.
+func (s *Sequence) MatchesPrecursorWithConfig(observedMz float64, charge int, tolerancePpm float64, config MassConfig) (bool, float64) {
+	theoretical, err := s.GetPrecursorMzWithConfig(charge, config)
+	if err != nil {
+		return false, math.NaN()
+	}
+	ppmError := (observedMz - theoretical) / theoretical * 1e6
+	return math.Abs(ppmError) <= tolerancePpm, ppmError
+}
+
+// CountModificationsWithMass counts the residue and terminal modifications, including those
+// at an unknown terminus, whose resolved mass (see Modification.GetResolvedMass) is within
+// toleranceDa of target. Named modifications and raw mass shifts are both matched. A range
+// modification is counted once, and crosslink and ambiguity references are not counted. All
+// chains of a multi-chain sequence and all peptidoforms of a chimeric one are counted.
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("PEPS[Phospho]T[+79.966]IDE")
+//	fmt.Println(seq.CountModificationsWithMass(79.966, 0.01)) // 2
+func (s *Sequence) CountModificationsWithMass(target float64, toleranceDa float64) int {
+	count := 0
+	for _, part := range s.partsOrSelf() {
+		counted := make(map[*Modification]bool)
+		for _, loc := range part.GetModificationsByType("") {
+			mod := loc.Modification
+			switch loc.Side {
+			case TerminalSideLabile, TerminalSideUnknown:
+				continue
+			}
+			if !countableModification(mod, counted) {
+				continue
+			}
+			if mass := mod.GetResolvedMass(); mass != nil && math.Abs(*mass-target) <= toleranceDa {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// GetAllMassShifts returns the resolved mass (see Modification.GetResolvedMass) of every
+// residue and terminal modification, including those at an unknown terminus, in sequence
+// order. Named modifications, formulas and raw mass shifts all contribute; modifications whose
+// mass cannot be resolved are skipped, as are labile, unknown-position and global
+// modifications. A range modification is included once, crosslink and ambiguity references
+// are not included, and the chains of a multi-chain sequence or the peptidoforms of a
+// chimeric one follow each other.
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("[Acetyl]-PEPS[+79.966]M[Oxidation]K[Custom]")
+//	fmt.Println(seq.GetAllMassShifts()) // [42.010565 79.966 15.994915]
+func (s *Sequence) GetAllMassShifts() []float64 {
+	shifts := make([]float64, 0)
+	for _, part := range s.partsOrSelf() {
+		counted := make(map[*Modification]bool)
+		for _, loc := range part.GetModificationsByType("") {
+			mod := loc.Modification
+			switch loc.Side {
+			case TerminalSideLabile, TerminalSideUnknown:
+				continue
+			}
+			if !countableModification(mod, counted) {
+				continue
+			}
+			if mass := mod.GetResolvedMass(); mass != nil {
+				shifts = append(shifts, *mass)
+			}
+		}
+	}
+	return shifts
+}
+
+// GetResidueMasses returns the mass of each residue plus the modifications attached to it,
+// indexed like GetSeq, as the basis for fragment ladders or per-position mass plots. Fixed
+// global modifications are added to the residues they apply to, and a range modification is
+// added once, to the first residue of its range. Terminal groups and terminal, labile and
+// unknown-position modifications are not included, so the masses add up to GetNeutralMass
+// only for sequences without them, less the water of the termini. An error is returned for
+// multi-chain and chimeric sequences, isotope labels, residues without a mass and
+// modifications whose mass cannot be resolved.
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("PEP[+79.966]TIDE")
+//	masses, _ := seq.GetResidueMasses()
+//	fmt.Printf("%.4f\n", masses[2]) // 177.0188
+func (s *Sequence) GetResidueMasses() ([]float64, error) {
+	if s.isMultiChain || s.isChimeric {
+		return nil, fmt.Errorf("residue masses need a single peptidoform")
+	}
+	for _, gm := range s.globalMods {
+		if gm.GetGlobalModType() == "isotope" {
+			return nil, fmt.Errorf("residue masses of isotope labeled sequences are not supported")
+		}
+	}
+
+	masses := make([]float64, len(s.seq))
+	counted := make(map[*Modification]bool)
+	for i, aa := range s.seq {
+		mass := aa.GetMass()
+		if mass == nil {
+			return nil, fmt.Errorf("no mass for residue '%s' at position %d", aa.GetValue(), i)
+		}
+		masses[i] = *mass
+
+		for _, mod := range aa.mods {
+			if !countableModification(mod, counted) {
+				continue
+			}
+			delta := mod.GetResolvedMass()
+			if delta == nil {
+				return nil, fmt.Errorf("cannot resolve mass of modification '%s' at position %d", mod.GetValue(), i)
+			}
+			masses[i] += *delta
+		}
+	}
+
+	for pos, globalMods := range s.GetGlobalModSites() {
+		for _, gm := range globalMods {
+			mass := gm.GetMass()
+			if mass == nil {
+				return nil, fmt.Errorf("cannot resolve mass of global modification '%s' at position %d", gm.GetValue(), pos)
+			}
+			masses[pos] += *mass
+		}
+	}
+
+	return masses, nil
+}
+
+// MassAnnotation lists the named modifications matching a mass shift written without a name,
+// such as "[+79.966]", at a location. Candidates are sorted by absolute error.
+type MassAnnotation struct {
+	Position     int
+	Side         TerminalSide
+	Modification *Modification
+	Delta        float64
+	Candidates   []MassCandidate
+}
+
+// MassCandidate is a named modification whose mass is within tolerance of a mass shift.
+// Error is the mass shift minus the modification mass.
+type MassCandidate struct {
+	Name  string
+	Mass  float64
+	Error float64
+}
+
+// AnnotateMassShifts proposes names for the modifications given only as a mass shift, such as
+// "[+79.966]", "[U:+79.966]" or "[Obs:+79.978]", by matching them against dict, a map of
+// modification names to masses (e.g. a subset of Unimod), within toleranceDa. Every mass
+// shift is reported, with no candidates when nothing matches; named modifications are not.
+// A mass pipe value is preferred over an observed mass. Candidates with equal errors are
+// ordered by name. Results follow the order of GetModificationsByType, and a range
+// modification is reported once.
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("PEPS[+79.966]TIDE")
+//	dict := map[string]float64{"Phospho": 79.966331, "Sulfo": 79.956815}
+//	for _, c := range seq.AnnotateMassShifts(dict, 0.02)[0].Candidates {
+//		fmt.Printf("%s %.4f\n", c.Name, c.Error)
+//	}
+//	// Phospho -0.0003
+//	// Sulfo 0.0092
+func (s *Sequence) AnnotateMassShifts(dict map[string]float64, toleranceDa float64) []MassAnnotation {
+	annotations := make([]MassAnnotation, 0)
+	seen := make(map[*Modification]bool)
+
+	for _, loc := range s.GetModificationsByType("") {
+		mod := loc.Modification
+		if seen[mod] || mod.IsCrosslinkRef() || mod.IsAmbiguityRef() {
+			continue
+		}
+		seen[mod] = true
+		delta, ok := mod.massShift()
+		if !ok {
+			continue
+		}
+
+		candidates := make([]MassCandidate, 0)
+		for name, mass := range dict {
+			if math.Abs(delta-mass) <= toleranceDa {
+				candidates = append(candidates, MassCandidate{Name: name, Mass: mass, Error: delta - mass})
+			}
+		}
+		sort.Slice(candidates, func(i, j int) bool {
+			ei, ej := math.Abs(candidates[i].Error), math.Abs(candidates[j].Error)
+			if ei != ej {
+				return ei < ej
+			}
+			return candidates[i].Name < candidates[j].Name
+		})
+
+		annotations = append(annotations, MassAnnotation{
+			Position:     loc.Position,
+			Side:         loc.Side,
+			Modification: mod,
+			Delta:        delta,
+			Candidates:   candidates,
+		})
+	}
+
+	return annotations
+}
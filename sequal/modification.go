@@ -41,11 +41,16 @@ type Modification struct {
 
 	// ProForma 2.1: Ion notation (Section 11.6)
 	isIonType bool // Indicates if this is an ion type modification (a-type-ion, b-type-ion, etc.)
+
+	// Byte offsets of the annotation within the parsed ProForma string
+	sourceStart   int
+	sourceEnd     int
+	hasSourceSpan bool
 }
 
 // KnownSources is a set of recognized modification source databases
 var KnownSources = map[string]bool{
-	"Unimod": true, "U": true, "PSI-MOD": true, "M": true,
+	"Unimod": true, "UNIMOD": true, "U": true, "PSI-MOD": true, "M": true,
 	"RESID": true, "R": true, "XL-MOD": true, "X": true,
 	"XLMOD": true, "GNO": true, "G": true, "MOD": true,
 	"Obs": true, "Formula": true, "FORMULA": true, "GLYCAN": true,
@@ -166,7 +171,7 @@ func NewModification(value string, position *int, regexPattern *string, fullName
 	if modType == "labile" {
 		mod.labile = true
 	}
-	if inRange {
+	if inRange && modType != "crosslink" {
 		mod.modType = "ambiguous"
 	}
 
@@ -193,6 +198,107 @@ func (m *Modification) GetMass() *float64 {
 	return m.BaseBlock.GetMass()
 }
 
+// GetResolvedMass returns the mass of the modification, falling back to formula pipe values
+// and then the embedded Unimod table when the modification carries no explicit mass.
+// Explicit masses, including mass pipe values such as "U:Phospho|+79.966331" and masses
+// written with a source such as "U:+79.966", take precedence. Charged formulas (ProForma
+// 2.1) resolve to the charged-species mass. Values with a non-Unimod source (e.g. "M:" for
+// PSI-MOD) are not looked up in Unimod.
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("S[U:Phospho]")
+//	mod := seq.GetSeq()[0].GetMods()[0]
+//	fmt.Println(*mod.GetResolvedMass()) // 79.966331
+func (m *Modification) GetResolvedMass() *float64 {
+	if mass := m.GetMass(); mass != nil {
+		return mass
+	}
+	if m.modValue == nil {
+		return nil
+	}
+
+	for _, pv := range m.modValue.GetPipeValues() {
+		if pv.GetType() == PipeValueTypeMass && pv.GetMass() != nil {
+			return pv.GetMass()
+		}
+	}
+
+	for _, pv := range m.modValue.GetPipeValues() {
+		if pv.GetType() == PipeValueTypeFormula && pv.GetSource() != nil && strings.ToUpper(*pv.GetSource()) == "FORMULA" {
+			if mass := pv.GetChargedMass(); mass != nil {
+				return mass
+			}
+		}
+	}
+
+	if !isUnimodSource(m.modValue.GetSource()) {
+		return nil
+	}
+
+	if entry, ok := resolveUnimodValue(m.modValue.GetSource(), m.modValue.GetPrimaryValue()); ok {
+		mass := entry.MonoMass
+		return &mass
+	}
+	for _, pv := range m.modValue.GetPipeValues() {
+		if pv.GetType() != PipeValueTypeSynonym || !isUnimodSource(pv.GetSource()) {
+			continue
+		}
+		if entry, ok := resolveUnimodValue(pv.GetSource(), pv.GetValue()); ok {
+			mass := entry.MonoMass
+			return &mass
+		}
+	}
+
+	return nil
+}
+
+// massShift returns the mass of a modification given only as a mass shift, such as "+79.966",
+// "U:+79.966" or "Obs:+79.978", ignoring info tags. A mass pipe value is preferred over an
+// observed mass.
+func (m *Modification) massShift() (float64, bool) {
+	if m.modValue == nil {
+		return 0, false
+	}
+	var mass, observed *float64
+	for _, pv := range m.modValue.GetPipeValues() {
+		switch pv.GetType() {
+		case PipeValueTypeMass:
+			if mass == nil {
+				mass = pv.GetMass()
+			}
+		case PipeValueTypeObservedMass:
+			if observed == nil {
+				observed = pv.GetObservedMass()
+			}
+		case PipeValueTypeInfoTag:
+		default:
+			return 0, false
+		}
+	}
+	if mass != nil {
+		return *mass, true
+	}
+	if observed != nil {
+		return *observed, true
+	}
+	return 0, false
+}
+
+// getFormula returns the chemical formula of a "Formula:" modification, or "" if the
+// modification is not expressed as a formula
+func (m *Modification) getFormula() string {
+	if m.modValue == nil {
+		return ""
+	}
+	for _, pv := range m.modValue.GetPipeValues() {
+		if pv.GetType() == PipeValueTypeFormula && pv.GetSource() != nil && strings.ToUpper(*pv.GetSource()) == "FORMULA" {
+			return pv.GetValue()
+		}
+	}
+	return ""
+}
+
 // GetObservedMass returns the observed mass of the modification if available.
 // This is only available through the ModificationValue.
 func (m *Modification) GetObservedMass() *float64 {
@@ -233,6 +339,13 @@ func (m *Modification) GetInfoTags() []string {
 	return m.modValue.GetInfoTags()
 }
 
+// GetInfoMap returns the info tags of this modification as key=value pairs.
+// For "[Phospho|INFO:reaction=NHS]" it returns {"reaction": "NHS"}.
+// Tags without "=" are stored under the empty key "".
+func (m *Modification) GetInfoMap() map[string]string {
+	return m.modValue.GetInfoMap()
+}
+
 // GetCrosslinkID returns the crosslink identifier if this is a crosslink modification.
 func (m *Modification) GetCrosslinkID() *string {
 	if m.modValue != nil {
@@ -249,6 +362,30 @@ func (m *Modification) IsCrosslinkRef() bool {
 	return m.isCrosslinkRef
 }
 
+// GetLocalizationScore returns the localization score of an ambiguity group or crosslink
+// member, as in "[Phospho#g1(0.90)]" or "[#XL1(0.90)]".
+func (m *Modification) GetLocalizationScore() *float64 {
+	if m.modValue != nil {
+		return m.modValue.GetLocalizationScore()
+	}
+	return m.localizationScore
+}
+
+// IsInRange returns true if this modification applies to a range of residues, as in "(PEP)[+79.966]".
+func (m *Modification) IsInRange() bool {
+	return m.inRange
+}
+
+// GetRangeStart returns the index of the first residue of a range modification.
+func (m *Modification) GetRangeStart() *int {
+	return m.rangeStart
+}
+
+// GetRangeEnd returns the index of the last residue of a range modification.
+func (m *Modification) GetRangeEnd() *int {
+	return m.rangeEnd
+}
+
 // GetSource returns the modification database source (e.g., "Unimod", "PSI-MOD").
 func (m *Modification) GetSource() *string {
 	if m.modValue != nil {
@@ -257,6 +394,51 @@ func (m *Modification) GetSource() *string {
 	return m.source
 }
 
+// GetSources returns the distinct sources of all pipe separated values of the modification,
+// in the order written, so "[U:Phospho|M:MOD:00046]" gives ["U", "M"]. Values without a
+// source are skipped. GetSource returns only the first.
+func (m *Modification) GetSources() []string {
+	if m.modValue == nil {
+		if m.source != nil {
+			return []string{*m.source}
+		}
+		return nil
+	}
+	var sources []string
+	seen := make(map[string]bool)
+	for _, pv := range m.modValue.GetPipeValues() {
+		source := pv.GetSource()
+		if source == nil || seen[*source] {
+			continue
+		}
+		seen[*source] = true
+		sources = append(sources, *source)
+	}
+	return sources
+}
+
+// canonicalizeSources rewrites the sources of the modification and its pipe values that
+// are keys of names
+func (m *Modification) canonicalizeSources(names map[string]string) {
+	rename := func(source *string) *string {
+		if source == nil {
+			return nil
+		}
+		if canonical, ok := names[*source]; ok {
+			return &canonical
+		}
+		return source
+	}
+	m.source = rename(m.source)
+	if m.modValue == nil {
+		return
+	}
+	m.modValue.source = rename(m.modValue.source)
+	for _, pv := range m.modValue.pipeValues {
+		pv.source = rename(pv.source)
+	}
+}
+
 // GetOriginalValue returns the original modification value including any source prefix.
 func (m *Modification) GetOriginalValue() string {
 	return m.originalValue
@@ -317,6 +499,52 @@ func (m *Modification) IsIonType() bool {
 	return m.isIonType
 }
 
+// GetSourceSpan returns the [start, end) byte offsets of the annotation this modification
+// was parsed from, including its enclosing brackets or braces (or the whole "<...>" for
+// global modifications). The last value is false for modifications that were not parsed.
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("PEPT[Phospho]IDE")
+//	start, end, _ := seq.GetSeq()[3].GetMods()[0].GetSourceSpan()
+//	fmt.Println(start, end) // 4 13
+func (m *Modification) GetSourceSpan() (int, int, bool) {
+	return m.sourceStart, m.sourceEnd, m.hasSourceSpan
+}
+
+// SetSourceSpan sets the [start, end) byte offsets of the annotation this modification was parsed from
+func (m *Modification) SetSourceSpan(start, end int) {
+	m.sourceStart = start
+	m.sourceEnd = end
+	m.hasSourceSpan = true
+}
+
+// Clone returns a deep copy of the modification. The modification value, its pipe values
+// and the position constraint are copied, so changes to the clone do not affect m.
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("PEPS[Phospho|+79.966]IDE")
+//	mod := seq.GetSeq()[3].GetMods()[0]
+//	clone := mod.Clone()
+//	clone.GetModificationValue().GetPipeValues()[1].SetMass(80)
+//	fmt.Println(*mod.GetModificationValue().GetPipeValues()[1].GetMass()) // 79.966
+func (m *Modification) Clone() *Modification {
+	if m == nil {
+		return nil
+	}
+	clone := *m
+	if impl, ok := m.BaseBlock.(*BaseBlockImpl); ok {
+		block := *impl
+		clone.BaseBlock = &block
+	}
+	clone.modValue = m.modValue.clone()
+	if m.positionConstraint != nil {
+		clone.positionConstraint = append([]string(nil), m.positionConstraint...)
+	}
+	return &clone
+}
+
 // FindPositions finds positions of the modification in the given sequence
 func (m *Modification) FindPositions(seq string) [][]int {
 	if m.regex == nil {
@@ -466,45 +694,64 @@ func (m *Modification) HasBranch() bool {
 //		nil, nil, false, false, false)
 //	fmt.Println(mod.ToProforma()) // "Phospho"
 func (m *Modification) ToProforma() string {
+	return m.ToProformaWithOptions(DefaultProformaOptions())
+}
+
+// ToProformaWithOptions converts the modification to ProForma notation using the given
+// formatting options for mass shifts.
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("PEPT[+79.9663]IDE")
+//	mod := seq.GetSeq()[3].GetMods()[0]
+//	precision := 2
+//	fmt.Println(mod.ToProformaWithOptions(sequal.ProformaOptions{MassPrecision: &precision})) // "+79.97"
+func (m *Modification) ToProformaWithOptions(opts ProformaOptions) string {
 	if m.modValue != nil {
 		seen := map[string]bool{}
 		parts := []string{}
 
 		for _, pv := range m.modValue.GetPipeValues() {
+			// A named reference such as "DSS#XL1" is written from its name, and the ID is
+			// appended below
+			reference := false
+			switch pv.GetType() {
+			case PipeValueTypeCrosslink, PipeValueTypeBranch, PipeValueTypeAmbiguity:
+				reference = strings.Contains(pv.GetValue(), "#")
+			}
+
 			modPart := ""
 			if pv.GetSource() != nil {
 				modPart = *pv.GetSource() + ":"
 				if pv.GetMass() != nil {
-					mass := *pv.GetMass()
-					if mass > 0 {
-						modPart += fmt.Sprintf("+%g", mass)
-						seen[fmt.Sprintf("+%g", mass)] = true
-					} else if mass < 0 {
-						modPart += fmt.Sprintf("%g", mass)
-						seen[fmt.Sprintf("%g", mass)] = true
+					if massStr := opts.formatPipeMass(pv); massStr != "" {
+						modPart += massStr
+						seen[massStr] = true
 					}
+				} else if reference {
+					name, _, _ := strings.Cut(pv.GetValue(), "#")
+					modPart += name
 				} else {
-					modPart += pv.GetValue()
+					modPart += opts.formatGlycan(pv)
 				}
 			} else {
 				if pv.GetMass() != nil {
-					mass := *pv.GetMass()
-					if mass > 0 {
-						modPart = fmt.Sprintf("+%g", mass)
-					} else if mass < 0 {
-						modPart = fmt.Sprintf("%g", mass)
-					}
+					modPart = opts.formatPipeMass(pv)
 				} else if pv.GetType() == PipeValueTypeSynonym {
 					modPart = pv.GetValue()
-				} else {
-					if !strings.Contains(pv.GetValue(), "#") {
-						modPart = pv.GetValue()
-					}
+				} else if reference {
+					modPart, _, _ = strings.Cut(pv.GetValue(), "#")
+				} else if !strings.Contains(pv.GetValue(), "#") {
+					modPart = pv.GetValue()
 				}
 			}
+			name := modPart
 
 			if pv.GetType() == PipeValueTypeCrosslink && pv.GetCrosslinkID() != nil {
 				modPart += "#" + *pv.GetCrosslinkID()
+				if pv.GetLocalizationScore() != nil {
+					modPart += fmt.Sprintf("(%.2f)", *pv.GetLocalizationScore())
+				}
 			} else if pv.GetType() == PipeValueTypeBranch && pv.IsBranch() {
 				modPart += "#BRANCH"
 			} else if pv.GetType() == PipeValueTypeAmbiguity && pv.GetAmbiguityGroup() != nil {
@@ -520,11 +767,24 @@ func (m *Modification) ToProforma() string {
 				modPart += ":" + *pv.GetCharge()
 			}
 
-			if _, exists := seen[modPart]; exists || modPart == "" {
+			if modPart == "" {
+				continue
+			}
+			// The name of a reference is also stored as the preceding synonym, so "DSS#XL1" is
+			// written once rather than as "DSS|DSS#XL1"
+			if reference && name != "" && name != modPart && len(parts) > 0 && parts[len(parts)-1] == name {
+				parts[len(parts)-1] = modPart
+				seen[modPart] = true
 				continue
 			}
+			// Info tags are free text, so repeated tags are kept in their original order
+			if pv.GetType() != PipeValueTypeInfoTag {
+				if seen[modPart] {
+					continue
+				}
+				seen[modPart] = true
+			}
 			parts = append(parts, modPart)
-			seen[modPart] = true
 		}
 
 		// ProForma 2.1: Add placement control tags (Section 11.2)
@@ -0,0 +1,255 @@
+package sequal
+
+import "encoding/json"
+
+// TerminalSide describes where in a sequence a modification is attached
+type TerminalSide string
+
+// Constants for the sides a modification can be attached to. They correspond to the
+// -1 (N-terminal), -2 (C-terminal), -3 (labile), -4 (unknown position) and -5 (unknown
+// terminus) slots of the modifications map, and to residue positions (0 and above).
+const (
+	TerminalSideNTerm           TerminalSide = "n-term"
+	TerminalSideCTerm           TerminalSide = "c-term"
+	TerminalSideLabile          TerminalSide = "labile"
+	TerminalSideUnknown         TerminalSide = "unknown"
+	TerminalSideUnknownTerminus TerminalSide = "unknown-terminus"
+	TerminalSideResidue         TerminalSide = "residue"
+)
+
+// ModificationLocation pairs a modification with the position and side it is attached to.
+// Position is the residue index for residue modifications, or the -1 to -5 slot otherwise.
+type ModificationLocation struct {
+	Position     int
+	Side         TerminalSide
+	Modification *Modification
+}
+
+// TerminalSideForPosition returns the side corresponding to a modifications map position
+func TerminalSideForPosition(position int) TerminalSide {
+	switch position {
+	case -1:
+		return TerminalSideNTerm
+	case -2:
+		return TerminalSideCTerm
+	case -3:
+		return TerminalSideLabile
+	case -4:
+		return TerminalSideUnknown
+	case -5:
+		return TerminalSideUnknownTerminus
+	default:
+		return TerminalSideResidue
+	}
+}
+
+// MarshalJSON serializes the location including its side and the modification map representation
+func (ml ModificationLocation) MarshalJSON() ([]byte, error) {
+	var mod map[string]interface{}
+	if ml.Modification != nil {
+		mod = ml.Modification.ToMap()
+	}
+	return json.Marshal(map[string]interface{}{
+		"position":     ml.Position,
+		"side":         ml.Side,
+		"modification": mod,
+	})
+}
+
+// GetModificationsByType returns all modifications of the given type (e.g. "terminal",
+// "labile", "static") with their locations. An empty modType returns every modification.
+// Results follow ProForma order: unknown position, unknown terminus, labile, N-terminal,
+// residues, C-terminal.
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("[Acetyl]-PEPTIDE-[Amidated]")
+//	for _, loc := range seq.GetModificationsByType("terminal") {
+//		fmt.Println(loc.Side, loc.Modification.GetValue())
+//	}
+//	// n-term Acetyl
+//	// c-term Amidated
+func (s *Sequence) GetModificationsByType(modType string) []ModificationLocation {
+	locations := make([]ModificationLocation, 0)
+
+	add := func(position int, mod *Modification) {
+		if modType == "" || mod.GetModType() == modType {
+			locations = append(locations, ModificationLocation{
+				Position:     position,
+				Side:         TerminalSideForPosition(position),
+				Modification: mod,
+			})
+		}
+	}
+
+	for _, position := range []int{-4, -5, -3, -1} {
+		for _, mod := range s.mods[position] {
+			add(position, mod)
+		}
+	}
+	for i, aa := range s.seq {
+		for _, mod := range aa.GetMods() {
+			add(i, mod)
+		}
+	}
+	for _, mod := range s.mods[-2] {
+		add(-2, mod)
+	}
+
+	return locations
+}
+
+// GetModifiedPositions returns the sorted indices of the residues carrying at least one
+// modification. Terminal, labile and unknown-position modifications are not included.
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("[Acetyl]-ELVIS[Phospho]K")
+//	fmt.Println(seq.GetModifiedPositions()) // [4]
+func (s *Sequence) GetModifiedPositions() []int {
+	positions := make([]int, 0)
+	for i, aa := range s.seq {
+		if len(aa.mods) > 0 {
+			positions = append(positions, i)
+		}
+	}
+	return positions
+}
+
+// CountModifications returns the number of modifications of the sequence: residue, terminal,
+// labile and unknown-position modifications plus global modifications. A range modification is
+// counted once, crosslink and ambiguity references are not counted, and the modifications of
+// all chains of a multi-chain sequence and all peptidoforms of a chimeric one are counted. A
+// global modification shared by several peptidoforms is counted once.
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("<[Carbamidomethyl]@C>[Acetyl]-PEPS[Phospho]C(IDE)[+1]")
+//	fmt.Println(seq.CountModifications()) // 4
+func (s *Sequence) CountModifications() int {
+	count := 0
+	globalCounted := make(map[*GlobalModification]bool)
+	for _, part := range s.partsOrSelf() {
+		counted := make(map[*Modification]bool)
+		for _, loc := range part.GetModificationsByType("") {
+			if countableModification(loc.Modification, counted) {
+				count++
+			}
+		}
+		for _, gm := range part.globalMods {
+			if !globalCounted[gm] {
+				globalCounted[gm] = true
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// CountResidueModifications returns the number of modifications attached to residues,
+// excluding global, terminal, labile and unknown-position modifications. Range modifications
+// and references are handled as in CountModifications.
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("<[Carbamidomethyl]@C>[Acetyl]-PEPS[Phospho]C(IDE)[+1]")
+//	fmt.Println(seq.CountResidueModifications()) // 2
+func (s *Sequence) CountResidueModifications() int {
+	count := 0
+	for _, part := range s.partsOrSelf() {
+		counted := make(map[*Modification]bool)
+		for _, aa := range part.seq {
+			for _, mod := range aa.mods {
+				if countableModification(mod, counted) {
+					count++
+				}
+			}
+		}
+	}
+	return count
+}
+
+// IsFullyLocalized reports whether every modification has a known site, as needed for site
+// specific mass calculations. It returns false when the sequence has an ambiguous
+// modification ("{Phospho}", an ambiguity group such as "[Phospho#g1]" or a modification on a
+// range), a modification at an unknown position ("[Phospho]?PEPTIDE") or unknown terminus, or
+// a sequence ambiguity ("(?DQ)"). Labile and global modifications do not affect the result.
+// All chains of a multi-chain sequence and all peptidoforms of a chimeric one are checked.
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("PEPS{Phospho}TIDE")
+//	fmt.Println(seq.IsFullyLocalized()) // false
+func (s *Sequence) IsFullyLocalized() bool {
+	for _, seq := range s.partsOrSelf() {
+		if len(seq.sequenceAmbiguities) > 0 || len(seq.mods[-4]) > 0 || len(seq.mods[-5]) > 0 {
+			return false
+		}
+		for _, aa := range seq.seq {
+			for _, mod := range aa.mods {
+				if mod.GetModType() == "ambiguous" || mod.inRange {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+// chainsOrSelf returns the chains of a multi-chain sequence, or the sequence itself
+func (s *Sequence) chainsOrSelf() []*Sequence {
+	if s.isMultiChain && len(s.chains) > 0 {
+		return s.chains
+	}
+	return []*Sequence{s}
+}
+
+// partsOrSelf returns the peptidoforms of a chimeric sequence, the chains of a multi-chain
+// sequence, or the sequence itself
+func (s *Sequence) partsOrSelf() []*Sequence {
+	if s.isChimeric && len(s.peptidoforms) > 1 {
+		return s.peptidoforms
+	}
+	return s.chainsOrSelf()
+}
+
+// countableModification reports whether mod should be counted, marking it in counted so shared
+// range modifications are only counted once
+func countableModification(mod *Modification, counted map[*Modification]bool) bool {
+	if counted[mod] || mod.IsCrosslinkRef() || mod.IsAmbiguityRef() {
+		return false
+	}
+	counted[mod] = true
+	return true
+}
+
+// ObservedMassSite reports the observed mass of a modification annotated with an "Obs:" value
+type ObservedMassSite struct {
+	Position     int
+	Side         TerminalSide
+	ObservedMass float64
+	Modification *Modification
+}
+
+// GetObservedMasses collects every modification in the sequence carrying an observed mass
+// ("Obs:" component), in the same order as GetModificationsByType.
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("ELVIS[U:Phospho|Obs:+79.978]K")
+//	sites := seq.GetObservedMasses()
+//	fmt.Println(sites[0].Position, sites[0].ObservedMass) // 4 79.978
+func (s *Sequence) GetObservedMasses() []ObservedMassSite {
+	sites := make([]ObservedMassSite, 0)
+	for _, loc := range s.GetModificationsByType("") {
+		if observed := loc.Modification.GetObservedMass(); observed != nil {
+			sites = append(sites, ObservedMassSite{
+				Position:     loc.Position,
+				Side:         loc.Side,
+				ObservedMass: *observed,
+				Modification: loc.Modification,
+			})
+		}
+	}
+	return sites
+}
@@ -330,3 +330,335 @@ func TestModificationHash(t *testing.T) {
 		t.Errorf("Expected different modifications to have different hashes")
 	}
 }
+
+func TestResolveUnimod(t *testing.T) {
+	for _, value := range []string{"U:21", "UNIMOD:21", "U:Phospho", "Phospho", "unimod:phospho"} {
+		entry, ok := ResolveUnimod(value)
+		if !ok {
+			t.Errorf("Expected '%s' to resolve", value)
+			continue
+		}
+		if entry.Accession != 21 || entry.Name != "Phospho" {
+			t.Errorf("Expected Phospho (21) for '%s', got %s (%d)", value, entry.Name, entry.Accession)
+		}
+	}
+
+	if _, ok := ResolveUnimod("U:NotAModification"); ok {
+		t.Errorf("Expected unknown modification not to resolve")
+	}
+	if _, ok := ResolveUnimod("U:999999"); ok {
+		t.Errorf("Expected unknown accession not to resolve")
+	}
+	for _, value := range []string{"21", "+21", "-21", "U:+21"} {
+		if entry, ok := ResolveUnimod(value); ok {
+			t.Errorf("Expected '%s' not to resolve, got %s", value, entry.Name)
+		}
+	}
+}
+
+func TestModificationResolvedMass(t *testing.T) {
+	tests := []struct {
+		proforma     string
+		expectedMass *float64
+	}{
+		{"S[U:Phospho]", Float64Ptr(79.966331)},
+		{"S[UNIMOD:21]", Float64Ptr(79.966331)},
+		{"M[Oxidation]", Float64Ptr(15.994915)},
+		{"S[+79.97]", Float64Ptr(79.97)},
+		{"S[U:Phospho|+80.0]", Float64Ptr(80.0)},
+		{"S[M:Phospho]", nil},
+		{"S[Unknown]", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.proforma, func(t *testing.T) {
+			seq, err := FromProforma(tt.proforma)
+			if err != nil {
+				t.Fatalf("Failed to parse: %v", err)
+			}
+			mass := seq.GetSeq()[0].GetMods()[0].GetResolvedMass()
+			if tt.expectedMass == nil {
+				if mass != nil {
+					t.Errorf("Expected no resolved mass, got %f", *mass)
+				}
+				return
+			}
+			if mass == nil {
+				t.Fatalf("Expected resolved mass %f, got nil", *tt.expectedMass)
+			}
+			if *mass < *tt.expectedMass-0.0001 || *mass > *tt.expectedMass+0.0001 {
+				t.Errorf("Expected resolved mass %f, got %f", *tt.expectedMass, *mass)
+			}
+		})
+	}
+}
+
+func TestModificationJointRepresentation(t *testing.T) {
+	proforma := "ELVIS[U:Phospho|+79.966331]K"
+	seq, err := FromProforma(proforma)
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+
+	mods := seq.GetSeq()[4].GetMods()
+	if len(mods) != 1 {
+		t.Fatalf("Expected a single modification, got %d", len(mods))
+	}
+	mod := mods[0]
+	mv := mod.GetModificationValue()
+	if mv.GetSource() == nil || *mv.GetSource() != "U" {
+		t.Errorf("Expected source 'U', got %v", mv.GetSource())
+	}
+	if mod.GetValue() != "Phospho" {
+		t.Errorf("Expected value 'Phospho', got '%s'", mod.GetValue())
+	}
+
+	pipeValues := mv.GetPipeValues()
+	if len(pipeValues) != 2 || pipeValues[0].GetType() != PipeValueTypeSynonym || pipeValues[1].GetType() != PipeValueTypeMass {
+		t.Fatalf("Expected a synonym and a mass pipe value, got %d pipe values", len(pipeValues))
+	}
+
+	mass := mod.GetMass()
+	if mass == nil || *mass < 79.966331-0.000001 || *mass > 79.966331+0.000001 {
+		t.Errorf("Expected mass 79.966331, got %v", mass)
+	}
+	expectedTotal := AAMass["S"] + 79.966331
+	if total := seq.GetSeq()[4].GetTotalMass(); total < expectedTotal-0.000001 || total > expectedTotal+0.000001 {
+		t.Errorf("Expected residue total mass %f, got %f", expectedTotal, total)
+	}
+
+	if seq.ToProforma() != proforma {
+		t.Errorf("Roundtrip failed: expected '%s', got '%s'", proforma, seq.ToProforma())
+	}
+}
+
+func TestModificationInfoMap(t *testing.T) {
+	tests := []struct {
+		proforma string
+		expected map[string]string
+	}{
+		{"ELVIS[Phospho|INFO:reaction=NHS]K", map[string]string{"reaction": "NHS"}},
+		{"ELVIS[Phospho|INFO:reaction=NHS|INFO:site = S5]K", map[string]string{"reaction": "NHS", "site": "S5"}},
+		{"ELVIS[Phospho|INFO:manually validated]K", map[string]string{"": "manually validated"}},
+		{"ELVIS[INFO:reaction=NHS]K", map[string]string{"reaction": "NHS"}},
+		{"ELVIS[Phospho]K", map[string]string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.proforma, func(t *testing.T) {
+			seq, err := FromProforma(tt.proforma)
+			if err != nil {
+				t.Fatalf("Failed to parse: %v", err)
+			}
+			mod := seq.seq[4].GetMods()[0]
+			infoMap := mod.GetInfoMap()
+			if len(infoMap) != len(tt.expected) {
+				t.Fatalf("Expected %v, got %v", tt.expected, infoMap)
+			}
+			for key, value := range tt.expected {
+				if infoMap[key] != value {
+					t.Errorf("Expected '%s' for key '%s', got '%s'", value, key, infoMap[key])
+				}
+			}
+			if seq.ToProforma() != tt.proforma {
+				t.Errorf("Roundtrip failed: expected '%s', got '%s'", tt.proforma, seq.ToProforma())
+			}
+		})
+	}
+}
+
+func TestModificationValueGlycanNotation(t *testing.T) {
+	tests := []struct {
+		value            string
+		expectedExpanded string
+		expectedCompact  string
+	}{
+		{"Glycan:HexNAc2Hex3Fuc", "Fuc(1)Hex(3)HexNAc(2)", "Fuc1Hex3HexNAc2"},
+		{"Glycan:HexNAc(2)Hex(3)", "Hex(3)HexNAc(2)", "Hex3HexNAc2"},
+		{"Glycan:HexNAc1Hex1HexNAc1", "Hex(1)HexNAc(2)", "Hex1HexNAc2"},
+		{"Glycan:Hex", "Hex(1)", "Hex"},
+		{"Glycan:{C8H13N1O5}1Hex2", "Hex(2){C8H13N1O5}(1)", "Hex2{C8H13N1O5}1"},
+		{"Glycan:Hex2{C8H13N1O5:z+1}", "Hex(2){C8H13N1O5:z+1}(1)", "Hex2{C8H13N1O5:z+1}1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			mv := NewModificationValue(tt.value, nil)
+			expanded, err := mv.GlycanToExpanded()
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if expanded != tt.expectedExpanded {
+				t.Errorf("Expected expanded '%s', got '%s'", tt.expectedExpanded, expanded)
+			}
+			compact, err := mv.GlycanToCompact()
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if compact != tt.expectedCompact {
+				t.Errorf("Expected compact '%s', got '%s'", tt.expectedCompact, compact)
+			}
+			if !validateGlycan(expanded) || !validateGlycan(compact) {
+				t.Errorf("Expected '%s' and '%s' to be valid glycans", expanded, compact)
+			}
+		})
+	}
+
+	if _, err := NewModificationValue("Phospho", nil).GlycanToCompact(); err == nil {
+		t.Error("Expected error for a non-glycan modification")
+	}
+}
+
+func TestModificationValueGetGlycanComposition(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected map[string]int
+	}{
+		{"Glycan:HexNAc2Hex3Fuc", map[string]int{"HexNAc": 2, "Hex": 3, "Fuc": 1}},
+		{"Glycan:HexNAc(2)Hex(5)", map[string]int{"HexNAc": 2, "Hex": 5}},
+		{"Glycan:Hex", map[string]int{"Hex": 1}},
+		{"Glycan:HexNAc1Hex1HexNAc1", map[string]int{"HexNAc": 2, "Hex": 1}},
+		{"Glycan:NeuAc1dHex2", map[string]int{"NeuAc": 1, "dHex": 2}},
+		{"Glycan:Hex2{C8H13N1O5:z+1}", map[string]int{"Hex": 2, "C8H13N1O5:z+1": 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			composition, err := NewModificationValue(tt.value, nil).GetGlycanComposition()
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(composition) != len(tt.expected) {
+				t.Fatalf("Expected %v, got %v", tt.expected, composition)
+			}
+			for name, count := range tt.expected {
+				if composition[name] != count {
+					t.Errorf("Expected %d %s, got %d", count, name, composition[name])
+				}
+			}
+		})
+	}
+
+	for _, value := range []string{"Phospho", "Glycan:Hexx", "Glycan:"} {
+		if _, err := NewModificationValue(value, nil).GetGlycanComposition(); err == nil {
+			t.Errorf("Expected error for '%s'", value)
+		}
+	}
+}
+
+func TestModificationValueGlycanAccession(t *testing.T) {
+	for _, proforma := range []string{"N[GNO:G62765YT]K", "N[G:G62765YT]K", "N[GNO:G62765YT|INFO:core]K"} {
+		t.Run(proforma, func(t *testing.T) {
+			seq, err := FromProforma(proforma)
+			if err != nil {
+				t.Fatalf("Failed to parse: %v", err)
+			}
+			mv := seq.GetSeq()[0].GetMods()[0].GetModificationValue()
+			if !mv.IsGlycanAccession() {
+				t.Error("Expected a glycan accession")
+			}
+			pv := mv.GetPipeValues()[0]
+			if pv.GetType() != PipeValueTypeGlycan || !pv.IsValidGlycan() {
+				t.Errorf("Expected a valid glycan pipe value, got type '%s'", pv.GetType())
+			}
+			if pv.GetValue() != "G62765YT" {
+				t.Errorf("Expected 'G62765YT', got '%s'", pv.GetValue())
+			}
+			if _, err := mv.GetGlycanComposition(); err == nil {
+				t.Error("Expected no composition for an accession")
+			}
+			if seq.ToProforma() != proforma {
+				t.Errorf("Expected '%s', got '%s'", proforma, seq.ToProforma())
+			}
+			compact := seq.ToProformaWithOptions(ProformaOptions{GlycanNotation: GlycanNotationCompact})
+			if compact != proforma {
+				t.Errorf("Expected '%s' with compact glycans, got '%s'", proforma, compact)
+			}
+		})
+	}
+
+	for _, value := range []string{"Glycan:Hex1HexNAc1", "Phospho"} {
+		if NewModificationValue(value, nil).IsGlycanAccession() {
+			t.Errorf("Expected '%s' not to be a glycan accession", value)
+		}
+	}
+}
+
+func TestModificationGetSources(t *testing.T) {
+	tests := []struct {
+		name     string
+		proforma string
+		expected []string
+	}{
+		{"Unimod and PSI-MOD", "S[U:Phospho|M:MOD:00046]K", []string{"U", "M"}},
+		{"Repeated source", "S[U:Phospho|U:+79.966]K", []string{"U"}},
+		{"Unsourced value skipped", "S[Phospho|M:MOD:00046]K", []string{"M"}},
+		{"No sources", "S[+79.966]K", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seq, err := FromProforma(tt.proforma)
+			if err != nil {
+				t.Fatalf("Failed to parse '%s': %v", tt.proforma, err)
+			}
+			mod := seq.GetSeq()[0].GetMods()[0]
+			sources := mod.GetSources()
+			if len(sources) != len(tt.expected) {
+				t.Fatalf("Expected sources %v, got %v", tt.expected, sources)
+			}
+			for i := range sources {
+				if sources[i] != tt.expected[i] {
+					t.Errorf("Expected sources %v, got %v", tt.expected, sources)
+				}
+			}
+			if result := seq.ToProforma(); result != tt.proforma {
+				t.Errorf("Expected '%s', got '%s'", tt.proforma, result)
+			}
+		})
+	}
+
+	seq, _ := FromProforma("S[U:Phospho|U:+42.011]K")
+	pv := seq.GetSeq()[0].GetMods()[0].GetModificationValue().GetPipeValues()[1]
+	if pv.GetType() != PipeValueTypeMass || pv.GetMass() == nil || *pv.GetMass() != 42.011 {
+		t.Errorf("Expected 'U:+42.011' to be a mass pipe value of 42.011, got %s", pv.GetType())
+	}
+}
+
+func TestModificationClone(t *testing.T) {
+	seq, err := FromProforma("PEPS[Phospho|+79.966|Info:test]IDE")
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	mod := seq.GetSeq()[3].GetMods()[0]
+	clone := mod.Clone()
+
+	if clone == mod || clone.GetModificationValue() == mod.GetModificationValue() {
+		t.Fatal("Expected the clone to have its own modification value")
+	}
+	if clone.ToProforma() != mod.ToProforma() {
+		t.Errorf("Expected '%s', got '%s'", mod.ToProforma(), clone.ToProforma())
+	}
+
+	clonePipes := clone.GetModificationValue().GetPipeValues()
+	clonePipes[1].SetMass(80)
+	clonePipes[2].SetType(PipeValueTypeSynonym)
+	clone.SetPosition(IntPtr(0))
+
+	pipes := mod.GetModificationValue().GetPipeValues()
+	if mass := pipes[1].GetMass(); mass == nil || *mass != 79.966 {
+		t.Errorf("Expected source mass 79.966, got %v", mass)
+	}
+	if pipes[2].GetType() != PipeValueTypeInfoTag {
+		t.Errorf("Expected source type '%s', got '%s'", PipeValueTypeInfoTag, pipes[2].GetType())
+	}
+	if pos := mod.GetPosition(); pos != nil {
+		t.Errorf("Expected no source position, got %d", *pos)
+	}
+
+	copied := DeepCopyModifications([]*Modification{mod})
+	copied[0].GetModificationValue().GetPipeValues()[1].SetMass(81)
+	if mass := pipes[1].GetMass(); mass == nil || *mass != 79.966 {
+		t.Errorf("Expected source mass 79.966 after DeepCopyModifications, got %v", mass)
+	}
+}
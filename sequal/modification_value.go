@@ -22,7 +22,7 @@ func NewModificationValue(value string, mass *float64) *ModificationValue {
 	mv := &ModificationValue{
 		pipeValues: make([]*PipeValue, 0),
 		knownSources: map[string]bool{
-			"Unimod": true, "U": true, "PSI-MOD": true, "M": true,
+			"Unimod": true, "UNIMOD": true, "U": true, "PSI-MOD": true, "M": true,
 			"RESID": true, "R": true, "XL-MOD": true, "X": true,
 			"XLMOD": true, "GNO": true, "G": true, "MOD": true,
 			"Obs": true, "Formula": true, "FORMULA": true, "GLYCAN": true,
@@ -48,6 +48,19 @@ func (mv *ModificationValue) _parseValue(value string) {
 	}
 }
 
+// clone returns a copy of the modification value with its own pipe values
+func (mv *ModificationValue) clone() *ModificationValue {
+	if mv == nil {
+		return nil
+	}
+	clone := *mv
+	clone.pipeValues = make([]*PipeValue, len(mv.pipeValues))
+	for i, pv := range mv.pipeValues {
+		clone.pipeValues[i] = pv.clone()
+	}
+	return &clone
+}
+
 // GetSource returns the source of the modification value
 func (mv *ModificationValue) GetSource() *string {
 	return mv.source
@@ -58,9 +71,18 @@ func (mv *ModificationValue) GetPrimaryValue() string {
 	return mv.primaryValue
 }
 
-// GetMass returns the mass value
+// GetMass returns the mass value. When the primary value is not a mass, the first mass pipe
+// value is returned, so "U:Phospho|+79.966331" has a mass of 79.966331.
 func (mv *ModificationValue) GetMass() *float64 {
-	return mv.mass
+	if mv.mass != nil {
+		return mv.mass
+	}
+	for _, pv := range mv.pipeValues {
+		if pv.valueType == PipeValueTypeMass && pv.mass != nil {
+			return pv.mass
+		}
+	}
+	return nil
 }
 
 // GetSynonyms returns list of synonyms
@@ -100,6 +122,23 @@ func (mv *ModificationValue) GetInfoTags() []string {
 	return result
 }
 
+// GetInfoMap returns the info tags parsed as key=value pairs. Keys and values are trimmed
+// of surrounding whitespace. Tags without "=" are stored under the empty key "", and when
+// the same key appears more than once the last value wins. The raw tags remain available
+// through GetInfoTags.
+func (mv *ModificationValue) GetInfoMap() map[string]string {
+	result := make(map[string]string)
+	for _, tag := range mv.GetInfoTags() {
+		key, value, found := strings.Cut(tag, "=")
+		if !found {
+			result[""] = strings.TrimSpace(tag)
+			continue
+		}
+		result[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return result
+}
+
 // GetCrosslinkID returns the crosslink ID if any
 func (mv *ModificationValue) GetCrosslinkID() *string {
 	for _, pv := range mv.pipeValues {
@@ -110,6 +149,27 @@ func (mv *ModificationValue) GetCrosslinkID() *string {
 	return nil
 }
 
+// IsGlycanAccession reports whether the modification names a glycan by its GlyTouCan
+// accession in the GNO source, as in "GNO:G62765YT" or "G:G62765YT", rather than giving a
+// composition such as "Glycan:Hex1HexNAc1"
+func (mv *ModificationValue) IsGlycanAccession() bool {
+	for _, pv := range mv.pipeValues {
+		if pv.valueType == PipeValueTypeGlycan && pv.source != nil && isGlycanAccessionSource(*pv.source) {
+			return true
+		}
+	}
+	return false
+}
+
+// isGlycanAccessionSource reports whether a source prefix refers to the GNO glycan ontology
+func isGlycanAccessionSource(source string) bool {
+	switch strings.ToUpper(source) {
+	case "GNO", "G":
+		return true
+	}
+	return false
+}
+
 // IsBranch checks if modification is a branch
 func (mv *ModificationValue) IsBranch() bool {
 	for _, pv := range mv.pipeValues {
@@ -150,6 +210,16 @@ func (mv *ModificationValue) GetAmbiguityGroup() *string {
 	return nil
 }
 
+// GetLocalizationScore returns the localization score of the ambiguity group or crosslink if any
+func (mv *ModificationValue) GetLocalizationScore() *float64 {
+	for _, pv := range mv.pipeValues {
+		if (pv.valueType == PipeValueTypeAmbiguity || pv.valueType == PipeValueTypeCrosslink) && pv.localizationScore != nil {
+			return pv.localizationScore
+		}
+	}
+	return nil
+}
+
 // IsAmbiguityRef checks if modification is an ambiguity reference
 func (mv *ModificationValue) IsAmbiguityRef() bool {
 	for _, pv := range mv.pipeValues {
@@ -356,7 +426,7 @@ func (mv *ModificationValue) processPrimaryValue(value string) {
 		// Handle crosslink or ambiguity reference
 		mv.primaryValue = ""
 		valueType := PipeValueTypeCrosslink
-		if strings.Contains(value[1:], "(") && strings.Contains(value[1:], ")") {
+		if !strings.HasPrefix(value[1:], "XL") {
 			valueType = PipeValueTypeAmbiguity
 		}
 
@@ -365,8 +435,7 @@ func (mv *ModificationValue) processPrimaryValue(value string) {
 		pipeVal.isAmbiguityRef = valueType == PipeValueTypeAmbiguity
 
 		if pipeVal.isCrosslinkRef {
-			crosslinkID := value[1:]
-			pipeVal.crosslinkID = &crosslinkID
+			pipeVal.setCrosslinkID(value[1:])
 		}
 
 		if pipeVal.isAmbiguityRef {
@@ -440,6 +509,10 @@ func (mv *ModificationValue) processPrimaryValue(value string) {
 				// Create pipe value for base value
 				pipeVal := NewPipeValue(baseValue, PipeValueTypeSynonym, valueStr)
 				pipeVal.source = &source
+				if mass, ok := sourceMass(source, baseValue); ok {
+					pipeVal.SetType(PipeValueTypeMass)
+					pipeVal.mass = &mass
+				}
 				mv.pipeValues = append(mv.pipeValues, pipeVal)
 
 				// Handle branch, ambiguity, or crosslink
@@ -448,7 +521,7 @@ func (mv *ModificationValue) processPrimaryValue(value string) {
 					branchVal.isBranch = true
 					branchVal.source = &source
 					mv.pipeValues = append(mv.pipeValues, branchVal)
-				} else if strings.Contains(specialPart, "(") && strings.Contains(specialPart, ")") {
+				} else if !strings.HasPrefix(specialPart, "XL") {
 					ambVal := NewPipeValue(valueStr, PipeValueTypeAmbiguity, valueStr)
 					ambiguityGroup := specialPart
 					ambVal.ambiguityGroup = &ambiguityGroup
@@ -469,7 +542,7 @@ func (mv *ModificationValue) processPrimaryValue(value string) {
 					mv.pipeValues = append(mv.pipeValues, ambVal)
 				} else {
 					xlVal := NewPipeValue(valueStr, PipeValueTypeCrosslink, valueStr)
-					xlVal.crosslinkID = &specialPart
+					xlVal.setCrosslinkID(specialPart)
 					xlVal.source = &source
 					mv.pipeValues = append(mv.pipeValues, xlVal)
 				}
@@ -478,7 +551,10 @@ func (mv *ModificationValue) processPrimaryValue(value string) {
 				pipeVal := NewPipeValue(valueStr, PipeValueTypeSynonym, valueStr)
 				pipeVal.source = &source
 				// ProForma 2.1: Set charge if present (for formulas)
-				if strings.ToUpper(source) == "FORMULA" {
+				if mass, ok := sourceMass(source, valueStr); ok {
+					pipeVal.SetType(PipeValueTypeMass)
+					pipeVal.mass = &mass
+				} else if strings.ToUpper(source) == "FORMULA" {
 					pipeVal.SetType(PipeValueTypeFormula)
 					pipeVal.isValidFormula = validateFormula(valueStr)
 					pipeVal.charge = chargeStr
@@ -487,6 +563,17 @@ func (mv *ModificationValue) processPrimaryValue(value string) {
 					// ProForma 2.1: Validate glycan (including custom monosaccharides)
 					pipeVal.SetType(PipeValueTypeGlycan)
 					pipeVal.isValidGlycan = validateGlycan(valueStr)
+				} else if isGlycanAccessionSource(source) {
+					// GNO accessions name a glycan rather than spell out its composition
+					pipeVal.SetType(PipeValueTypeGlycan)
+					pipeVal.isValidGlycan = true
+				} else if strings.ToUpper(source) == "INFO" {
+					pipeVal.SetType(PipeValueTypeInfoTag)
+				} else if strings.ToUpper(source) == "OBS" {
+					pipeVal.SetType(PipeValueTypeObservedMass)
+					if observedMass, err := strconv.ParseFloat(valueStr, 64); err == nil {
+						pipeVal.observedMass = &observedMass
+					}
 				}
 				mv.pipeValues = append(mv.pipeValues, pipeVal)
 			}
@@ -531,7 +618,7 @@ func (mv *ModificationValue) processPrimaryValue(value string) {
 				branchVal := NewPipeValue(value, PipeValueTypeBranch, value)
 				branchVal.isBranch = true
 				mv.pipeValues = append(mv.pipeValues, branchVal)
-			} else if strings.Contains(specialPart, "(") && strings.Contains(specialPart, ")") {
+			} else if !strings.HasPrefix(specialPart, "XL") {
 				ambVal := NewPipeValue(value, PipeValueTypeAmbiguity, value)
 				ambiguityGroup := specialPart
 				ambVal.ambiguityGroup = &ambiguityGroup
@@ -551,7 +638,7 @@ func (mv *ModificationValue) processPrimaryValue(value string) {
 				mv.pipeValues = append(mv.pipeValues, ambVal)
 			} else {
 				xlVal := NewPipeValue(value, PipeValueTypeCrosslink, value)
-				xlVal.crosslinkID = &specialPart
+				xlVal.setCrosslinkID(specialPart)
 				mv.pipeValues = append(mv.pipeValues, xlVal)
 			}
 		} else {
@@ -560,17 +647,8 @@ func (mv *ModificationValue) processPrimaryValue(value string) {
 
 			// Try to parse as mass
 			if strings.HasPrefix(value, "+") || strings.HasPrefix(value, "-") {
-				massStr := value
-				if strings.HasPrefix(value, "+") {
-					massStr = value[1:] // Remove + sign
-				}
-
-				mass, err := strconv.ParseFloat(massStr, 64)
+				mass, err := strconv.ParseFloat(value, 64)
 				if err == nil {
-					if strings.HasPrefix(value, "-") {
-						mass = -mass
-					}
-
 					mv.mass = &mass
 					massVal := NewPipeValue(value, PipeValueTypeMass, value)
 					massVal.mass = &mass
@@ -618,8 +696,7 @@ func (mv *ModificationValue) _processPipeComponent(component string) {
 		pipeVal.isAmbiguityRef = pipeVal.valueType == PipeValueTypeAmbiguity
 
 		if pipeVal.isCrosslinkRef {
-			crosslinkID := component[1:]
-			pipeVal.crosslinkID = &crosslinkID
+			pipeVal.setCrosslinkID(component[1:])
 		}
 
 		if pipeVal.isAmbiguityRef {
@@ -689,8 +766,7 @@ func (mv *ModificationValue) _processPipeComponent(component string) {
 				if source == "XL" || source == "XLMOD" || source == "XL-MOD" || source == "X" {
 					pipeVal = NewPipeValue(value, PipeValueTypeCrosslink, component)
 					pipeVal.source = &source
-					crosslinkID := pvParts[1]
-					pipeVal.crosslinkID = &crosslinkID
+					pipeVal.setCrosslinkID(pvParts[1])
 				} else if pvParts[1] == "BRANCH" {
 					pipeVal = NewPipeValue(value, PipeValueTypeBranch, component)
 					pipeVal.source = &source
@@ -748,7 +824,10 @@ func (mv *ModificationValue) _processPipeComponent(component string) {
 			} else {
 				var pipeVal *PipeValue
 
-				if strings.ToUpper(source) == "INFO" {
+				if mass, ok := sourceMass(source, value); ok {
+					pipeVal = NewPipeValue(value, PipeValueTypeMass, component)
+					pipeVal.mass = &mass
+				} else if strings.ToUpper(source) == "INFO" {
 					pipeVal = NewPipeValue(value, PipeValueTypeInfoTag, component)
 				} else if strings.ToUpper(source) == "OBS" {
 					pipeVal = NewPipeValue(value, PipeValueTypeObservedMass, component)
@@ -790,8 +869,7 @@ func (mv *ModificationValue) _processPipeComponent(component string) {
 						pipeVal.isBranch = true
 						pipeVal.AssignType(PipeValueTypeBranch)
 					} else if strings.HasPrefix(pvParts[1], "XL") {
-						crosslinkID := pvParts[1]
-						pipeVal.crosslinkID = &crosslinkID
+						pipeVal.setCrosslinkID(pvParts[1])
 						pipeVal.AssignType(PipeValueTypeCrosslink)
 					} else {
 						ambiguityGroup := pvParts[1]
@@ -837,8 +915,7 @@ func (mv *ModificationValue) _processPipeComponent(component string) {
 				pipeVal.isBranch = true
 			} else if strings.HasPrefix(parts[1], "XL") {
 				pipeVal = NewPipeValue(value, PipeValueTypeCrosslink, component)
-				crosslinkID := parts[1]
-				pipeVal.crosslinkID = &crosslinkID
+				pipeVal.setCrosslinkID(parts[1])
 			} else {
 				pipeVal = NewPipeValue(value, PipeValueTypeAmbiguity, component)
 				ambiguityGroup := parts[1]
@@ -894,6 +971,21 @@ func containsDigit(s string) bool {
 	return false
 }
 
+// sourceMass parses a signed mass shift written after a controlled vocabulary source, such
+// as "+79.966" in "U:+79.966". Observed masses, formulas, glycans and info tags are not
+// mass shifts of their source.
+func sourceMass(source, value string) (float64, bool) {
+	switch strings.ToUpper(source) {
+	case "OBS", "FORMULA", "GLYCAN", "INFO":
+		return 0, false
+	}
+	if !strings.HasPrefix(value, "+") && !strings.HasPrefix(value, "-") {
+		return 0, false
+	}
+	mass, err := strconv.ParseFloat(value, 64)
+	return mass, err == nil
+}
+
 func isInKnownSources(source string, knownSources map[string]bool) bool {
 	_, ok := knownSources[source]
 	return ok
@@ -976,7 +1068,8 @@ func validateGlycan(glycan string) bool {
 		return len(monos[i]) > len(monos[j])
 	})
 
-	// Build pattern for standard monosaccharides - count must start with 1-9
+	// Build pattern for standard monosaccharides. Any signed count is captured so that
+	// it can be checked by validGlycanCount rather than silently left unmatched.
 	monoPattern := "^("
 	for i, mono := range monos {
 		if i > 0 {
@@ -984,54 +1077,60 @@ func validateGlycan(glycan string) bool {
 		}
 		monoPattern += regexp.QuoteMeta(mono)
 	}
-	monoPattern += `)((\([1-9]\d*\))|[1-9]\d*)?`
+	monoPattern += `)(?:\((-?\d+)\)|(-?\d+))?`
 
 	// ProForma 2.1: Pattern for custom monosaccharides in curly braces
-	// Format: {Formula} or {Formula:z+N} - count must start with 1-9
-	customMonoPattern := `^\{([A-Za-z0-9]+)(:z[+-]\d+)?\}((\([1-9]\d*\))|[1-9]\d*)?`
+	// Format: {Formula} or {Formula:z+N}
+	customMonoPattern := `^\{([A-Za-z0-9]+)(:z[+-]\d+)?\}(?:\((-?\d+)\)|(-?\d+))?`
 
 	standardRe := regexp.MustCompile(monoPattern)
 	customRe := regexp.MustCompile(customMonoPattern)
 
 	i := 0
 	for i < len(glycanClean) {
+		var countStr string
+
 		// Try custom monosaccharide first
-		customMatch := customRe.FindStringSubmatch(glycanClean[i:])
-		if customMatch != nil {
+		if customMatch := customRe.FindStringSubmatch(glycanClean[i:]); customMatch != nil {
 			// Validate the formula part
-			formula := customMatch[1]
-			if !validateFormula(formula) {
+			if !validateFormula(customMatch[1]) {
 				return false
 			}
-
-			monoLength := len(customMatch[0])
-			hasCount := len(customMatch) > 3 && customMatch[3] != ""
-			i += monoLength
-
-			isAtEnd := i == len(glycanClean)
-			if !hasCount && !isAtEnd {
-				return false
-			}
-			continue
+			countStr = customMatch[3] + customMatch[4]
+			i += len(customMatch[0])
+		} else if standardMatch := standardRe.FindStringSubmatch(glycanClean[i:]); standardMatch != nil {
+			countStr = standardMatch[2] + standardMatch[3]
+			i += len(standardMatch[0])
+		} else {
+			// No match found
+			return false
 		}
 
-		// Try standard monosaccharide
-		standardMatch := standardRe.FindStringSubmatch(glycanClean[i:])
-		if standardMatch != nil {
-			monoLength := len(standardMatch[0])
-			hasCount := len(standardMatch) > 2 && standardMatch[2] != ""
-			i += monoLength
-
-			isAtEnd := i == len(glycanClean)
-			if !hasCount && !isAtEnd {
+		// The count may only be omitted on the last monosaccharide
+		if countStr == "" {
+			if i != len(glycanClean) {
 				return false
 			}
 			continue
 		}
-
-		// No match found
-		return false
+		if !validGlycanCount(countStr) {
+			return false
+		}
 	}
 
 	return i == len(glycanClean)
 }
+
+// validGlycanCount reports whether a monosaccharide count is a positive integer written
+// without leading zeros
+func validGlycanCount(count string) bool {
+	if count == "" || count[0] < '1' || count[0] > '9' {
+		return false
+	}
+	for _, c := range count[1:] {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,322 @@
+package sequal
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ToMzTabPSMFields returns the "sequence", "modifications" and "charge" columns of an mzTab
+// PSM row for the sequence, with "null" for an empty column as mzTab requires.
+//
+// Modifications are written as "position-identifier" separated by commas, where positions
+// count residues from 1, 0 is the N-terminus and length+1 the C-terminus. The identifier is
+// the Unimod accession ("UNIMOD:21") of a Unimod name or accession, the PSI-MOD accession
+// ("MOD:00046") of a PSI-MOD accession, and otherwise "CHEMMOD:" followed by the mass, to six
+// decimals, for mass shifts, formulas and other modifications with a resolvable mass. The possible
+// positions of a range modification or an ambiguity group are joined with "|", labile and
+// unknown-position modifications are written without a position, and fixed global
+// modifications are written at each residue they apply to.
+//
+// Unsupported cases are left out: modifications without an accession or resolvable mass
+// (such as glycans and unknown names), crosslink and branch references, isotope labels and
+// localization scores. Multi-chain and chimeric sequences are not split, so only the first
+// chain or peptidoform is described.
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("[Acetyl]-PEPS[Phospho]TM[+15.995]IDE/2")
+//	fields := seq.ToMzTabPSMFields()
+//	fmt.Println(fields["sequence"], fields["modifications"], fields["charge"])
+//	// PEPSTMIDE 0-UNIMOD:1,4-UNIMOD:21,6-CHEMMOD:+15.995 2
+func (s *Sequence) ToMzTabPSMFields() map[string]string {
+	fields := map[string]string{
+		"sequence":      s.ToStrippedString(),
+		"modifications": "null",
+		"charge":        "null",
+	}
+	if charge := s.GetCharge(); charge != nil {
+		fields["charge"] = strconv.Itoa(*charge)
+	}
+
+	type mzTabModification struct {
+		positions  []int
+		identifier string
+	}
+	var entries []*mzTabModification
+	byMod := make(map[*Modification]*mzTabModification)
+	byGroup := make(map[string]*mzTabModification)
+
+	add := func(mod *Modification, positions ...int) {
+		if mod.IsCrosslinkRef() || mod.isBranchRef {
+			return
+		}
+		if group := mod.GetAmbiguityGroup(); group != nil {
+			entry, seen := byGroup[*group]
+			if !seen {
+				entry = &mzTabModification{}
+				byGroup[*group] = entry
+				entries = append(entries, entry)
+			}
+			entry.positions = append(entry.positions, positions...)
+			if identifier, ok := mzTabIdentifier(mod); ok {
+				entry.identifier = identifier
+			}
+			return
+		}
+		if entry, seen := byMod[mod]; seen {
+			entry.positions = append(entry.positions, positions...)
+			return
+		}
+		identifier, ok := mzTabIdentifier(mod)
+		if !ok {
+			return
+		}
+		entry := &mzTabModification{positions: positions, identifier: identifier}
+		byMod[mod] = entry
+		entries = append(entries, entry)
+	}
+
+	length := len(s.seq)
+	for _, position := range []int{-4, -3} {
+		for _, mod := range s.mods[position] {
+			add(mod)
+		}
+	}
+	for _, mod := range s.mods[-5] {
+		add(mod, 0, length+1)
+	}
+	for _, mod := range s.mods[-1] {
+		add(mod, 0)
+	}
+	globalSites := s.GetGlobalModSites()
+	for i, aa := range s.seq {
+		for _, mod := range aa.mods {
+			add(mod, i+1)
+		}
+		for _, gm := range globalSites[i] {
+			if identifier, ok := mzTabIdentifier(&gm.Modification); ok {
+				entries = append(entries, &mzTabModification{positions: []int{i + 1}, identifier: identifier})
+			}
+		}
+	}
+	for _, mod := range s.mods[-2] {
+		add(mod, length+1)
+	}
+
+	tokens := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.identifier == "" {
+			continue
+		}
+		if len(entry.positions) == 0 {
+			tokens = append(tokens, entry.identifier)
+			continue
+		}
+		positions := make([]string, len(entry.positions))
+		for i, position := range entry.positions {
+			positions[i] = strconv.Itoa(position)
+		}
+		tokens = append(tokens, strings.Join(positions, "|")+"-"+entry.identifier)
+	}
+	if len(tokens) > 0 {
+		fields["modifications"] = strings.Join(tokens, ",")
+	}
+	return fields
+}
+
+// mzTabIdentifier returns the mzTab identifier of a modification: the Unimod or PSI-MOD
+// accession of one of its values, or a CHEMMOD mass when it has none. A modification given
+// as a mass shift is always a CHEMMOD mass, even when the number matches an accession.
+func mzTabIdentifier(mod *Modification) (string, bool) {
+	if mass, isShift := mod.massShift(); isShift {
+		return mzTabChemMod(mass), true
+	}
+
+	if mv := mod.GetModificationValue(); mv != nil {
+		for _, pv := range mv.GetPipeValues() {
+			if pv.GetType() != PipeValueTypeSynonym {
+				continue
+			}
+			if isUnimodSource(pv.GetSource()) {
+				if entry, ok := resolveUnimodValue(pv.GetSource(), pv.GetValue()); ok {
+					return fmt.Sprintf("UNIMOD:%d", entry.Accession), true
+				}
+				continue
+			}
+			switch strings.ToUpper(*pv.GetSource()) {
+			case "M", "MOD", "PSI-MOD":
+				accession := strings.TrimPrefix(strings.ToUpper(pv.GetValue()), "MOD:")
+				if _, err := strconv.Atoi(accession); err == nil {
+					return "MOD:" + accession, true
+				}
+			}
+		}
+	}
+
+	if isUnimodSource(mod.GetSource()) {
+		if entry, ok := resolveUnimodValue(mod.GetSource(), mod.GetValue()); ok {
+			return fmt.Sprintf("UNIMOD:%d", entry.Accession), true
+		}
+	}
+
+	if mass := mod.GetResolvedMass(); mass != nil {
+		return mzTabChemMod(*mass), true
+	}
+	return "", false
+}
+
+// mzTabChemMod returns the CHEMMOD identifier of a mass, rounded to six decimals as in Unimod
+// to hide the rounding noise of formula masses
+func mzTabChemMod(mass float64) string {
+	formatted := strconv.FormatFloat(math.Round(mass*1e6)/1e6, 'f', -1, 64)
+	if mass >= 0 {
+		formatted = "+" + formatted
+	}
+	return "CHEMMOD:" + formatted
+}
+
+// ParseMzTabModifications parses the modifications column of an mzTab PSM or peptide row,
+// such as "3-UNIMOD:21,8-UNIMOD:35", into a modifications map for NewSequence, for a
+// sequence of seqLen residues. Position 0 becomes the N-terminal (-1) slot, seqLen+1 the
+// C-terminal (-2) slot and a residue position n the index n-1; a modification written
+// without a position goes to the unknown-position (-4) slot. Possible positions joined with
+// "|", as in "3|4-UNIMOD:21", become an ambiguity group defined at the first position and
+// referenced at the others.
+//
+// Unimod and PSI-MOD accessions are kept as "UNIMOD:21" and "MOD:00046", "CHEMMOD:" masses
+// become mass shifts and other "CHEMMOD:" values formulas. Parameters attached to a
+// position, such as modification probabilities, are ignored. An empty or "null" column gives
+// an empty map. An error is returned for positions outside the sequence and for identifiers
+// that are not Unimod, PSI-MOD or CHEMMOD, such as neutral loss parameters.
+//
+// Example:
+//
+//	mods, _ := sequal.ParseMzTabModifications(7, "0-UNIMOD:1,4-UNIMOD:21")
+//	seq := sequal.NewSequence("PEPTIDE", mods, true, "right", nil, nil, nil, nil, nil, nil, nil, nil)
+//	fmt.Println(seq.ToProforma()) // "[UNIMOD:1]-PEPT[UNIMOD:21]IDE"
+func ParseMzTabModifications(seqLen int, modStr string) (map[int][]*Modification, error) {
+	mods := make(map[int][]*Modification)
+	modStr = strings.TrimSpace(modStr)
+	if modStr == "" || modStr == "null" {
+		return mods, nil
+	}
+
+	parser := NewProFormaParser()
+	groups := 0
+	for _, token := range splitMzTabModifications(modStr) {
+		token = strings.TrimSpace(token)
+		positionStr, identifier := "", token
+		if token != "" && token[0] >= '0' && token[0] <= '9' {
+			if dash := indexOutsideBrackets(token, '-'); dash != -1 {
+				positionStr, identifier = token[:dash], token[dash+1:]
+			}
+		}
+
+		value, err := mzTabProformaValue(identifier)
+		if err != nil {
+			return nil, err
+		}
+
+		if positionStr == "" {
+			mods[-4] = append(mods[-4], parser.createModification(value, map[string]interface{}{"isUnknownPosition": true}))
+			continue
+		}
+
+		var slots []int
+		for _, part := range strings.Split(positionStr, "|") {
+			if open := strings.Index(part, "["); open != -1 {
+				part = part[:open]
+			}
+			position, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil {
+				return nil, fmt.Errorf("invalid mzTab modification position '%s' in '%s'", part, token)
+			}
+			switch {
+			case position == 0:
+				slots = append(slots, -1)
+			case position == seqLen+1:
+				slots = append(slots, -2)
+			case position >= 1 && position <= seqLen:
+				slots = append(slots, position-1)
+			default:
+				return nil, fmt.Errorf("mzTab modification position %d out of range for sequence of length %d", position, seqLen)
+			}
+		}
+
+		if len(slots) == 1 {
+			options := map[string]interface{}{"isTerminal": slots[0] < 0}
+			mods[slots[0]] = append(mods[slots[0]], parser.createModification(value, options))
+			continue
+		}
+
+		groups++
+		group := "g" + strconv.Itoa(groups)
+		for i, slot := range slots {
+			annotation := "#" + group
+			if i == 0 {
+				annotation = value + annotation
+			}
+			mods[slot] = append(mods[slot], parser.createModification(annotation, nil))
+		}
+	}
+	return mods, nil
+}
+
+// mzTabProformaValue converts an mzTab modification identifier to a ProForma value
+func mzTabProformaValue(identifier string) (string, error) {
+	prefix, rest, found := strings.Cut(strings.TrimSpace(identifier), ":")
+	if found {
+		switch strings.ToUpper(prefix) {
+		case "UNIMOD", "MOD":
+			if _, err := strconv.Atoi(rest); err == nil {
+				return strings.ToUpper(prefix) + ":" + rest, nil
+			}
+		case "CHEMMOD":
+			if _, err := strconv.ParseFloat(rest, 64); err == nil {
+				if !strings.HasPrefix(rest, "+") && !strings.HasPrefix(rest, "-") {
+					rest = "+" + rest
+				}
+				return rest, nil
+			}
+			if rest != "" {
+				return "Formula:" + rest, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("unsupported mzTab modification identifier '%s'", identifier)
+}
+
+// splitMzTabModifications splits an mzTab modifications column on the commas that are not
+// inside the brackets of a parameter
+func splitMzTabModifications(modStr string) []string {
+	var tokens []string
+	for {
+		comma := indexOutsideBrackets(modStr, ',')
+		if comma == -1 {
+			return append(tokens, modStr)
+		}
+		tokens = append(tokens, modStr[:comma])
+		modStr = modStr[comma+1:]
+	}
+}
+
+// indexOutsideBrackets returns the index of the first target byte of s that is not inside
+// square brackets, or -1
+func indexOutsideBrackets(s string, target byte) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '[':
+			depth++
+		case ']':
+			depth--
+		case target:
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
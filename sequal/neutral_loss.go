@@ -0,0 +1,120 @@
+package sequal
+
+import "strings"
+
+// ModificationLoss is a neutral loss produced by a modification, named by the lost species
+type ModificationLoss struct {
+	Name string
+	Mass float64
+}
+
+// NeutralLossTable maps modification names to their characteristic neutral losses other
+// than the loss of the intact modification
+var NeutralLossTable = map[string][]ModificationLoss{
+	"Phospho":   {{Name: "H3PO4", Mass: 97.976896}},
+	"Oxidation": {{Name: "CH4OS", Mass: 63.998285}},
+}
+
+// NeutralLoss describes a neutral loss predicted for a labile modification at a location.
+// Name is the lost species: the modification itself for the intact loss, a NeutralLossTable
+// entry such as "H3PO4", or a monosaccharide of a glycan.
+type NeutralLoss struct {
+	Position     int
+	Side         TerminalSide
+	Modification *Modification
+	Name         string
+	Mass         float64
+}
+
+// GetNeutralLosses enumerates the neutral losses of the labile modifications of the sequence.
+// Modifications written as labile ("{Glycan:Hex}") are always considered; labileMods names
+// further modifications to treat as labile wherever they are located, matched
+// case-insensitively against the modification name, its Unimod name or "Glycan" for glycans.
+//
+// Each modification reports the loss of the intact modification when its mass is known,
+// followed by its NeutralLossTable entries. Glycans report the loss of each monosaccharide
+// (the complement of its oxonium ion) and of the whole glycan. Results follow the order of
+// GetModificationsByType.
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("PEPS[Phospho]TIDE")
+//	for _, loss := range seq.GetNeutralLosses("Phospho") {
+//		fmt.Printf("%s %.4f\n", loss.Name, loss.Mass)
+//	}
+//	// Phospho 79.9663
+//	// H3PO4 97.9769
+func (s *Sequence) GetNeutralLosses(labileMods ...string) []NeutralLoss {
+	losses := make([]NeutralLoss, 0)
+	seen := make(map[*Modification]bool)
+
+	for _, loc := range s.GetModificationsByType("") {
+		mod := loc.Modification
+		if seen[mod] || mod.IsCrosslinkRef() || mod.IsAmbiguityRef() {
+			continue
+		}
+		if loc.Side != TerminalSideLabile && !matchesLabileName(mod, labileMods) {
+			continue
+		}
+		seen[mod] = true
+
+		add := func(name string, mass float64) {
+			losses = append(losses, NeutralLoss{
+				Position:     loc.Position,
+				Side:         loc.Side,
+				Modification: mod,
+				Name:         name,
+				Mass:         mass,
+			})
+		}
+
+		if composition := mod.getGlycanComposition(); composition != nil {
+			total, totalCount := 0.0, 0
+			complete := true
+			for _, name := range sortedGlycanBlocks(composition) {
+				mass, err := glycanMonosaccharideMass(name)
+				if err != nil {
+					complete = false
+					continue
+				}
+				add(name, mass)
+				total += mass * float64(composition[name])
+				totalCount += composition[name]
+			}
+			if complete && totalCount > 1 {
+				add(mod.GetValue(), total)
+			}
+			continue
+		}
+
+		if mass := mod.GetResolvedMass(); mass != nil {
+			add(mod.GetValue(), *mass)
+		}
+		for _, loss := range NeutralLossTable[unimodName(mod)] {
+			add(loss.Name, loss.Mass)
+		}
+	}
+
+	return losses
+}
+
+// matchesLabileName reports whether a modification is named in labileMods
+func matchesLabileName(mod *Modification, labileMods []string) bool {
+	for _, name := range labileMods {
+		if strings.EqualFold(name, mod.GetValue()) || strings.EqualFold(name, unimodName(mod)) {
+			return true
+		}
+		if strings.EqualFold(name, "Glycan") && mod.getGlycanComposition() != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// unimodName returns the Unimod name of a modification, or its value if it is not in UnimodTable
+func unimodName(mod *Modification) string {
+	if entry, ok := resolveUnimodValue(mod.GetSource(), mod.GetValue()); ok {
+		return entry.Name
+	}
+	return mod.GetValue()
+}
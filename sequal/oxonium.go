@@ -0,0 +1,75 @@
+package sequal
+
+import "sort"
+
+// OxoniumIonLosses maps monosaccharides to the neutral losses of their oxonium ions that are
+// commonly observed in glycopeptide spectra
+var OxoniumIonLosses = map[string][]ModificationLoss{
+	"HexNAc": {
+		{Name: "H2O", Mass: 18.010565},
+		{Name: "2H2O", Mass: 36.021129},
+		{Name: "C2H4O2", Mass: 60.021129},
+		{Name: "CH6O3", Mass: 66.031694},
+		{Name: "C2H6O3", Mass: 78.031694},
+	},
+	"Hex":   {{Name: "H2O", Mass: 18.010565}},
+	"NeuAc": {{Name: "H2O", Mass: 18.010565}},
+	"NeuGc": {{Name: "H2O", Mass: 18.010565}},
+}
+
+// OxoniumIon is a singly charged, low-m/z glycan fragment ion, named by its monosaccharides
+// and any neutral loss, such as "HexNAc" or "HexNAc-H2O"
+type OxoniumIon struct {
+	Name string
+	Mz   float64
+}
+
+// GetOxoniumIons returns the characteristic oxonium ions of the "Glycan:" modifications of
+// the sequence, sorted by m/z. Each monosaccharide of the glycans gives its oxonium ion (its
+// GlycanBlockDict mass plus a proton) followed by its OxoniumIonLosses, and glycans holding
+// both Hex and HexNAc also give the HexHexNAc ion. Ions are listed once however many glycans
+// contain the monosaccharide. Custom formula blocks and glycans without a composition, such
+// as "GNO:" accessions, give no ions, and the result is empty when there is no glycan.
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("N[Glycan:HexNAc2Hex5]ST")
+//	for _, ion := range seq.GetOxoniumIons()[:3] {
+//		fmt.Printf("%s %.4f\n", ion.Name, ion.Mz)
+//	}
+//	// HexNAc-C2H6O3 126.0550
+//	// HexNAc-CH6O3 138.0550
+//	// HexNAc-C2H4O2 144.0655
+func (s *Sequence) GetOxoniumIons() []OxoniumIon {
+	ions := make([]OxoniumIon, 0)
+	blocks := make(map[string]int)
+	for _, loc := range s.GetModificationsByType("") {
+		for name, count := range loc.Modification.getGlycanComposition() {
+			blocks[name] += count
+		}
+	}
+
+	seen := make(map[string]bool)
+	add := func(name string, mass float64) {
+		if !seen[name] {
+			seen[name] = true
+			ions = append(ions, OxoniumIon{Name: name, Mz: mass + Proton})
+		}
+	}
+	for _, name := range sortedGlycanBlocks(blocks) {
+		mass, ok := glycanBlockMass(name)
+		if !ok {
+			continue
+		}
+		add(name, mass)
+		for _, loss := range OxoniumIonLosses[name] {
+			add(name+"-"+loss.Name, mass-loss.Mass)
+		}
+	}
+	if blocks["Hex"] > 0 && blocks["HexNAc"] > 0 {
+		add("HexHexNAc", GlycanBlockDict["Hex"]+GlycanBlockDict["HexNAc"])
+	}
+
+	sort.SliceStable(ions, func(i, j int) bool { return ions[i].Mz < ions[j].Mz })
+	return ions
+}
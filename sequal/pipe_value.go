@@ -69,6 +69,16 @@ func NewPipeValue(value string, valueType PipeValueType, originalValue string) *
 	return pv
 }
 
+// clone returns a copy of the pipe value with its own assigned types
+func (pv *PipeValue) clone() *PipeValue {
+	if pv == nil {
+		return nil
+	}
+	clone := *pv
+	clone.assignedTypes = append([]PipeValueType(nil), pv.assignedTypes...)
+	return &clone
+}
+
 // extractProperties extracts special properties from the value based on type
 func (pv *PipeValue) extractProperties() {
 	// Handle crosslink values with #
@@ -77,8 +87,7 @@ func (pv *PipeValue) extractProperties() {
 		if parts[1] == "BRANCH" {
 			pv.isBranch = true
 		} else {
-			crosslinkID := parts[1]
-			pv.crosslinkID = &crosslinkID
+			pv.setCrosslinkID(parts[1])
 		}
 	} else if pv.valueType == PipeValueTypeAmbiguity && strings.Contains(pv.value, "#") {
 		// Handle ambiguity values with #
@@ -151,6 +160,20 @@ func (pv *PipeValue) AssignType(valueType PipeValueType) {
 	pv.assignedTypes = append(pv.assignedTypes, valueType)
 }
 
+// crosslinkScorePattern matches a crosslink ID with a localization score, e.g. "XL1(0.90)"
+var crosslinkScorePattern = regexp.MustCompile(`^(XL[A-Za-z0-9]+)\(([\d.]+)\)$`)
+
+// setCrosslinkID sets the crosslink ID, moving a trailing "(score)" into the localization score
+func (pv *PipeValue) setCrosslinkID(crosslinkID string) {
+	if matches := crosslinkScorePattern.FindStringSubmatch(crosslinkID); matches != nil {
+		if score, err := strconv.ParseFloat(matches[2], 64); err == nil {
+			crosslinkID = matches[1]
+			pv.localizationScore = &score
+		}
+	}
+	pv.crosslinkID = &crosslinkID
+}
+
 // GetValue returns the value
 func (pv *PipeValue) GetValue() string {
 	return pv.value
@@ -240,3 +263,37 @@ func (pv *PipeValue) IsValidGlycan() bool {
 func (pv *PipeValue) IsValidFormula() bool {
 	return pv.isValidFormula
 }
+
+// GetNeutralMass returns the monoisotopic mass of a formula pipe value, ignoring any charge.
+// Returns nil if the value is not a formula or the formula cannot be evaluated.
+func (pv *PipeValue) GetNeutralMass() *float64 {
+	if pv.valueType != PipeValueTypeFormula {
+		return nil
+	}
+	mass, err := CalculateFormulaMass(pv.value)
+	if err != nil {
+		return nil
+	}
+	return &mass
+}
+
+// GetChargedMass returns the mass of a formula pipe value as the charged species (ProForma 2.1).
+// For a charge of z+N the formula has lost N electrons, so N electron masses are subtracted
+// from the neutral mass; for z-N they are added. Uncharged formulas return the neutral mass.
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("SEQUEN[Formula:Zn1:z+2]CE")
+//	pv := seq.GetSeq()[5].GetMods()[0].GetModificationValue().GetPipeValues()[0]
+//	fmt.Printf("%.4f\n", *pv.GetChargedMass()) // 63.9280
+func (pv *PipeValue) GetChargedMass() *float64 {
+	mass := pv.GetNeutralMass()
+	if mass == nil {
+		return nil
+	}
+	if pv.chargeValue == nil {
+		return mass
+	}
+	charged := *mass - float64(*pv.chargeValue)*ElectronMass
+	return &charged
+}
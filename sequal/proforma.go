@@ -5,6 +5,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 )
 
 // ProFormaParser handles parsing of ProForma 2.0 notation strings.
@@ -15,17 +16,50 @@ type ProFormaParser struct {
 	crosslinkRefPattern *regexp.Regexp
 	branchPattern       *regexp.Regexp
 	branchRefPattern    *regexp.Regexp
+	ambiguityPattern    *regexp.Regexp
+	ambiguityRefPattern *regexp.Regexp
+	version             string
+}
+
+// ProForma specification versions accepted by NewProFormaParserVersion
+const (
+	ProFormaVersion20 = "2.0"
+	ProFormaVersion21 = "2.1"
+)
+
+// NewProFormaParserVersion creates a ProFormaParser that only accepts the given ProForma
+// version. A "2.0" parser rejects the constructs introduced in ProForma 2.1 with a
+// *ParseError of kind ParseErrorUnsupportedFeature: named entities such as "(>name)",
+// charged formulas such as "[Formula:Zn1:z+2]" and the placement controls "Position:",
+// "Limit:", "CoMKP" and "CoMUP". A "2.1" parser behaves like NewProFormaParser.
+//
+// Example:
+//
+//	parser, _ := sequal.NewProFormaParserVersion(sequal.ProFormaVersion20)
+//	_, err := parser.ParseSequence("(>Tryptic)PEPTIDE")
+//	fmt.Println(err) // "named entity at position 0 requires ProForma 2.1"
+func NewProFormaParserVersion(version string) (*ProFormaParser, error) {
+	if version != ProFormaVersion20 && version != ProFormaVersion21 {
+		return nil, fmt.Errorf("unsupported ProForma version '%s', expected '%s' or '%s'", version, ProFormaVersion20, ProFormaVersion21)
+	}
+	p := NewProFormaParser()
+	p.version = version
+	return p, nil
 }
 
 // NewProFormaParser creates a new ProFormaParser with pre-compiled regex patterns
-// for parsing mass shifts, crosslinks, and branches.
+// for parsing mass shifts, crosslinks, branches, and ambiguity groups. Mass shifts may use
+// scientific notation such as "+7.9966e1"; they are written back exactly as given.
 func NewProFormaParser() *ProFormaParser {
 	return &ProFormaParser{
-		massShiftPattern:    regexp.MustCompile(`^[+-]\d+(\.\d+)?$`),
-		crosslinkPattern:    regexp.MustCompile(`^([^#]+)#(XL[A-Za-z0-9]+)$`),
-		crosslinkRefPattern: regexp.MustCompile(`^#(XL[A-Za-z0-9]+)$`),
+		massShiftPattern:    regexp.MustCompile(`^[+-]\d+(\.\d+)?([eE][+-]?\d+)?$`),
+		crosslinkPattern:    regexp.MustCompile(`^([^#]+)#(XL[A-Za-z0-9]+)(?:\(([0-9.]+)\))?$`),
+		crosslinkRefPattern: regexp.MustCompile(`^#(XL[A-Za-z0-9]+)(?:\(([0-9.]+)\))?$`),
 		branchPattern:       regexp.MustCompile(`^([^#]+)#BRANCH$`),
 		branchRefPattern:    regexp.MustCompile(`^#BRANCH$`),
+		ambiguityPattern:    regexp.MustCompile(`(.+?)#([A-Za-z0-9]+)(?:\(([0-9.]+)\))?$`),
+		ambiguityRefPattern: regexp.MustCompile(`#([A-Za-z0-9]+)(?:\(([0-9.]+)\))?$`),
+		version:             ProFormaVersion21,
 	}
 }
 
@@ -105,15 +139,20 @@ func ParseProForma(proformaStr string) (string, map[string][]*Modification, []*G
 //	result, _ = sequal.ParseProFormaDetailed(proformaStr)
 //	fmt.Println(*result.PeptidoformName) // "Tryptic peptide"
 func ParseProFormaDetailed(proformaStr string) (*ParseProFormaResult, error) {
-	parser := NewProFormaParser()
+	return NewProFormaParser().ParseDetailed(proformaStr)
+}
 
+// ParseDetailed parses a ProForma string into a structured result using this parser's
+// pre-compiled patterns. Reuse a single parser when parsing many strings to avoid
+// recompiling patterns per call.
+func (p *ProFormaParser) ParseDetailed(proformaStr string) (*ParseProFormaResult, error) {
 	// Extract named entities before parsing (ProForma 2.1)
 	var compoundIonName, peptidoformIonName, peptidoformName *string
 	originalStr := proformaStr
 
 	// Extract compound ion name (>>>name)
 	if strings.HasPrefix(originalStr, "(>>>") {
-		end := parser.findBalancedParen(originalStr, 4)
+		end := p.findBalancedParen(originalStr, 4)
 		if end > 0 {
 			name := originalStr[4 : end-1]
 			compoundIonName = &name
@@ -123,7 +162,7 @@ func ParseProFormaDetailed(proformaStr string) (*ParseProFormaResult, error) {
 
 	// Extract peptidoform ion name (>>name)
 	if strings.HasPrefix(originalStr, "(>>") {
-		end := parser.findBalancedParen(originalStr, 3)
+		end := p.findBalancedParen(originalStr, 3)
 		if end > 0 {
 			name := originalStr[3 : end-1]
 			peptidoformIonName = &name
@@ -133,14 +172,14 @@ func ParseProFormaDetailed(proformaStr string) (*ParseProFormaResult, error) {
 
 	// Extract peptidoform name (>name)
 	if strings.HasPrefix(originalStr, "(>") {
-		end := parser.findBalancedParen(originalStr, 2)
+		end := p.findBalancedParen(originalStr, 2)
 		if end > 0 {
 			name := originalStr[2 : end-1]
 			peptidoformName = &name
 		}
 	}
 
-	baseSeq, mods, globalMods, seqAmbig, chargeInfo, err := parser.Parse(proformaStr)
+	baseSeq, mods, globalMods, seqAmbig, chargeInfo, err := p.Parse(proformaStr)
 	if err != nil {
 		return nil, err
 	}
@@ -160,7 +199,7 @@ func ParseProFormaDetailed(proformaStr string) (*ParseProFormaResult, error) {
 	}
 
 	if strings.Contains(proformaStr, "/") {
-		chargeInfoResult, err := parser.parseChargeInfo(proformaStr)
+		chargeInfoResult, err := p.parseChargeInfo(proformaStr)
 		if err == nil && len(chargeInfoResult) > 2 {
 			if species, ok := chargeInfoResult[2].(*string); ok {
 				result.IonicSpecies = species
@@ -191,6 +230,26 @@ func (p *ProFormaParser) findBalancedParen(s string, start int) int {
 	return -1
 }
 
+// findBalancedBrace finds the matching closing curly brace, counting braces nested inside
+// such as the custom monosaccharides of "{Glycan:{C8H13N1O5}1Hex2}", and returns the
+// position after it or -1
+func (p *ProFormaParser) findBalancedBrace(s string, start int) int {
+	count := 1
+	i := start
+	for i < len(s) && count > 0 {
+		if s[i] == '{' {
+			count++
+		} else if s[i] == '}' {
+			count--
+		}
+		i++
+	}
+	if count == 0 {
+		return i
+	}
+	return -1
+}
+
 // findBalancedAngleBracket finds the matching closing angle bracket for a global modification
 // ProForma 2.1: Handles > in modification names like Gln->pyro-Glu
 func (p *ProFormaParser) findBalancedAngleBracket(s string, start int) int {
@@ -215,20 +274,30 @@ func (p *ProFormaParser) findBalancedAngleBracket(s string, start int) int {
 // Parse parses a ProForma string into its constituent parts and returns the base sequence,
 // modifications map, global modifications, sequence ambiguities, and charge information.
 // This is the main parsing method that handles all ProForma 2.1 notation elements.
+// Malformed input is reported as a *ParseError carrying the error kind and byte offset.
 func (p *ProFormaParser) Parse(proformaStr string) (string, map[string][]*Modification, []*GlobalModification, []*SequenceAmbiguity, []*int, error) {
 	baseSequence := ""
 	modifications := make(map[string][]*Modification)
 	globalMods := make([]*GlobalModification, 0)
 	sequenceAmbiguities := make([]*SequenceAmbiguity, 0)
 
+	// offset tracks how many bytes have been consumed from the front of the input, so
+	// errors can report positions within the original string
+	offset := 0
+
 	// Extract named entities (ProForma 2.1 Section 8.2) - strip from input but don't return
 	// (names are extracted separately in ParseProFormaDetailed)
+	if p.version == ProFormaVersion20 && strings.HasPrefix(proformaStr, "(>") {
+		return "", nil, nil, nil, nil, newParseError(ParseErrorUnsupportedFeature, offset,
+			"named entity", " requires ProForma 2.1")
+	}
 
 	// Extract compound ion name (>>>name)
 	if strings.HasPrefix(proformaStr, "(>>>") {
 		end := p.findBalancedParen(proformaStr, 4)
 		if end > 0 {
 			proformaStr = proformaStr[end:]
+			offset += end
 		}
 	}
 
@@ -237,6 +306,7 @@ func (p *ProFormaParser) Parse(proformaStr string) (string, map[string][]*Modifi
 		end := p.findBalancedParen(proformaStr, 3)
 		if end > 0 {
 			proformaStr = proformaStr[end:]
+			offset += end
 		}
 	}
 
@@ -245,6 +315,7 @@ func (p *ProFormaParser) Parse(proformaStr string) (string, map[string][]*Modifi
 		end := p.findBalancedParen(proformaStr, 2)
 		if end > 0 {
 			proformaStr = proformaStr[end:]
+			offset += end
 		}
 	}
 
@@ -266,25 +337,33 @@ func (p *ProFormaParser) Parse(proformaStr string) (string, map[string][]*Modifi
 		// Find balanced closing > (to handle > in modification names like Gln->pyro-Glu)
 		endBracket := p.findBalancedAngleBracket(proformaStr, 1)
 		if endBracket == -1 {
-			return "", nil, nil, nil, nil, fmt.Errorf("unclosed global modification angle bracket")
+			return "", nil, nil, nil, nil, newParseError(ParseErrorUnclosedAngleBracket, offset,
+				"unclosed global modification angle bracket", "")
 		}
 
 		globalModStr := proformaStr[1 : endBracket-1]
 		proformaStr = proformaStr[endBracket:]
+		globalModOffset := offset
+		offset += endBracket
 
 		if strings.Contains(globalModStr, "@") {
 			// Fixed protein modification
 			parts := strings.Split(globalModStr, "@")
 			if len(parts) != 2 {
-				return "", nil, nil, nil, nil, fmt.Errorf("invalid global modification format")
+				return "", nil, nil, nil, nil, newParseError(ParseErrorInvalidGlobalModification, globalModOffset,
+					"invalid global modification format", "")
 			}
 
 			modPart, targets := parts[0], parts[1]
 			modValue := modPart
 
-			if strings.HasPrefix(modPart, "[") && strings.HasSuffix(modPart, "]") {
+			bracketed := strings.HasPrefix(modPart, "[") && strings.HasSuffix(modPart, "]")
+			if bracketed {
 				modValue = modPart[1 : len(modPart)-1]
 			}
+			if strings.TrimSpace(modValue) == "" {
+				return "", nil, nil, nil, nil, newEmptyModificationError(globalModOffset)
+			}
 
 			// ProForma 2.1: Parse placement control tags (Section 11.2)
 			var positionConstraint []string
@@ -315,32 +394,100 @@ func (p *ProFormaParser) Parse(proformaStr string) (string, map[string][]*Modifi
 			}
 
 			targetResidues := strings.Split(targets, ",")
-			globalMods = append(globalMods, NewGlobalModification(modValue, targetResidues, "fixed", positionConstraint, limitPerPosition, colocalizeKnown, colocalizeUnknown))
+			globalMod := NewGlobalModification(modValue, targetResidues, "fixed", positionConstraint, limitPerPosition, colocalizeKnown, colocalizeUnknown)
+			globalMod.bare = !bracketed
+			globalMod.SetSourceSpan(globalModOffset, offset)
+			globalMods = append(globalMods, globalMod)
 		} else {
 			// Isotope labeling
-			globalMods = append(globalMods, NewGlobalModification(globalModStr, nil, "isotope", nil, nil, false, false))
+			if strings.TrimSpace(globalModStr) == "" {
+				return "", nil, nil, nil, nil, newEmptyModificationError(globalModOffset)
+			}
+			globalMod := NewGlobalModification(globalModStr, nil, "isotope", nil, nil, false, false)
+			globalMod.SetSourceSpan(globalModOffset, offset)
+			globalMods = append(globalMods, globalMod)
 		}
 	}
 
-	// Handle unknown position modifications
-	if strings.Contains(proformaStr, "?") {
+	// Parse labile modifications
+	parseLabile := func() error {
 		i := 0
+		for i < len(proformaStr) && proformaStr[i] == '{' {
+			end := p.findBalancedBrace(proformaStr, i+1)
+			if end == -1 {
+				return newParseError(ParseErrorUnclosedBrace, offset+i,
+					"unclosed curly brace", "")
+			}
+			j := end - 1
+
+			modStr := proformaStr[i+1 : j]
+			if strings.TrimSpace(modStr) == "" {
+				return newEmptyModificationError(offset + i)
+			}
+
+			// A "^n" multiplier repeats the labile modification n times
+			count, next, ok := parseMultiplier(proformaStr, j+1)
+			if !ok {
+				return newInvalidMultiplierError(offset + j + 1)
+			}
+
+			// Labile modifications are numbered by order of appearance, starting at 1
+			for k := 0; k < count; k++ {
+				currentMods := getModsAtPosition(-3)
+				mod := p.createModification(modStr, map[string]interface{}{
+					"isLabile":     true,
+					"labileNumber": len(currentMods) + 1,
+				})
+				mod.SetSourceSpan(offset+i, offset+j+1)
+				currentMods = append(currentMods, mod)
+				setModsAtPosition(-3, currentMods)
+			}
+			i = next
+		}
+
+		proformaStr = proformaStr[i:]
+		offset += i
+		return nil
+	}
+
+	// Handle unknown position modifications. "[Mod]?" is at an unknown position and
+	// "[Mod]?-" is at an unknown terminus; a block of brackets not followed by '?' is
+	// left for the terminal and residue parsing below.
+	parseUnknownPosition := func() error {
+		if !strings.Contains(proformaStr, "?") {
+			return nil
+		}
+		i := 0
+		consumed := 0
 		var unknownPosMods []string
+		var unknownPosSpans [][2]int
 		proformaRunes := []rune(proformaStr)
 
 		for i < len(proformaRunes) {
 			if proformaRunes[i] != '[' {
-				if len(unknownPosMods) > 0 && i < len(proformaRunes) && proformaRunes[i] == '?' {
-					for _, modStr := range unknownPosMods {
-						mod := p.createModification(modStr, map[string]interface{}{"isUnknownPosition": true})
-						currentMods := getModsAtPosition(-4)
-						currentMods = append(currentMods, mod)
-						setModsAtPosition(-4, currentMods)
-					}
+				if len(unknownPosMods) == 0 || proformaRunes[i] != '?' {
+					break
+				}
+				i++
+				position, options := -4, map[string]interface{}{"isUnknownPosition": true}
+				if i < len(proformaRunes) && proformaRunes[i] == '-' {
 					i++
+					position, options = -5, map[string]interface{}{"isUnknownTerminal": true}
+				}
+				for k, modStr := range unknownPosMods {
+					mod := p.createModification(modStr, options)
+					mod.SetSourceSpan(unknownPosSpans[k][0], unknownPosSpans[k][1])
+					currentMods := getModsAtPosition(position)
+					currentMods = append(currentMods, mod)
+					setModsAtPosition(position, currentMods)
 				}
 				unknownPosMods = nil
-				break
+				unknownPosSpans = nil
+				consumed = i
+				if position == -5 {
+					break
+				}
+				continue
 			}
 
 			bracketCount := 1
@@ -355,10 +502,16 @@ func (p *ProFormaParser) Parse(proformaStr string) (string, map[string][]*Modifi
 			}
 
 			if bracketCount > 0 {
-				return "", nil, nil, nil, nil, fmt.Errorf("unclosed bracket at position %d", i)
+				bracketOffset := offset + len(string(proformaRunes[:i]))
+				return newParseError(ParseErrorUnclosedBracket, bracketOffset,
+					"unclosed bracket", "")
 			}
 
 			modStr := string(proformaRunes[i+1 : j-1])
+			span := [2]int{offset + len(string(proformaRunes[:i])), offset + len(string(proformaRunes[:j]))}
+			if strings.TrimSpace(modStr) == "" {
+				return newEmptyModificationError(span[0])
+			}
 
 			count := 1
 			if j < len(proformaRunes) && proformaRunes[j] == '^' {
@@ -378,86 +531,52 @@ func (p *ProFormaParser) Parse(proformaStr string) (string, map[string][]*Modifi
 
 			for k := 0; k < count; k++ {
 				unknownPosMods = append(unknownPosMods, modStr)
+				unknownPosSpans = append(unknownPosSpans, span)
 			}
 			i = j
 		}
-		proformaStr = string(proformaRunes[i:])
+		offset += len(string(proformaRunes[:consumed]))
+		proformaStr = string(proformaRunes[consumed:])
+		return nil
 	}
 
-	// Parse labile modifications
-	i := 0
-	for i < len(proformaStr) && proformaStr[i] == '{' {
-		j := strings.Index(proformaStr[i:], "}")
-		if j == -1 {
-			return "", nil, nil, nil, nil, fmt.Errorf("unclosed curly brace at position %d", i)
+	// The labile and unknown position prefixes may be written in either order
+	for {
+		remaining := len(proformaStr)
+		if err := parseLabile(); err != nil {
+			return "", nil, nil, nil, nil, err
+		}
+		if err := parseUnknownPosition(); err != nil {
+			return "", nil, nil, nil, nil, err
+		}
+		if len(proformaStr) == remaining {
+			break
 		}
-		j += i
-
-		modStr := proformaStr[i+1 : j]
-
-		mod := p.createModification(modStr, map[string]interface{}{"isLabile": true})
-		currentMods := getModsAtPosition(-3)
-		currentMods = append(currentMods, mod)
-		setModsAtPosition(-3, currentMods)
-		i = j + 1
 	}
 
-	proformaStr = proformaStr[i:]
-
 	// Parse N-terminal modifications
 	if strings.HasPrefix(proformaStr, "[") {
-		bracketLevel := 0
-		terminatorPos := -1
-
-		for i, char := range proformaStr {
-			switch char {
-			case '[':
-				bracketLevel++
-			case ']':
-				bracketLevel--
-			case '-':
-				if bracketLevel == 0 {
-					terminatorPos = i
-					break
-				}
-			}
-			if terminatorPos != -1 {
-				break
-			}
-		}
+		terminatorPos := p.findTerminalSeparator(proformaStr, false)
 
 		if terminatorPos != -1 {
 			nTerminalPart := proformaStr[:terminatorPos]
 			proformaStr = proformaStr[terminatorPos+1:]
+			nTerminalOffset := offset
+			offset += terminatorPos + 1
 
-			currentPos := 0
-			for currentPos < len(nTerminalPart) {
-				if nTerminalPart[currentPos] == '[' {
-					bracketDepth := 1
-					endPos := currentPos + 1
-
-					for endPos < len(nTerminalPart) && bracketDepth > 0 {
-						if nTerminalPart[endPos] == '[' {
-							bracketDepth++
-						}
-						if nTerminalPart[endPos] == ']' {
-							bracketDepth--
-						}
-						endPos++
-					}
-
-					if bracketDepth == 0 {
-						modString := nTerminalPart[currentPos+1 : endPos-1]
-						nTermMod := p.createModification(modString, map[string]interface{}{"isTerminal": true})
-						currentMods := getModsAtPosition(-1)
-						currentMods = append(currentMods, nTermMod)
-						setModsAtPosition(-1, currentMods)
-					}
-
-					currentPos = endPos
-				} else {
-					currentPos++
+			modStrings, spans, invalidAt := p.extractBracketedMods(nTerminalPart)
+			if invalidAt != -1 {
+				return "", nil, nil, nil, nil, newInvalidMultiplierError(nTerminalOffset + invalidAt)
+			}
+			for k, modString := range modStrings {
+				if strings.TrimSpace(modString) == "" {
+					return "", nil, nil, nil, nil, newEmptyModificationError(nTerminalOffset + spans[k][0])
 				}
+				nTermMod := p.createModification(modString, map[string]interface{}{"isTerminal": true})
+				nTermMod.SetSourceSpan(nTerminalOffset+spans[k][0], nTerminalOffset+spans[k][1])
+				currentMods := getModsAtPosition(-1)
+				currentMods = append(currentMods, nTermMod)
+				setModsAtPosition(-1, currentMods)
 			}
 		}
 	}
@@ -471,68 +590,35 @@ func (p *ProFormaParser) Parse(proformaStr string) (string, map[string][]*Modifi
 
 	// Parse C-terminal modifications
 	if strings.Contains(proformaStr, "-") {
-		bracketLevel := 0
-		terminatorPos := -1
-
-		// Scan from right to left
-		proformaRunes := []rune(proformaStr)
-		for i := len(proformaRunes) - 1; i >= 0; i-- {
-			switch proformaRunes[i] {
-			case ']':
-				bracketLevel++
-			case '[':
-				bracketLevel--
-			case '-':
-				if bracketLevel == 0 {
-					terminatorPos = i
-					break
-				}
-			}
-			if terminatorPos != -1 {
-				break
-			}
-		}
+		terminatorPos := p.findTerminalSeparator(proformaStr, true)
 
 		if terminatorPos != -1 {
-			cTerminalPart := string(proformaRunes[terminatorPos+1:])
-			proformaStr = string(proformaRunes[:terminatorPos])
-
-			currentPos := 0
-			for currentPos < len(cTerminalPart) {
-				if cTerminalPart[currentPos] == '[' {
-					bracketDepth := 1
-					endPos := currentPos + 1
-
-					for endPos < len(cTerminalPart) && bracketDepth > 0 {
-						if cTerminalPart[endPos] == '[' {
-							bracketDepth++
-						}
-						if cTerminalPart[endPos] == ']' {
-							bracketDepth--
-						}
-						endPos++
-					}
-
-					if bracketDepth == 0 {
-						modString := cTerminalPart[currentPos+1 : endPos-1]
-						cTermMod := p.createModification(modString, map[string]interface{}{"isTerminal": true})
-						currentMods := getModsAtPosition(-2)
-						currentMods = append(currentMods, cTermMod)
-						setModsAtPosition(-2, currentMods)
-					}
+			cTerminalPart := proformaStr[terminatorPos+1:]
+			proformaStr = proformaStr[:terminatorPos]
 
-					currentPos = endPos
-				} else {
-					currentPos++
+			cTerminalOffset := offset + terminatorPos + 1
+			modStrings, spans, invalidAt := p.extractBracketedMods(cTerminalPart)
+			if invalidAt != -1 {
+				return "", nil, nil, nil, nil, newInvalidMultiplierError(cTerminalOffset + invalidAt)
+			}
+			for k, modString := range modStrings {
+				if strings.TrimSpace(modString) == "" {
+					return "", nil, nil, nil, nil, newEmptyModificationError(cTerminalOffset + spans[k][0])
 				}
+				cTermMod := p.createModification(modString, map[string]interface{}{"isTerminal": true})
+				cTermMod.SetSourceSpan(cTerminalOffset+spans[k][0], cTerminalOffset+spans[k][1])
+				currentMods := getModsAtPosition(-2)
+				currentMods = append(currentMods, cTermMod)
+				setModsAtPosition(-2, currentMods)
 			}
 		}
 	}
 
 	// Parse main sequence
-	i = 0
+	i := 0
 	nextModIsGap := false
 	var rangeStack []int
+	var rangeOffsets []int
 
 	for i < len(proformaStr) {
 		char := proformaStr[i]
@@ -540,7 +626,8 @@ func (p *ProFormaParser) Parse(proformaStr string) (string, map[string][]*Modifi
 		if i+1 < len(proformaStr) && proformaStr[i:i+2] == "(?" {
 			closingParen := strings.Index(proformaStr[i+2:], ")")
 			if closingParen == -1 {
-				return "", nil, nil, nil, nil, fmt.Errorf("unclosed sequence ambiguity parenthesis")
+				return "", nil, nil, nil, nil, newParseError(ParseErrorUnclosedParenthesis, offset+i,
+					"unclosed sequence ambiguity parenthesis", "")
 			}
 			closingParen += i + 2
 
@@ -554,16 +641,19 @@ func (p *ProFormaParser) Parse(proformaStr string) (string, map[string][]*Modifi
 		switch char {
 		case '(':
 			rangeStack = append(rangeStack, len(baseSequence))
+			rangeOffsets = append(rangeOffsets, offset+i)
 			i++
 			continue
 
 		case ')':
 			if len(rangeStack) == 0 {
-				return "", nil, nil, nil, nil, fmt.Errorf("unmatched closing parenthesis")
+				return "", nil, nil, nil, nil, newParseError(ParseErrorUnmatchedParenthesis, offset+i,
+					"unmatched closing parenthesis", "")
 			}
 
 			rangeStart := rangeStack[len(rangeStack)-1]
 			rangeStack = rangeStack[:len(rangeStack)-1]
+			rangeOffsets = rangeOffsets[:len(rangeOffsets)-1]
 			rangeEnd := len(baseSequence) - 1
 
 			// Look for modification after the range
@@ -584,11 +674,22 @@ func (p *ProFormaParser) Parse(proformaStr string) (string, map[string][]*Modifi
 
 				if bracketCount == 0 {
 					modStr := proformaStr[modStart+1 : j-1]
-					mod := p.createModification(modStr, map[string]interface{}{
+					if strings.TrimSpace(modStr) == "" {
+						return "", nil, nil, nil, nil, newEmptyModificationError(offset + modStart)
+					}
+					options := map[string]interface{}{
 						"inRange":    true,
 						"rangeStart": rangeStart,
 						"rangeEnd":   rangeEnd,
-					})
+					}
+					// A crosslink on a range links one residue of the range, not yet localized
+					if p.crosslinkRefPattern.MatchString(modStr) {
+						options["isCrosslinkRef"] = true
+					} else if matches := p.crosslinkPattern.FindStringSubmatch(modStr); matches != nil {
+						options["crosslinkId"] = matches[2]
+					}
+					mod := p.createModification(modStr, options)
+					mod.SetSourceSpan(offset+modStart, offset+j)
 
 					for pos := rangeStart; pos <= rangeEnd; pos++ {
 						currentMods := getModsAtPosition(pos)
@@ -612,10 +713,14 @@ func (p *ProFormaParser) Parse(proformaStr string) (string, map[string][]*Modifi
 			}
 
 			if bracketCount > 0 {
-				return "", nil, nil, nil, nil, fmt.Errorf("unclosed square bracket at position %d", i)
+				return "", nil, nil, nil, nil, newParseError(ParseErrorUnclosedBracket, offset+i,
+					"unclosed square bracket", "")
 			}
 
 			modStr := proformaStr[i+1 : j-1]
+			if strings.TrimSpace(modStr) == "" {
+				return "", nil, nil, nil, nil, newEmptyModificationError(offset + i)
+			}
 			var mod *Modification
 
 			if nextModIsGap {
@@ -632,6 +737,7 @@ func (p *ProFormaParser) Parse(proformaStr string) (string, map[string][]*Modifi
 			} else {
 				mod = p.createModification(modStr, nil)
 			}
+			mod.SetSourceSpan(offset+i, offset+j)
 
 			if len(baseSequence) > 0 {
 				currentMods := getModsAtPosition(len(baseSequence) - 1)
@@ -642,14 +748,19 @@ func (p *ProFormaParser) Parse(proformaStr string) (string, map[string][]*Modifi
 			i = j
 
 		case '{':
-			j := strings.Index(proformaStr[i:], "}")
-			if j == -1 {
-				return "", nil, nil, nil, nil, fmt.Errorf("unclosed curly brace at position %d", i)
+			end := p.findBalancedBrace(proformaStr, i+1)
+			if end == -1 {
+				return "", nil, nil, nil, nil, newParseError(ParseErrorUnclosedBrace, offset+i,
+					"unclosed curly brace", "")
 			}
-			j += i
+			j := end - 1
 
 			modStr := proformaStr[i+1 : j]
+			if strings.TrimSpace(modStr) == "" {
+				return "", nil, nil, nil, nil, newEmptyModificationError(offset + i)
+			}
 			mod := p.createModification(modStr, map[string]interface{}{"isAmbiguous": true})
+			mod.SetSourceSpan(offset+i, offset+j+1)
 
 			if len(baseSequence) > 0 {
 				currentMods := getModsAtPosition(len(baseSequence) - 1)
@@ -660,6 +771,11 @@ func (p *ProFormaParser) Parse(proformaStr string) (string, map[string][]*Modifi
 			i = j + 1
 
 		default:
+			if char < 'A' || char > 'Z' {
+				r, _ := utf8.DecodeRuneInString(proformaStr[i:])
+				return "", nil, nil, nil, nil, newParseError(ParseErrorInvalidResidue, offset+i,
+					fmt.Sprintf("invalid residue %q", r), "")
+			}
 			baseSequence += string(char)
 			isGap := char == 'X' && i+1 < len(proformaStr) && proformaStr[i+1] == '['
 			if isGap {
@@ -670,7 +786,8 @@ func (p *ProFormaParser) Parse(proformaStr string) (string, map[string][]*Modifi
 	}
 
 	if len(rangeStack) > 0 {
-		return "", nil, nil, nil, nil, fmt.Errorf("unclosed parenthesis")
+		return "", nil, nil, nil, nil, newParseError(ParseErrorUnclosedParenthesis, rangeOffsets[len(rangeOffsets)-1],
+			"unclosed parenthesis", "")
 	}
 
 	var chargeInfoResult []*int
@@ -682,15 +799,178 @@ func (p *ProFormaParser) Parse(proformaStr string) (string, map[string][]*Modifi
 		}
 	}
 
+	if p.version == ProFormaVersion20 {
+		if err := checkProForma20(modifications, globalMods); err != nil {
+			return "", nil, nil, nil, nil, err
+		}
+	}
+
 	return baseSequence, modifications, globalMods, sequenceAmbiguities, chargeInfoResult, nil
 }
 
+// checkProForma20 returns a ParseError for the first ProForma 2.1 construct used by the
+// parsed modifications, or nil if all of them are valid ProForma 2.0
+func checkProForma20(modifications map[string][]*Modification, globalMods []*GlobalModification) error {
+	var first *ParseError
+	check := func(mod *Modification) {
+		feature := ""
+		for _, pv := range mod.GetModificationValue().GetPipeValues() {
+			if pv.GetCharge() != nil {
+				feature = "charged formula"
+			}
+			// Placement controls outside a global modification are kept as pipe values
+			if pv.GetSource() == nil && isPlacementControl(pv.GetValue()) {
+				feature = "placement control"
+			}
+		}
+		if len(mod.GetPositionConstraint()) > 0 || mod.GetLimitPerPosition() != nil ||
+			mod.GetColocalizeKnown() || mod.GetColocalizeUnknown() {
+			feature = "placement control"
+		}
+		if feature == "" {
+			return
+		}
+		start, _, _ := mod.GetSourceSpan()
+		if first == nil || start < first.Offset {
+			first = newParseError(ParseErrorUnsupportedFeature, start,
+				fmt.Sprintf("%s '%s'", feature, mod.ToProforma()), " requires ProForma 2.1")
+		}
+	}
+
+	for _, gm := range globalMods {
+		check(&gm.Modification)
+	}
+	for _, mods := range modifications {
+		for _, mod := range mods {
+			check(mod)
+		}
+	}
+
+	if first != nil {
+		return first
+	}
+	return nil
+}
+
+// isPlacementControl reports whether a pipe value is a ProForma 2.1 placement control tag
+func isPlacementControl(value string) bool {
+	switch value {
+	case "CoMKP", "CoMUP", "ColocaliseModificationsOfKnownPosition", "ColocaliseModificationsOfUnknownPosition":
+		return true
+	}
+	return strings.HasPrefix(value, "Position:") || strings.HasPrefix(value, "Limit:")
+}
+
+// findTerminalSeparator returns the byte index of the '-' separating terminal modifications
+// from the sequence, ignoring any '-' inside square brackets (e.g. "[Gln->pyro-Glu]").
+// The N-terminal separator is the first one scanning left to right; the C-terminal separator
+// (fromRight) is the first one scanning right to left. Brackets and '-' are single-byte in
+// UTF-8, so the byte scan is rune-safe and the index can be used to slice s directly.
+// Returns -1 if no separator is found.
+func (p *ProFormaParser) findTerminalSeparator(s string, fromRight bool) int {
+	bracketLevel := 0
+
+	if fromRight {
+		for i := len(s) - 1; i >= 0; i-- {
+			switch s[i] {
+			case ']':
+				bracketLevel++
+			case '[':
+				bracketLevel--
+			case '-':
+				if bracketLevel == 0 {
+					return i
+				}
+			}
+		}
+		return -1
+	}
+
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '[':
+			bracketLevel++
+		case ']':
+			bracketLevel--
+		case '-':
+			if bracketLevel == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// extractBracketedMods returns the contents of each top-level square-bracketed
+// modification in a terminal part such as "[Acetyl][+1.0]", along with the [start, end)
+// offset of each bracketed modification within terminalPart. A modification followed by
+// a "^n" multiplier is repeated n times. The last value is the offset of an invalid
+// multiplier within terminalPart, or -1 if all multipliers are valid.
+func (p *ProFormaParser) extractBracketedMods(terminalPart string) ([]string, [][2]int, int) {
+	var modStrings []string
+	var spans [][2]int
+
+	currentPos := 0
+	for currentPos < len(terminalPart) {
+		if terminalPart[currentPos] != '[' {
+			currentPos++
+			continue
+		}
+
+		bracketDepth := 1
+		endPos := currentPos + 1
+		for endPos < len(terminalPart) && bracketDepth > 0 {
+			if terminalPart[endPos] == '[' {
+				bracketDepth++
+			}
+			if terminalPart[endPos] == ']' {
+				bracketDepth--
+			}
+			endPos++
+		}
+
+		if bracketDepth == 0 {
+			count, next, ok := parseMultiplier(terminalPart, endPos)
+			if !ok {
+				return nil, nil, endPos
+			}
+			for k := 0; k < count; k++ {
+				modStrings = append(modStrings, terminalPart[currentPos+1:endPos-1])
+				spans = append(spans, [2]int{currentPos, endPos})
+			}
+			endPos = next
+		}
+		currentPos = endPos
+	}
+
+	return modStrings, spans, -1
+}
+
+// parseMultiplier parses an optional "^n" multiplier starting at index i of s. It returns
+// the count (1 when there is no multiplier), the index after the multiplier, and false if
+// a '^' is not followed by a positive integer.
+func parseMultiplier(s string, i int) (int, int, bool) {
+	if i >= len(s) || s[i] != '^' {
+		return 1, i, true
+	}
+	j := i + 1
+	for j < len(s) && s[j] >= '0' && s[j] <= '9' {
+		j++
+	}
+	count, err := strconv.Atoi(s[i+1 : j])
+	if err != nil || count < 1 {
+		return 0, i, false
+	}
+	return count, j, true
+}
+
 // createModification creates a Modification instance with the specified options.
 // The options map contains various boolean flags and values that control the modification type.
 func (p *ProFormaParser) createModification(modStr string, options map[string]interface{}) *Modification {
 	isTerminal := false
 	isAmbiguous := false
 	isLabile := false
+	labileNumber := 0
 	isUnknownPosition := false
 	var crosslinkId *string
 	isCrosslinkRef := false
@@ -711,9 +991,15 @@ func (p *ProFormaParser) createModification(modStr string, options map[string]in
 		if v, ok := options["isLabile"].(bool); ok {
 			isLabile = v
 		}
+		if v, ok := options["labileNumber"].(int); ok {
+			labileNumber = v
+		}
 		if v, ok := options["isUnknownPosition"].(bool); ok {
 			isUnknownPosition = v
 		}
+		if v, ok := options["isUnknownTerminal"].(bool); ok {
+			isTerminal = v
+		}
 		if v, ok := options["crosslinkId"].(string); ok {
 			crosslinkId = &v
 		}
@@ -778,17 +1064,14 @@ func (p *ProFormaParser) createModification(modStr string, options map[string]in
 				nil, false, false, false, nil, false, true, rangeStart, rangeEnd, nil, modValueForMassShift,
 				positionConstraint, limitPerPosition, colocalizeKnown, colocalizeUnknown, p.isIonTypeModification(modStr))
 		}
-		return NewModification("Mass:"+modStr, nil, nil, nil, modType, isLabile, 0, massValue, false,
+		return NewModification("Mass:"+modStr, nil, nil, nil, modType, isLabile, labileNumber, massValue, false,
 			nil, false, false, false, nil, false, inRange, rangeStart, rangeEnd, nil, modValueForMassShift,
 			positionConstraint, limitPerPosition, colocalizeKnown, colocalizeUnknown, p.isIonTypeModification(modStr))
 	}
 
 	// Handle ambiguity patterns
-	ambiguityPattern := regexp.MustCompile(`(.+?)#([A-Za-z0-9]+)(?:\(([0-9.]+)\))?$`)
-	ambiguityRefPattern := regexp.MustCompile(`#([A-Za-z0-9]+)(?:\(([0-9.]+)\))?$`)
-
 	if strings.Contains(modStr, "#") && !isCrosslinkRef && !isBranch && !isBranchRef && crosslinkId == nil {
-		if matches := ambiguityPattern.FindStringSubmatch(modStr); matches != nil && !strings.HasPrefix(matches[2], "XL") {
+		if matches := p.ambiguityPattern.FindStringSubmatch(modStr); matches != nil && !strings.HasPrefix(matches[2], "XL") {
 			modStr = matches[1]
 			ambiguityGroup := matches[2]
 			var localizationScore *float64
@@ -800,7 +1083,7 @@ func (p *ProFormaParser) createModification(modStr string, options map[string]in
 			return NewModification(modStr, nil, nil, nil, "ambiguous", false, 0, 0.0, false,
 				nil, false, false, false, &ambiguityGroup, false, inRange, rangeStart, rangeEnd, localizationScore, modValue,
 				positionConstraint, limitPerPosition, colocalizeKnown, colocalizeUnknown, p.isIonTypeModification(modStr))
-		} else if matches := ambiguityRefPattern.FindStringSubmatch(modStr); matches != nil && !strings.HasPrefix(matches[1], "XL") {
+		} else if matches := p.ambiguityRefPattern.FindStringSubmatch(modStr); matches != nil && !strings.HasPrefix(matches[1], "XL") {
 			ambiguityGroup := matches[1]
 			var localizationScore *float64
 			if len(matches) > 2 && matches[2] != "" {
@@ -814,8 +1097,24 @@ func (p *ProFormaParser) createModification(modStr string, options map[string]in
 		}
 	}
 
+	// Gaps of known composition (e.g. "X[Formula:C16H30N4]") take their mass from the formula
+	if isGap && modValue.GetSource() != nil && strings.ToUpper(*modValue.GetSource()) == "FORMULA" {
+		for _, pv := range modValue.GetPipeValues() {
+			if pv.GetType() != PipeValueTypeFormula {
+				continue
+			}
+			if gapMass := pv.GetChargedMass(); gapMass != nil {
+				modValue = NewModificationValue(modStr, gapMass)
+				return NewModification(modStr, nil, nil, nil, "gap", false, 0, *gapMass, false,
+					nil, false, false, false, nil, false, inRange, rangeStart, rangeEnd, nil, modValue,
+					positionConstraint, limitPerPosition, colocalizeKnown, colocalizeUnknown, p.isIonTypeModification(modStr))
+			}
+			break
+		}
+	}
+
 	// Create the modification with appropriate attributes
-	return NewModification(modStr, nil, nil, nil, modType, isLabile, 0, 0.0, false,
+	return NewModification(modStr, nil, nil, nil, modType, isLabile, labileNumber, 0.0, false,
 		crosslinkId, isCrosslinkRef, isBranchRef, isBranch, nil, false, inRange, rangeStart, rangeEnd, nil, modValue,
 		positionConstraint, limitPerPosition, colocalizeKnown, colocalizeUnknown, p.isIonTypeModification(modStr))
 }
@@ -406,6 +406,12 @@ func TestComprehensiveIntegration_AllProForma21Features(t *testing.T) {
 				if seq.GetCharge() == nil || *seq.GetCharge() != 2 {
 					t.Errorf("Expected charge 2")
 				}
+				if seq.CountModifications() != 7 {
+					t.Errorf("Expected 7 modifications, got %d", seq.CountModifications())
+				}
+				if seq.CountResidueModifications() != 3 {
+					t.Errorf("Expected 3 residue modifications, got %d", seq.CountResidueModifications())
+				}
 			},
 		},
 	}
@@ -573,6 +579,8 @@ func TestComprehensiveIntegration_GlycanCountValidation(t *testing.T) {
 		{"HexNAc2Hex", "HexNAc has count 2, Hex at end"},
 		{"Hex(3)HexNAc2", "explicit counts"},
 		{"{C8H13N1O5}1Hex2", "custom with count, Hex with count"},
+		{"Hex10", "multi-digit count"},
+		{"Hex(10)HexNAc2", "multi-digit explicit count"},
 	}
 
 	for _, tc := range validCases {
@@ -604,6 +612,13 @@ func TestComprehensiveIntegration_GlycanCountValidation(t *testing.T) {
 	}{
 		{"HexNAcHex", "HexNAc not at end, missing count"},
 		{"HexNAc0", "zero count not allowed"},
+		{"Hex0", "zero count not allowed"},
+		{"Hex00", "leading zeros not allowed"},
+		{"Hex01HexNAc2", "leading zeros not allowed"},
+		{"Hex-1", "negative count not allowed"},
+		{"Hex(-1)", "negative count not allowed"},
+		{"Hex(0)HexNAc2", "zero explicit count not allowed"},
+		{"{C8H13N1O5}0Hex2", "zero custom count not allowed"},
 		{"{C8H13N1O5}Hex2", "custom not at end, missing count"},
 	}
 
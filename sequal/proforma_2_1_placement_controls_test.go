@@ -452,3 +452,82 @@ func TestPlacementControls_RoundTrip(t *testing.T) {
 		})
 	}
 }
+
+func TestPlacementControls_SiteEnforcement(t *testing.T) {
+	tests := []struct {
+		name           string
+		proformaString string
+		expectedSites  []int
+	}{
+		{
+			name:           "Targets without controls",
+			proformaString: "<[Oxidation]@M>MPMM",
+			expectedSites:  []int{0, 2, 3},
+		},
+		{
+			name:           "Limit keeps first sites by position",
+			proformaString: "<[Oxidation|Position:M|Limit:1]@M>MMMM",
+			expectedSites:  []int{0},
+		},
+		{
+			name:           "Position narrows multiple targets",
+			proformaString: "<[Phospho|Position:S,T]@S,T,Y>STYSTY",
+			expectedSites:  []int{0, 1, 3, 4},
+		},
+		{
+			name:           "Position, Limit, and multiple targets",
+			proformaString: "<[Phospho|Position:S,T,Y|Limit:2|CoMKP]@S,T,Y>STYSTY",
+			expectedSites:  []int{0, 1},
+		},
+		{
+			name:           "Terminal targets",
+			proformaString: "<[TMT6plex]@K,N-term><[Gln->pyro-Glu]@N-term:Q>MKPK",
+			expectedSites:  []int{0, 1, 3},
+		},
+		{
+			name:           "Terminal position constraint",
+			proformaString: "<[Acetyl|Position:N-term]@K>KAKK",
+			expectedSites:  []int{0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seq, err := FromProforma(tt.proformaString)
+			if err != nil {
+				t.Fatalf("Failed to parse %s: %v", tt.proformaString, err)
+			}
+
+			sites := seq.GetGlobalMods()[0].FindSites(seq.ToStrippedString())
+			if len(sites) != len(tt.expectedSites) {
+				t.Fatalf("Expected sites %v, got %v", tt.expectedSites, sites)
+			}
+			for i, site := range sites {
+				if site != tt.expectedSites[i] {
+					t.Errorf("Expected sites %v, got %v", tt.expectedSites, sites)
+					break
+				}
+			}
+
+			siteMap := seq.GetGlobalModSites()
+			if len(siteMap) != len(tt.expectedSites) {
+				t.Errorf("Expected %d materialized sites, got %d", len(tt.expectedSites), len(siteMap))
+			}
+			for _, site := range tt.expectedSites {
+				if len(siteMap[site]) != 1 {
+					t.Errorf("Expected one global modification at position %d", site)
+				}
+			}
+		})
+	}
+
+	t.Run("Isotope modification has no sites", func(t *testing.T) {
+		seq, err := FromProforma("<13C>PEPTIDE")
+		if err != nil {
+			t.Fatalf("Failed to parse: %v", err)
+		}
+		if len(seq.GetGlobalModSites()) != 0 {
+			t.Errorf("Expected no sites for isotope modification")
+		}
+	})
+}
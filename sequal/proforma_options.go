@@ -0,0 +1,106 @@
+package sequal
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// ProformaOptions controls how sequences and modifications are written by the
+// ToProformaWithOptions methods. DefaultProformaOptions returns the options used by
+// ToProforma; the zero value normalizes every mass shift to its shortest representation.
+type ProformaOptions struct {
+	// MassPrecision is the number of decimal places written for mass shifts.
+	// When nil, the shortest representation is used (e.g. "-10.0" is written as "-10").
+	MassPrecision *int
+
+	// PreserveMassFormatting writes mass shifts exactly as they appeared in the parsed
+	// input (e.g. "+79.9660"), taking precedence over MassPrecision for those values.
+	PreserveMassFormatting bool
+
+	// SortModifications orders the modifications on each residue and terminus by their
+	// ProForma representation. Labile modifications keep their labile order.
+	SortModifications bool
+
+	// GlycanNotation rewrites "Glycan:" compositions in a canonical form (see
+	// ModificationValue.GlycanToCompact and GlycanToExpanded). When empty, glycans are
+	// written as they appeared in the input.
+	GlycanNotation GlycanNotation
+}
+
+// GlycanNotation selects how glycan compositions are written
+type GlycanNotation string
+
+// Constants for the glycan notations accepted by ProformaOptions
+const (
+	GlycanNotationCompact  GlycanNotation = "compact"
+	GlycanNotationExpanded GlycanNotation = "expanded"
+)
+
+// DefaultProformaOptions returns the options used by ToProforma, which write mass shifts
+// as they appeared in the parsed input.
+//
+// Example:
+//
+//	opts := sequal.DefaultProformaOptions()
+//	opts.SortModifications = true
+//	seq, _ := sequal.FromProforma("PEPS[Phospho][+1.0]IDE")
+//	fmt.Println(seq.ToProformaWithOptions(opts)) // "PEPS[+1.0][Phospho]IDE"
+func DefaultProformaOptions() ProformaOptions {
+	return ProformaOptions{PreserveMassFormatting: true}
+}
+
+// formatPipeMass formats the mass of a pipe value according to the options. The original
+// literal is only kept while it still matches the mass, so masses changed after parsing
+// are formatted normally. A zero mass is omitted.
+func (opts ProformaOptions) formatPipeMass(pv *PipeValue) string {
+	mass := *pv.GetMass()
+	if opts.PreserveMassFormatting && pv.GetType() == PipeValueTypeMass {
+		if literal, err := strconv.ParseFloat(pv.GetValue(), 64); err == nil && literal == mass {
+			return pv.GetValue()
+		}
+	}
+	return opts.formatMass(mass)
+}
+
+// formatMass formats a mass shift with an explicit sign for positive values
+func (opts ProformaOptions) formatMass(mass float64) string {
+	if mass == 0 {
+		return ""
+	}
+	sign := ""
+	if mass > 0 {
+		sign = "+"
+	}
+	if opts.MassPrecision != nil {
+		return sign + strconv.FormatFloat(mass, 'f', *opts.MassPrecision, 64)
+	}
+	return fmt.Sprintf("%s%g", sign, mass)
+}
+
+// formatGlycan writes a valid glycan pipe value in the notation selected by GlycanNotation,
+// or as written when no notation is selected
+func (opts ProformaOptions) formatGlycan(pv *PipeValue) string {
+	if opts.GlycanNotation == "" || pv.GetType() != PipeValueTypeGlycan || !pv.IsValidGlycan() {
+		return pv.GetValue()
+	}
+	composition, err := parseGlycanComposition(pv.GetValue())
+	if err != nil {
+		return pv.GetValue()
+	}
+	return formatGlycanComposition(composition, opts.GlycanNotation == GlycanNotationExpanded)
+}
+
+// orderMods returns the modifications in output order, sorted by ProForma representation
+// when SortModifications is set
+func (opts ProformaOptions) orderMods(mods []*Modification) []*Modification {
+	if !opts.SortModifications {
+		return mods
+	}
+	sorted := make([]*Modification, len(mods))
+	copy(sorted, mods)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].ToProformaWithOptions(opts) < sorted[j].ToProformaWithOptions(opts)
+	})
+	return sorted
+}
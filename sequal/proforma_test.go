@@ -1,6 +1,10 @@
 package sequal
 
 import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
 	"testing"
 )
 
@@ -103,6 +107,174 @@ func TestProFormaParserTerminalModifications(t *testing.T) {
 	})
 }
 
+func TestProFormaParserHyphenatedModNames(t *testing.T) {
+	testCases := []struct {
+		name          string
+		proforma      string
+		expectedSeq   string
+		residueMods   map[string]string
+		nTermExpected []string
+		cTermExpected []string
+	}{
+		{
+			name:        "Hyphenated residue mod at sequence end",
+			proforma:    "PEPTIDE[Gln->pyro-Glu]",
+			expectedSeq: "PEPTIDE",
+			residueMods: map[string]string{"6": "Gln->pyro-Glu"},
+		},
+		{
+			name:          "True C-terminal modification",
+			proforma:      "PEPTIDE-[Amidated]",
+			expectedSeq:   "PEPTIDE",
+			cTermExpected: []string{"Amidated"},
+		},
+		{
+			name:          "Hyphenated residue mod followed by C-terminal modification",
+			proforma:      "PEPTIDE[Gln->pyro-Glu]-[Amidated]",
+			expectedSeq:   "PEPTIDE",
+			residueMods:   map[string]string{"6": "Gln->pyro-Glu"},
+			cTermExpected: []string{"Amidated"},
+		},
+		{
+			name:          "Hyphenated N-terminal modification",
+			proforma:      "[Gln->pyro-Glu]-QEPTIDE[Gln->pyro-Glu]",
+			expectedSeq:   "QEPTIDE",
+			residueMods:   map[string]string{"6": "Gln->pyro-Glu"},
+			nTermExpected: []string{"Gln->pyro-Glu"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			baseSeq, modifications, _, _, _, err := ParseProForma(tc.proforma)
+			if err != nil {
+				t.Fatalf("Failed to parse ProForma '%s': %v", tc.proforma, err)
+			}
+
+			if baseSeq != tc.expectedSeq {
+				t.Errorf("Expected sequence '%s', got '%s'", tc.expectedSeq, baseSeq)
+			}
+
+			for pos, value := range tc.residueMods {
+				if len(modifications[pos]) != 1 || modifications[pos][0].GetValue() != value {
+					t.Errorf("Expected residue mod '%s' at position %s", value, pos)
+				}
+			}
+
+			if len(modifications["-1"]) != len(tc.nTermExpected) {
+				t.Errorf("Expected %d N-terminal mods, got %d", len(tc.nTermExpected), len(modifications["-1"]))
+			}
+			for i, value := range tc.nTermExpected {
+				if i < len(modifications["-1"]) && modifications["-1"][i].GetValue() != value {
+					t.Errorf("Expected N-terminal mod '%s', got '%s'", value, modifications["-1"][i].GetValue())
+				}
+			}
+
+			if len(modifications["-2"]) != len(tc.cTermExpected) {
+				t.Errorf("Expected %d C-terminal mods, got %d", len(tc.cTermExpected), len(modifications["-2"]))
+			}
+			for i, value := range tc.cTermExpected {
+				if i < len(modifications["-2"]) && modifications["-2"][i].GetValue() != value {
+					t.Errorf("Expected C-terminal mod '%s', got '%s'", value, modifications["-2"][i].GetValue())
+				}
+			}
+
+			seq, err := FromProforma(tc.proforma)
+			if err != nil {
+				t.Fatalf("Failed to parse ProForma '%s': %v", tc.proforma, err)
+			}
+			if seq.ToProforma() != tc.proforma {
+				t.Errorf("Roundtrip failed: expected '%s', got '%s'", tc.proforma, seq.ToProforma())
+			}
+		})
+	}
+}
+
+func TestProFormaParserNestedBrackets(t *testing.T) {
+	tests := []struct {
+		input    string
+		position int
+		value    string
+		span     string
+	}{
+		{"N[Glycan:{C8H13[15N1]O5}2]K", 0, "{C8H13[15N1]O5}2", "[Glycan:{C8H13[15N1]O5}2]"},
+		{"N[Glycan:Hex2{C8H13[15N1][13C2]O5}1]K", 0, "Hex2{C8H13[15N1][13C2]O5}1", "[Glycan:Hex2{C8H13[15N1][13C2]O5}1]"},
+		{"N[Glycan:{C8H13[15N1]O5}2|INFO:core]K", 0, "{C8H13[15N1]O5}2", "[Glycan:{C8H13[15N1]O5}2|INFO:core]"},
+		{"[Glycan:{C8H13[15N1]O5}1]-PEPTIDE", -1, "{C8H13[15N1]O5}1", "[Glycan:{C8H13[15N1]O5}1]"},
+		{"PEPTIDE-[Glycan:{C8H13[15N1]O5}1]", -2, "{C8H13[15N1]O5}1", "[Glycan:{C8H13[15N1]O5}1]"},
+		{"{Glycan:{C8H13[15N1]O5}1}PEPTIDE", -3, "{C8H13[15N1]O5}1", "{Glycan:{C8H13[15N1]O5}1}"},
+		{"[Glycan:{C8H13[15N1]O5}1]?PEPTIDE", -4, "{C8H13[15N1]O5}1", "[Glycan:{C8H13[15N1]O5}1]"},
+		{"N[Formula:H4[13C2]]K", 0, "H4[13C2]", "[Formula:H4[13C2]]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			seq, err := FromProforma(tt.input)
+			if err != nil {
+				t.Fatalf("Failed to parse: %v", err)
+			}
+			mods := seq.GetModificationAt(tt.position)
+			if len(mods) != 1 {
+				t.Fatalf("Expected 1 modification at %d, got %d", tt.position, len(mods))
+			}
+			if mods[0].GetValue() != tt.value {
+				t.Errorf("Expected value '%s', got '%s'", tt.value, mods[0].GetValue())
+			}
+			if start, end, ok := mods[0].GetSourceSpan(); !ok || tt.input[start:end] != tt.span {
+				t.Errorf("Expected span '%s', got [%d, %d)", tt.span, start, end)
+			}
+			if seq.ToProforma() != tt.input {
+				t.Errorf("Expected '%s', got '%s'", tt.input, seq.ToProforma())
+			}
+		})
+	}
+
+	for _, input := range []string{"N[Glycan:{C8H13[15N1O5}2]K", "[Glycan:{C8H13[15N1O5}1]-PEPTIDE"} {
+		_, err := FromProforma(input)
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) || parseErr.Kind != ParseErrorUnclosedBracket {
+			t.Errorf("Expected an unclosed bracket error for '%s', got %v", input, err)
+		}
+	}
+}
+
+func TestProFormaParserScientificNotationMass(t *testing.T) {
+	tests := []struct {
+		input    string
+		position int
+		mass     float64
+	}{
+		{"PEP[+1.0e2]TIDE", 2, 100},
+		{"PEPS[+7.9966e1]TIDE", 3, 79.966},
+		{"PEP[-1E-1]TIDE", 2, -0.1},
+		{"[+4.2e+1]-PEPTIDE", -1, 42},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			seq, err := FromProforma(tt.input)
+			if err != nil {
+				t.Fatalf("Failed to parse: %v", err)
+			}
+			var mods []*Modification
+			if tt.position == -1 {
+				mods = seq.GetMods()[-1]
+			} else {
+				mods = seq.GetSeq()[tt.position].GetMods()
+			}
+			if len(mods) != 1 {
+				t.Fatalf("Expected 1 modification, got %d", len(mods))
+			}
+			if mods[0].GetMass() == nil || math.Abs(*mods[0].GetMass()-tt.mass) > 1e-9 {
+				t.Errorf("Expected mass %f, got %v", tt.mass, mods[0].GetMass())
+			}
+			if seq.ToProforma() != tt.input {
+				t.Errorf("Expected '%s', got '%s'", tt.input, seq.ToProforma())
+			}
+		})
+	}
+}
+
 func TestProFormaParserGlobalMods(t *testing.T) {
 	tests := []struct {
 		name               string
@@ -157,6 +329,44 @@ func TestProFormaParserGlobalMods(t *testing.T) {
 	}
 }
 
+func TestProFormaParserGlobalModBracketRoundtrip(t *testing.T) {
+	tests := []struct {
+		input string
+		mass  float64
+	}{
+		{"<[Carbamidomethyl]@C>PEPTCDE", 57.021464},
+		{"<Carbamidomethyl@C>PEPTCDE", 57.021464},
+		{"<[+57.021]@C>PEPTCDE", 57.021},
+		{"<+57.021@C>PEPTCDE", 57.021},
+		{"<[-17.027]@N-term:Q>QPEPTIDE", -17.027},
+		{"<-17.027@N-term:Q>QPEPTIDE", -17.027},
+		{"<15N>PEPTIDE", 0},
+		{"<13C,15N>PEPTIDE", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			seq, err := FromProforma(tt.input)
+			if err != nil {
+				t.Fatalf("Failed to parse: %v", err)
+			}
+			if len(seq.GetGlobalMods()) != 1 {
+				t.Fatalf("Expected 1 global modification, got %d", len(seq.GetGlobalMods()))
+			}
+			if seq.ToProforma() != tt.input {
+				t.Errorf("Expected '%s', got '%s'", tt.input, seq.ToProforma())
+			}
+			if mass := seq.GetGlobalMods()[0].GetMass(); tt.mass != 0 && (mass == nil || math.Abs(*mass-tt.mass) > 1e-9) {
+				t.Errorf("Expected mass %f, got %v", tt.mass, mass)
+			}
+		})
+	}
+
+	if mod := NewGlobalModification("+57.021", []string{"C"}, "fixed", nil, nil, false, false); mod.ToProforma() != "<+57.021@C>" {
+		t.Errorf("Expected '<+57.021@C>', got '%s'", mod.ToProforma())
+	}
+}
+
 func TestProFormaParserChargeInfo(t *testing.T) {
 	tests := []struct {
 		name            string
@@ -267,6 +477,105 @@ func TestProFormaParserLabileMods(t *testing.T) {
 	}
 }
 
+func TestProFormaParserMultipleLabileMods(t *testing.T) {
+	proforma := "{Glycan:Hex}{Phospho}{+79.966}PEPTIDE"
+	_, modifications, _, _, _, err := ParseProForma(proforma)
+	if err != nil {
+		t.Fatalf("Failed to parse ProForma '%s': %v", proforma, err)
+	}
+
+	labileMods := modifications["-3"]
+	if len(labileMods) != 3 {
+		t.Fatalf("Expected 3 labile modifications, got %d", len(labileMods))
+	}
+	for i, mod := range labileMods {
+		if mod.GetLabileNumber() != i+1 {
+			t.Errorf("Expected labile number %d, got %d", i+1, mod.GetLabileNumber())
+		}
+	}
+
+	seq, err := FromProforma(proforma)
+	if err != nil {
+		t.Fatalf("Failed to parse ProForma '%s': %v", proforma, err)
+	}
+	if seq.ToProforma() != proforma {
+		t.Errorf("Roundtrip failed: expected '%s', got '%s'", proforma, seq.ToProforma())
+	}
+
+	// Labile mods are emitted in labile number order regardless of insertion order
+	seq.GetMods()[-3][0], seq.GetMods()[-3][2] = seq.GetMods()[-3][2], seq.GetMods()[-3][0]
+	if seq.ToProforma() != proforma {
+		t.Errorf("Expected labile number order '%s', got '%s'", proforma, seq.ToProforma())
+	}
+}
+
+func TestProFormaParserModificationMultiplier(t *testing.T) {
+	tests := []struct {
+		name           string
+		proforma       string
+		expectedLabile int
+		expectedNTerm  int
+		expectedCTerm  int
+		expectedOutput string
+	}{
+		{"Labile multiplier", "{Phospho}^2PEPTIDE", 2, 0, 0, "{Phospho}{Phospho}PEPTIDE"},
+		{"Labile multiplier with other labile mods", "{Hex}^2{Fuc}PEPTIDE/2", 3, 0, 0, "{Hex}{Hex}{Fuc}PEPTIDE/2"},
+		{"N-terminal multiplier", "[Acetyl]^2-PEPTIDE", 0, 2, 0, "[Acetyl][Acetyl]-PEPTIDE"},
+		{"C-terminal multiplier", "PEPTIDE-[Amidated]^2", 0, 0, 2, "PEPTIDE-[Amidated][Amidated]"},
+		{"Multiplier of one", "{Phospho}^1PEPTIDE", 1, 0, 0, "{Phospho}PEPTIDE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seq, err := FromProforma(tt.proforma)
+			if err != nil {
+				t.Fatalf("Failed to parse ProForma '%s': %v", tt.proforma, err)
+			}
+			if seq.ToStrippedString() != "PEPTIDE" {
+				t.Errorf("Expected sequence 'PEPTIDE', got '%s'", seq.ToStrippedString())
+			}
+			mods := seq.GetMods()
+			if len(mods[-3]) != tt.expectedLabile || len(mods[-1]) != tt.expectedNTerm || len(mods[-2]) != tt.expectedCTerm {
+				t.Errorf("Expected %d labile, %d N-terminal and %d C-terminal mods, got %d, %d and %d",
+					tt.expectedLabile, tt.expectedNTerm, tt.expectedCTerm, len(mods[-3]), len(mods[-1]), len(mods[-2]))
+			}
+			for i, mod := range mods[-3] {
+				if mod.GetLabileNumber() != i+1 {
+					t.Errorf("Expected labile number %d, got %d", i+1, mod.GetLabileNumber())
+				}
+			}
+			if seq.ToProforma() != tt.expectedOutput {
+				t.Errorf("Expected '%s', got '%s'", tt.expectedOutput, seq.ToProforma())
+			}
+		})
+	}
+
+	invalid := []struct {
+		proforma       string
+		expectedOffset int
+	}{
+		{"{Phospho}^PEPTIDE", 9},
+		{"{Phospho}^0PEPTIDE", 9},
+		{"[Acetyl]^-PEPTIDE", 8},
+		{"PEPTIDE-[Amidated]^x", 18},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.proforma, func(t *testing.T) {
+			_, err := FromProforma(tt.proforma)
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("Expected *ParseError, got %v", err)
+			}
+			if parseErr.Kind != ParseErrorInvalidMultiplier {
+				t.Errorf("Expected kind '%s', got '%s'", ParseErrorInvalidMultiplier, parseErr.Kind)
+			}
+			if parseErr.Offset != tt.expectedOffset {
+				t.Errorf("Expected offset %d, got %d", tt.expectedOffset, parseErr.Offset)
+			}
+		})
+	}
+}
+
 func TestProFormaParserUnknownPositionMods(t *testing.T) {
 	tests := []struct {
 		name            string
@@ -361,12 +670,66 @@ func TestProFormaParserRangeMods(t *testing.T) {
 	}
 }
 
+func TestProFormaParserRangeCrosslink(t *testing.T) {
+	tests := []struct {
+		name       string
+		proforma   string
+		rangeStart int
+		rangeEnd   int
+		isRef      bool
+	}{
+		{"Crosslink definition on a range", "K(PEPTK)[XL:DSS#XL1]IDEK[#XL1]", 1, 5, false},
+		{"Range at the start", "(PEPTIDE)[XL:DSS#XL1]K[#XL1]", 0, 6, false},
+		{"Crosslink reference on a range", "SEK[XL:DSS#XL1]S(TIDEK)[#XL1]", 4, 8, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seq, err := FromProforma(tt.proforma)
+			if err != nil {
+				t.Fatalf("Failed to parse ProForma '%s': %v", tt.proforma, err)
+			}
+
+			rangeMod := seq.GetSeq()[tt.rangeStart].GetMods()[0]
+			for pos := tt.rangeStart; pos <= tt.rangeEnd; pos++ {
+				mods := seq.GetSeq()[pos].GetMods()
+				if len(mods) != 1 || mods[0] != rangeMod {
+					t.Fatalf("Expected the range crosslink shared at position %d, got %v", pos, mods)
+				}
+			}
+			if rangeMod.GetModType() != "crosslink" {
+				t.Errorf("Expected mod type 'crosslink', got '%s'", rangeMod.GetModType())
+			}
+			if id := rangeMod.GetCrosslinkID(); id == nil || *id != "XL1" {
+				t.Errorf("Expected crosslink ID 'XL1', got %v", id)
+			}
+			if rangeMod.IsCrosslinkRef() != tt.isRef {
+				t.Errorf("Expected crosslink reference %v, got %v", tt.isRef, rangeMod.IsCrosslinkRef())
+			}
+			if *rangeMod.GetRangeStart() != tt.rangeStart || *rangeMod.GetRangeEnd() != tt.rangeEnd {
+				t.Errorf("Expected range %d-%d, got %d-%d", tt.rangeStart, tt.rangeEnd, *rangeMod.GetRangeStart(), *rangeMod.GetRangeEnd())
+			}
+
+			if issues := seq.Validate(); len(issues) != 0 {
+				t.Errorf("Expected no validation issues, got %v", issues)
+			}
+			if result := seq.ToProforma(); result != tt.proforma {
+				t.Errorf("Expected '%s', got '%s'", tt.proforma, result)
+			}
+			if result := seq.Clone().ToProforma(); result != tt.proforma {
+				t.Errorf("Expected clone '%s', got '%s'", tt.proforma, result)
+			}
+		})
+	}
+}
+
 func TestProFormaParserCrosslinks(t *testing.T) {
 	tests := []struct {
 		name              string
 		proforma          string
 		expectedSeq       string
 		expectedCrosslink string
+		expectedScore     *float64
 	}{
 		{
 			name:              "Crosslink with ID",
@@ -380,6 +743,20 @@ func TestProFormaParserCrosslinks(t *testing.T) {
 			expectedSeq:       "PEPTKIDE",
 			expectedCrosslink: "XL1",
 		},
+		{
+			name:              "Crosslink reference with localization score",
+			proforma:          "PEPTK[#XL1(0.90)]IDE",
+			expectedSeq:       "PEPTKIDE",
+			expectedCrosslink: "XL1",
+			expectedScore:     Float64Ptr(0.90),
+		},
+		{
+			name:              "Crosslink with ID and localization score",
+			proforma:          "PEPTK[DSS#XL1(0.75)]IDE",
+			expectedSeq:       "PEPTKIDE",
+			expectedCrosslink: "XL1",
+			expectedScore:     Float64Ptr(0.75),
+		},
 	}
 
 	for _, tt := range tests {
@@ -406,6 +783,13 @@ func TestProFormaParserCrosslinks(t *testing.T) {
 				} else if *crosslinkID != tt.expectedCrosslink {
 					t.Errorf("Expected crosslink ID '%s', got '%s'", tt.expectedCrosslink, *crosslinkID)
 				}
+
+				score := mod.GetLocalizationScore()
+				if tt.expectedScore == nil && score != nil {
+					t.Errorf("Expected no localization score, got %f", *score)
+				} else if tt.expectedScore != nil && (score == nil || *score != *tt.expectedScore) {
+					t.Errorf("Expected localization score %f, got %v", *tt.expectedScore, score)
+				}
 			} else {
 				t.Errorf("Expected crosslink modification at position 4, but found none")
 			}
@@ -413,6 +797,87 @@ func TestProFormaParserCrosslinks(t *testing.T) {
 	}
 }
 
+func TestCrosslinkLocalizationScoreRoundtrip(t *testing.T) {
+	tests := []string{
+		"PEPTK[#XL1(0.90)]IDE",
+		"PEPTK[DSS|#XL1(0.75)]IDEK[#XL1(0.25)]",
+		"PEPTK[DSS|#XL1]IDEK[#XL1]",
+	}
+
+	for _, proforma := range tests {
+		t.Run(proforma, func(t *testing.T) {
+			seq, err := FromProforma(proforma)
+			if err != nil {
+				t.Fatalf("Failed to parse: %v", err)
+			}
+			if seq.ToProforma() != proforma {
+				t.Errorf("Expected '%s', got '%s'", proforma, seq.ToProforma())
+			}
+		})
+	}
+}
+
+func TestSourcePrefixedMassRoundtrip(t *testing.T) {
+	tests := []struct {
+		proforma string
+		position int
+		mass     float64
+	}{
+		{"ELVIS[U:+79.966]K", 4, 79.966},
+		{"ELVIS[U:+79.9660]K", 4, 79.966},
+		{"ELVIS[M:+79.966]K", 4, 79.966},
+		{"ELVIS[Unimod:+79.966]K", 4, 79.966},
+		{"ELVIS[U:-18.011]K", 4, -18.011},
+		{"ELVIS[U:Phospho|Obs:+79.978]K", 4, 79.966331},
+		{"ELVIS[U:+79.966|Obs:+79.978]K", 4, 79.966},
+		{"ELVIS[U:+79.966|U:Phospho]K", 4, 79.966},
+		{"ELVIS[U:Phospho|+79.966331]K", 4, 79.966331},
+		{"ELVIS[R:+79.966|INFO:x]K", 4, 79.966},
+		{"ELVIS[X:+138.068#XL1]K[#XL1]", 4, 138.068},
+		{"[U:+42.011]-PEPTIDE", -1, 42.011},
+		{"PEPTIDE-[U:-0.984]", -2, -0.984},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.proforma, func(t *testing.T) {
+			seq, err := FromProforma(tt.proforma)
+			if err != nil {
+				t.Fatalf("Failed to parse: %v", err)
+			}
+			if seq.ToProforma() != tt.proforma {
+				t.Errorf("Expected '%s', got '%s'", tt.proforma, seq.ToProforma())
+			}
+
+			var mod *Modification
+			if tt.position < 0 {
+				mod = seq.GetMods()[tt.position][0]
+			} else {
+				mod = seq.GetSeq()[tt.position].GetMods()[0]
+			}
+			mass := mod.GetResolvedMass()
+			if mass == nil || math.Abs(*mass-tt.mass) > 1e-6 {
+				t.Errorf("Expected mass %f, got %v", tt.mass, mass)
+			}
+		})
+	}
+
+	// Named crosslinks, branches and ambiguity groups are written without repeating the name
+	for _, proforma := range []string{
+		"EMEVTK[XLMOD:02001#XL1]SESPEK[#XL1]",
+		"PEPC[Disulfide#XL1]TIDE",
+		"PEPTK[Ubiquitin#BRANCH]IDE",
+		"ELVIS[Phospho#g1(0.50)]T[#g1(0.50)]K",
+	} {
+		seq, err := FromProforma(proforma)
+		if err != nil {
+			t.Fatalf("Failed to parse '%s': %v", proforma, err)
+		}
+		if seq.ToProforma() != proforma {
+			t.Errorf("Expected '%s', got '%s'", proforma, seq.ToProforma())
+		}
+	}
+}
+
 func TestProFormaParserBranches(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -492,6 +957,39 @@ func TestProFormaParserGapNotation(t *testing.T) {
 	}
 }
 
+func TestProFormaParserGapFormula(t *testing.T) {
+	proforma := "RTAAX[Formula:C16H30N4]WT"
+	seq, err := FromProforma(proforma)
+	if err != nil {
+		t.Fatalf("Failed to parse ProForma '%s': %v", proforma, err)
+	}
+
+	mods := seq.GetSeq()[4].GetMods()
+	if len(mods) != 1 {
+		t.Fatalf("Expected 1 gap modification at position 4, got %d", len(mods))
+	}
+	mod := mods[0]
+	if mod.GetModType() != "gap" {
+		t.Errorf("Expected gap modification type, got '%s'", mod.GetModType())
+	}
+
+	expectedMass := 16*ElementMass["C"] + 30*ElementMass["H"] + 4*ElementMass["N"]
+	mass := mod.GetMass()
+	if mass == nil {
+		t.Fatalf("Expected gap mass, got nil")
+	}
+	if math.Abs(*mass-expectedMass) > 1e-6 {
+		t.Errorf("Expected gap mass %f, got %f", expectedMass, *mass)
+	}
+	if resolved := mod.GetResolvedMass(); resolved == nil || math.Abs(*resolved-expectedMass) > 1e-6 {
+		t.Errorf("Expected resolved gap mass %f, got %v", expectedMass, resolved)
+	}
+
+	if seq.ToProforma() != proforma {
+		t.Errorf("Roundtrip failed: expected '%s', got '%s'", proforma, seq.ToProforma())
+	}
+}
+
 func TestProFormaParserAmbiguousModifications(t *testing.T) {
 	proforma := "ELVIS{Phospho}K"
 	baseSeq, modifications, _, _, _, err := ParseProForma(proforma)
@@ -516,24 +1014,118 @@ func TestProFormaParserAmbiguousModifications(t *testing.T) {
 
 func TestProFormaParserErrorCases(t *testing.T) {
 	errorCases := []struct {
-		name     string
-		proforma string
+		name           string
+		proforma       string
+		expectedKind   ParseErrorKind
+		expectedOffset int
 	}{
 		{
-			name:     "Unclosed bracket",
-			proforma: "ELVIS[PhosphoPEPTIDE",
+			name:           "Unclosed bracket",
+			proforma:       "ELVIS[PhosphoPEPTIDE",
+			expectedKind:   ParseErrorUnclosedBracket,
+			expectedOffset: 5,
+		},
+		{
+			name:           "Unclosed global mod",
+			proforma:       "<15NPEPTIDE",
+			expectedKind:   ParseErrorUnclosedAngleBracket,
+			expectedOffset: 0,
+		},
+		{
+			name:           "Unclosed parenthesis",
+			proforma:       "ELVIS(PEPTIDE",
+			expectedKind:   ParseErrorUnclosedParenthesis,
+			expectedOffset: 5,
+		},
+		{
+			name:           "Unmatched closing parenthesis",
+			proforma:       "ELVIS)PEPTIDE",
+			expectedKind:   ParseErrorUnmatchedParenthesis,
+			expectedOffset: 5,
+		},
+		{
+			name:           "Unclosed bracket after prefix",
+			proforma:       "<13C>[Acetyl]-PEP[Phospho",
+			expectedKind:   ParseErrorUnclosedBracket,
+			expectedOffset: 17,
+		},
+		{
+			name:           "Unclosed labile brace",
+			proforma:       "{Glycan:Hex",
+			expectedKind:   ParseErrorUnclosedBrace,
+			expectedOffset: 0,
+		},
+		{
+			name:           "Unclosed unknown position bracket",
+			proforma:       "[Phospho][Acetyl?PEPTIDE",
+			expectedKind:   ParseErrorUnclosedBracket,
+			expectedOffset: 9,
+		},
+		{
+			name:           "Unclosed nested labile brace",
+			proforma:       "{Glycan:{C8H13N1O5}1Hex2PEPTIDE",
+			expectedKind:   ParseErrorUnclosedBrace,
+			expectedOffset: 0,
 		},
 		{
-			name:     "Unclosed global mod",
-			proforma: "<15NPEPTIDE",
+			name:           "Lowercase residue",
+			proforma:       "PEPtIDE",
+			expectedKind:   ParseErrorInvalidResidue,
+			expectedOffset: 3,
 		},
 		{
-			name:     "Unclosed parenthesis",
-			proforma: "ELVIS(PEPTIDE",
+			name:           "Digit after modification",
+			proforma:       "[Acetyl]-PEP[Phospho]1IDE",
+			expectedKind:   ParseErrorInvalidResidue,
+			expectedOffset: 21,
 		},
 		{
-			name:     "Unmatched closing parenthesis",
-			proforma: "ELVIS)PEPTIDE",
+			name:           "Non-ASCII residue",
+			proforma:       "PEPÉTIDE",
+			expectedKind:   ParseErrorInvalidResidue,
+			expectedOffset: 3,
+		},
+		{
+			name:           "Empty residue modification",
+			proforma:       "PEP[]TIDE",
+			expectedKind:   ParseErrorEmptyModification,
+			expectedOffset: 3,
+		},
+		{
+			name:           "Empty ambiguous modification",
+			proforma:       "PEP{ }TIDE",
+			expectedKind:   ParseErrorEmptyModification,
+			expectedOffset: 3,
+		},
+		{
+			name:           "Empty terminal modification",
+			proforma:       "[Acetyl]-PEPTIDE-[]",
+			expectedKind:   ParseErrorEmptyModification,
+			expectedOffset: 17,
+		},
+		{
+			name:           "Empty range modification",
+			proforma:       "(PEP)[]TIDE",
+			expectedKind:   ParseErrorEmptyModification,
+			expectedOffset: 5,
+		},
+		{
+			name:           "Empty unknown position modification",
+			proforma:       "[]?PEPTIDE",
+			expectedKind:   ParseErrorEmptyModification,
+			expectedOffset: 0,
+		},
+		{
+			name:           "Empty labile modification",
+			proforma:       "{}PEPTIDE",
+			expectedKind:   ParseErrorEmptyModification,
+			expectedOffset: 0,
+		},
+		{
+			name:           "Empty global modification",
+			proforma:       "<[]@C>PEPCTIDE",
+			expectedKind:   ParseErrorEmptyModification,
+			expectedOffset: 0,
 		},
 	}
 
@@ -541,10 +1133,130 @@ func TestProFormaParserErrorCases(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			_, _, _, _, _, err := ParseProForma(tt.proforma)
 			if err == nil {
-				t.Errorf("Expected error for invalid ProForma '%s', but parsing succeeded", tt.proforma)
+				t.Fatalf("Expected error for invalid ProForma '%s', but parsing succeeded", tt.proforma)
+			}
+
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("Expected *ParseError, got %T", err)
+			}
+			if parseErr.Kind != tt.expectedKind {
+				t.Errorf("Expected kind '%s', got '%s'", tt.expectedKind, parseErr.Kind)
+			}
+			if parseErr.Offset != tt.expectedOffset {
+				t.Errorf("Expected offset %d, got %d", tt.expectedOffset, parseErr.Offset)
+			}
+			if parseErr.Error() == "" {
+				t.Errorf("Expected a human-readable message")
 			}
 		})
 	}
+
+	_, _, _, _, _, err := ParseProForma("PEPÉTIDE")
+	if err == nil || err.Error() != "invalid residue 'É' at position 3" {
+		t.Errorf("Expected the residue to be decoded, got '%v'", err)
+	}
+
+	_, err = FromProforma("PEPTIDE//SEK[Acetyl")
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("Expected *ParseError for invalid second chain, got %v", err)
+	}
+	if parseErr.Offset != 12 {
+		t.Errorf("Expected offset 12 within the full string, got %d", parseErr.Offset)
+	}
+
+	for proforma, offset := range map[string]int{
+		"PEPTIDE//SEK[Acetyl":   12,
+		"PEPTIDE+ELVIS[Phospho": 13,
+		"PEPTIDE/2+ELV1S/3":     13,
+		"PEP//ELVIS//SEQ(UENCE": 15,
+	} {
+		_, err := FromProforma(proforma)
+		if !errors.As(err, &parseErr) {
+			t.Fatalf("Expected *ParseError for '%s', got %v", proforma, err)
+		}
+		if parseErr.Offset != offset {
+			t.Errorf("Expected offset %d for '%s', got %d", offset, proforma, parseErr.Offset)
+		}
+		if !strings.Contains(parseErr.Error(), fmt.Sprintf("position %d", offset)) || parseErr.Message != parseErr.Error() {
+			t.Errorf("Expected the message for '%s' to report position %d, got '%s'", proforma, offset, parseErr.Error())
+		}
+	}
+}
+
+func TestProFormaParserVersion(t *testing.T) {
+	parser20, err := NewProFormaParserVersion(ProFormaVersion20)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	parser21, err := NewProFormaParserVersion(ProFormaVersion21)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := NewProFormaParserVersion("1.0"); err == nil {
+		t.Error("Expected error for unsupported version")
+	}
+
+	rejected := []struct {
+		name           string
+		proforma       string
+		expectedOffset int
+	}{
+		{"Peptidoform name", "(>Tryptic)PEPTIDE", 0},
+		{"Peptidoform ion name", "(>>Precursor)PEPTIDE/2", 0},
+		{"Name on second peptidoform", "PEPTIDE/2+(>Second)ANOTHER/3", 10},
+		{"Charged formula", "PEPTIDEK[Formula:Zn1:z+2]", 8},
+		{"Charged formula at terminus", "[Formula:Zn1:z+2]-PEPTIDE", 0},
+		{"Position constraint", "<[Phospho|Position:S,T]@S,T>PEPSTIDE", 0},
+		{"Limit per position", "<[Oxidation|Limit:2]@M>PEMMTIDE", 0},
+		{"Colocalization", "<[Phospho|CoMKP]@S>PEPSIDE", 0},
+		{"Colocalization on residue", "PEP[Phospho|CoMKP]TIDE", 3},
+		{"Limit on residue", "PEPS[Phospho|Limit:2]K", 4},
+		{"Position constraint on range", "(PEPS)[Phospho|Position:S]TIDE", 6},
+		{"Colocalization at terminus", "PEPTIDE-[Amidated|CoMUP]", 8},
+		{"Limit at unknown position", "[Phospho|Limit:2]?PEPTIDE", 0},
+		{"Name after the first peptidoform", "PEPTIDE+(>n)ELVIS", 8},
+	}
+
+	for _, tt := range rejected {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parser20.ParseSequence(tt.proforma)
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("Expected *ParseError for '%s' under ProForma 2.0, got %v", tt.proforma, err)
+			}
+			if parseErr.Kind != ParseErrorUnsupportedFeature {
+				t.Errorf("Expected kind '%s', got '%s'", ParseErrorUnsupportedFeature, parseErr.Kind)
+			}
+			if parseErr.Offset != tt.expectedOffset {
+				t.Errorf("Expected offset %d, got %d", tt.expectedOffset, parseErr.Offset)
+			}
+			if !strings.Contains(parseErr.Error(), fmt.Sprintf("position %d", tt.expectedOffset)) {
+				t.Errorf("Expected the message to report position %d, got '%s'", tt.expectedOffset, parseErr.Error())
+			}
+
+			seq, err := parser21.ParseSequence(tt.proforma)
+			if err != nil {
+				t.Fatalf("Expected ProForma 2.1 parser to accept '%s', got %v", tt.proforma, err)
+			}
+			if seq.ToProforma() != tt.proforma {
+				t.Errorf("Roundtrip failed: expected '%s', got '%s'", tt.proforma, seq.ToProforma())
+			}
+		})
+	}
+
+	accepted := []string{
+		"PEPT[Phospho]IDE/2",
+		"<[Carbamidomethyl]@C>PEPC[Formula:HPO3]IDE",
+		"EMEVTK[XLMOD:02001#XL1]SESPEK[#XL1]",
+		"PEPTIDE/2+ANOTHER/3",
+	}
+	for _, proforma := range accepted {
+		if _, err := parser20.ParseSequence(proforma); err != nil {
+			t.Errorf("Expected ProForma 2.0 parser to accept '%s', got %v", proforma, err)
+		}
+	}
 }
 
 // ProForma 2.1 Tests - Phase 1: Named Entities
@@ -642,6 +1354,44 @@ func TestNameWithBalancedParentheses(t *testing.T) {
 	}
 }
 
+func TestProteinHeaderFromName(t *testing.T) {
+	testCases := []struct {
+		proforma  string
+		accession string
+		name      string
+	}{
+		{"(>sp|P02768|ALBU_HUMAN (Albumin))PEPTIDE", "P02768", "ALBU_HUMAN"},
+		{"(>tr|A0A024R161|A0A024R161_HUMAN)PEPTIDE", "A0A024R161", "A0A024R161_HUMAN"},
+		{"(>sp|P02768|ALBU_HUMAN Serum albumin OS=Homo sapiens)PEPTIDE", "P02768", "ALBU_HUMAN"},
+		{"(>Tryptic peptide)PEPTIDE", "", ""},
+		{"(>sp|P02768)PEPTIDE", "", ""},
+		{"PEPTIDE", "", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.proforma, func(t *testing.T) {
+			seq, err := FromProforma(tc.proforma)
+			if err != nil {
+				t.Fatalf("Failed to parse: %v", err)
+			}
+
+			accession, name := seq.GetProteinAccession(), seq.GetProteinName()
+			if tc.accession == "" {
+				if accession != nil || name != nil {
+					t.Errorf("Expected nil accession and name, got '%v' and '%v'", accession, name)
+				}
+				return
+			}
+			if accession == nil || *accession != tc.accession {
+				t.Errorf("Expected accession '%s', got '%v'", tc.accession, accession)
+			}
+			if name == nil || *name != tc.name {
+				t.Errorf("Expected name '%s', got '%v'", tc.name, name)
+			}
+		})
+	}
+}
+
 func TestNameWithModifications(t *testing.T) {
 	proforma := "(>Tryptic peptide)SEQUEN[Phospho]CE[Oxidation]"
 	seq, err := FromProforma(proforma)
@@ -716,6 +1466,82 @@ func TestChargedFormulaPositive(t *testing.T) {
 	}
 }
 
+func TestChargedFormulaMass(t *testing.T) {
+	seq, err := FromProforma("SEQUEN[Formula:Zn1:z+2]CE")
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+
+	mod := seq.GetSeq()[5].GetMods()[0]
+	pv := mod.GetModificationValue().GetPipeValues()[0]
+
+	neutral := pv.GetNeutralMass()
+	if neutral == nil || math.Abs(*neutral-63.9291422) > 1e-6 {
+		t.Fatalf("Expected neutral mass 63.9291422, got %v", neutral)
+	}
+
+	charged := pv.GetChargedMass()
+	expected := 63.9291422 - 2*ElectronMass
+	if charged == nil || math.Abs(*charged-expected) > 1e-6 {
+		t.Fatalf("Expected charged mass %f, got %v", expected, charged)
+	}
+
+	resolved := mod.GetResolvedMass()
+	if resolved == nil || math.Abs(*resolved-expected) > 1e-6 {
+		t.Errorf("Expected resolved mass %f, got %v", expected, resolved)
+	}
+
+	negative, err := FromProforma("SEQUEN[Formula:Cl1:z-1]CE")
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	negativePv := negative.GetSeq()[5].GetMods()[0].GetModificationValue().GetPipeValues()[0]
+	expected = 34.96885268 + ElectronMass
+	if negativePv.GetChargedMass() == nil || math.Abs(*negativePv.GetChargedMass()-expected) > 1e-6 {
+		t.Errorf("Expected charged mass %f for z-1, got %v", expected, negativePv.GetChargedMass())
+	}
+
+	uncharged, err := FromProforma("SEQUEN[Formula:H2O]CE")
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	unchargedPv := uncharged.GetSeq()[5].GetMods()[0].GetModificationValue().GetPipeValues()[0]
+	if *unchargedPv.GetChargedMass() != *unchargedPv.GetNeutralMass() {
+		t.Errorf("Expected uncharged formula to have equal neutral and charged mass")
+	}
+}
+
+func TestCalculateFormulaMass(t *testing.T) {
+	tests := []struct {
+		formula  string
+		expected float64
+	}{
+		{"H2O", 18.0105646837},
+		{"C2H2O", 42.0105646837},
+		{"C 2 H 2 O", 42.0105646837},
+		{"H-1N1", 12.9952489727},
+		{"[13C2]C-2", 2.0067096756},
+		{"[13C]2C-2", 2.0067096756},
+	}
+
+	for _, tt := range tests {
+		mass, err := CalculateFormulaMass(tt.formula)
+		if err != nil {
+			t.Errorf("Failed to calculate mass of '%s': %v", tt.formula, err)
+			continue
+		}
+		if math.Abs(mass-tt.expected) > 0.01 {
+			t.Errorf("Expected mass %f for '%s', got %f", tt.expected, tt.formula, mass)
+		}
+	}
+
+	for _, formula := range []string{"", "Xx2", "[99C]", "C2H(", "[13C"} {
+		if _, err := CalculateFormulaMass(formula); err == nil {
+			t.Errorf("Expected error for formula '%s'", formula)
+		}
+	}
+}
+
 func TestChargedFormulaNegative(t *testing.T) {
 	proforma := "PEPTIDE[Formula:C2H3NO:z-1]"
 	seq, err := FromProforma(proforma)
@@ -1069,3 +1895,323 @@ func TestTerminalGlobalMultiple(t *testing.T) {
 		t.Errorf("Roundtrip failed: expected '%s', got '%s'", proforma, seq.ToProforma())
 	}
 }
+
+func TestParseByteOrderMarkAndLineEndings(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"\ufeffPEPTIDE\r", "PEPTIDE"},
+		{"\ufeffPEPTIDE\r\n", "PEPTIDE"},
+		{"PEP[Phospho]TIDE/2\r\n", "PEP[Phospho]TIDE/2"},
+		{"\ufeff[Acetyl]-PEPTIDE//SEQUENCE\n", "[Acetyl]-PEPTIDE//SEQUENCE"},
+		{"\ufeffPEPTIDE/2+ANOTHER/3\r", "PEPTIDE/2+ANOTHER/3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			seq, err := FromProforma(tt.input)
+			if err != nil {
+				t.Fatalf("Failed to parse %q: %v", tt.input, err)
+			}
+			if seq.ToProforma() != tt.expected {
+				t.Errorf("Expected '%s', got '%s'", tt.expected, seq.ToProforma())
+			}
+		})
+	}
+
+	seq, err := FromProforma("\ufeffPEPT[Phospho]IDE")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if start, end, _ := seq.GetSeq()[3].GetMods()[0].GetSourceSpan(); start != 7 || end != 16 {
+		t.Errorf("Expected span 7-16 including the byte order mark, got %d-%d", start, end)
+	}
+
+	_, err = FromProforma("\ufeffPEP[Phospho\r\n")
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("Expected a ParseError, got %v", err)
+	}
+	if parseErr.Offset != 6 {
+		t.Errorf("Expected offset 6, got %d", parseErr.Offset)
+	}
+	if parseErr.Error() != "unclosed square bracket at position 6" || parseErr.Message != parseErr.Error() {
+		t.Errorf("Expected the message to report position 6, got '%s'", parseErr.Error())
+	}
+
+	_, err = FromProforma("\ufeffPEPT[Phospho")
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("Expected a ParseError, got %v", err)
+	}
+	if parseErr.Offset != 7 || !strings.Contains(parseErr.Error(), "position 7") {
+		t.Errorf("Expected an error at position 7, got %d: '%s'", parseErr.Offset, parseErr.Error())
+	}
+
+	sequences, errs := ParseProformaColumn(strings.NewReader("\ufeffELVISK,1\r\nPEPTIDE,2\r\n"), 0, false)
+	for i, expected := range []string{"ELVISK", "PEPTIDE"} {
+		if errs[i] != nil || sequences[i].ToProforma() != expected {
+			t.Errorf("Expected '%s', got %v (%v)", expected, sequences[i], errs[i])
+		}
+	}
+}
+
+func TestParseProformaPartial(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		kinds    []ParseErrorKind
+		offsets  []int
+	}{
+		{"PEPT[Phospho]IDE", "PEPT[Phospho]IDE", nil, nil},
+		{"PEPTIDE[Phospho", "PEPTIDE", []ParseErrorKind{ParseErrorUnclosedBracket}, []int{7}},
+		{"PEP1TIDE[Phospho", "PEPTIDE", []ParseErrorKind{ParseErrorInvalidResidue, ParseErrorUnclosedBracket}, []int{3, 8}},
+		{"<[Carbamidomethyl]@C@D>PE(PT)IDE)K", "PEPTIDEK", []ParseErrorKind{ParseErrorInvalidGlobalModification, ParseErrorUnmatchedParenthesis}, []int{0, 32}},
+		{"{Glycan:Hex}^0PEPTIDE", "{Glycan:Hex}PEPTIDE", []ParseErrorKind{ParseErrorInvalidMultiplier}, []int{12}},
+		{"[Phospho", "", []ParseErrorKind{ParseErrorUnclosedBracket}, []int{0}},
+		{"PEP[]TIDE", "PEPTIDE", []ParseErrorKind{ParseErrorEmptyModification}, []int{3}},
+		{"[]-PEPTIDE-[Amidated]", "PEPTIDE-[Amidated]", []ParseErrorKind{ParseErrorEmptyModification}, []int{0}},
+		{"<[]@C>PEPTIDE-[]", "PEPTIDE", []ParseErrorKind{ParseErrorEmptyModification, ParseErrorEmptyModification}, []int{0, 14}},
+		{"PEP[]+ELV[]", "PEP+ELV", []ParseErrorKind{ParseErrorEmptyModification, ParseErrorEmptyModification}, []int{3, 9}},
+		{"PEPÉTIDE", "PEPTIDE", []ParseErrorKind{ParseErrorInvalidResidue}, []int{3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			seq, errs := ParseProformaPartial(tt.input)
+			if tt.expected == "" {
+				if seq != nil {
+					t.Errorf("Expected no sequence, got '%s'", seq.ToProforma())
+				}
+			} else if seq == nil {
+				t.Fatalf("Expected '%s', got no sequence", tt.expected)
+			} else if seq.ToProforma() != tt.expected {
+				t.Errorf("Expected '%s', got '%s'", tt.expected, seq.ToProforma())
+			}
+
+			if len(errs) != len(tt.kinds) {
+				t.Fatalf("Expected %d errors, got %v", len(tt.kinds), errs)
+			}
+			for i, parseErr := range errs {
+				if parseErr.Kind != tt.kinds[i] || parseErr.Offset != tt.offsets[i] {
+					t.Errorf("Expected %s at %d, got %s at %d", tt.kinds[i], tt.offsets[i], parseErr.Kind, parseErr.Offset)
+				}
+				if !strings.Contains(parseErr.Message, fmt.Sprintf("position %d", tt.offsets[i])) || parseErr.Message != parseErr.Error() {
+					t.Errorf("Expected message to report position %d, got '%s'", tt.offsets[i], parseErr.Message)
+				}
+			}
+		})
+	}
+
+	if _, err := FromProforma("PEP1TIDE[Phospho"); err == nil {
+		t.Error("Expected FromProforma to still fail on the first error")
+	}
+}
+
+func TestParseMany(t *testing.T) {
+	inputs := []string{
+		"PEPTIDE",
+		"[Acetyl]-PEP[Phospho]TIDE-[Amidated]",
+		"PEP[Phospho",
+		"PEPTIDE/2+ANOTHER/3",
+		"PEPTIDE//SEQUENCE",
+	}
+
+	sequences, errs := ParseMany(inputs)
+	if len(sequences) != len(inputs) || len(errs) != len(inputs) {
+		t.Fatalf("Expected %d results, got %d sequences and %d errors", len(inputs), len(sequences), len(errs))
+	}
+
+	for i, input := range inputs {
+		if i == 2 {
+			if errs[i] == nil || sequences[i] != nil {
+				t.Errorf("Expected error for '%s'", input)
+			}
+			continue
+		}
+		if errs[i] != nil {
+			t.Errorf("Failed to parse '%s': %v", input, errs[i])
+			continue
+		}
+		if sequences[i].ToProforma() != input {
+			t.Errorf("Roundtrip failed: expected '%s', got '%s'", input, sequences[i].ToProforma())
+		}
+	}
+}
+
+func TestParseProformaColumn(t *testing.T) {
+	t.Run("tab separated with header", func(t *testing.T) {
+		data := "scan\tpeptidoform\tscore\n" +
+			"1\tPEP[Phospho]TIDE\t0.9\n" +
+			"2\t\t0.5\n" +
+			"3\tPEP[Phospho\t0.1\n" +
+			"4\n" +
+			"5\t<[Carbamidomethyl]@C,M>PEPTCDE/2\t0.7\n"
+
+		sequences, errs := ParseProformaColumn(strings.NewReader(data), 1, true)
+		if len(sequences) != 5 || len(errs) != 5 {
+			t.Fatalf("Expected 5 results, got %d sequences and %d errors", len(sequences), len(errs))
+		}
+
+		if errs[0] != nil || sequences[0] == nil || sequences[0].ToProforma() != "PEP[Phospho]TIDE" {
+			t.Errorf("Expected 'PEP[Phospho]TIDE', got %v (%v)", sequences[0], errs[0])
+		}
+		if sequences[1] != nil || errs[1] != nil {
+			t.Errorf("Expected empty cell to be skipped, got %v (%v)", sequences[1], errs[1])
+		}
+		var parseErr *ParseError
+		if sequences[2] != nil || !errors.As(errs[2], &parseErr) {
+			t.Errorf("Expected parse error for row 4, got %v", errs[2])
+		}
+		if sequences[3] != nil || errs[3] == nil {
+			t.Error("Expected error for row without the column")
+		}
+		if errs[4] != nil || sequences[4] == nil || sequences[4].ToProforma() != "<[Carbamidomethyl]@C,M>PEPTCDE/2" {
+			t.Errorf("Expected global modification row to parse, got %v (%v)", sequences[4], errs[4])
+		}
+	})
+
+	t.Run("comma separated with quoted fields", func(t *testing.T) {
+		data := "ELVISK,1\n\"<[Oxidation]@M,W>PEPMWIDE\",2\n"
+
+		sequences, errs := ParseProformaColumn(strings.NewReader(data), 0, false)
+		expected := []string{"ELVISK", "<[Oxidation]@M,W>PEPMWIDE"}
+		if len(sequences) != len(expected) {
+			t.Fatalf("Expected %d results, got %d", len(expected), len(sequences))
+		}
+		for i, proforma := range expected {
+			if errs[i] != nil {
+				t.Errorf("Failed to parse row %d: %v", i+1, errs[i])
+				continue
+			}
+			if sequences[i].ToProforma() != proforma {
+				t.Errorf("Expected '%s', got '%s'", proforma, sequences[i].ToProforma())
+			}
+		}
+	})
+
+	t.Run("negative column", func(t *testing.T) {
+		sequences, errs := ParseProformaColumn(strings.NewReader("PEPTIDE\n"), -1, false)
+		if len(sequences) != 0 || len(errs) != 1 || errs[0] == nil {
+			t.Errorf("Expected a single error, got %v", errs)
+		}
+	})
+}
+
+var benchmarkInputs = []string{
+	"PEPTIDE",
+	"[Acetyl]-PEP[Phospho]TIDE-[Amidated]",
+	"ELVIS[U:Phospho|+79.966331]K",
+	"<[Carbamidomethyl]@C>PEPTCDE/2",
+	"EM[Oxidation]EVEES[Phospho]PEK",
+}
+
+func BenchmarkFromProformaEach(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		for _, input := range benchmarkInputs {
+			if _, err := FromProforma(input); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkParseMany(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		if _, errs := ParseMany(benchmarkInputs); errs[0] != nil {
+			b.Fatal(errs[0])
+		}
+	}
+}
+
+func BenchmarkParseAmbiguousModifications(b *testing.B) {
+	var sb strings.Builder
+	for i := 0; i < 48; i++ {
+		fmt.Fprintf(&sb, "S[Phospho#g%d(0.%d)]T[#g%d(0.%d)]", i, i%10, i, 9-i%10)
+	}
+	proforma := sb.String()
+
+	parser := NewProFormaParser()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, _, _, _, err := parser.Parse(proforma); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestModificationSourceSpans(t *testing.T) {
+	tests := []struct {
+		name     string
+		proforma string
+	}{
+		{"Residue modification", "PEPT[Phospho]IDE"},
+		{"Terminal modifications", "[Acetyl][+1.0]-PEPTIDE-[Amidated]"},
+		{"Global, labile and unknown position", "<[Carbamidomethyl]@C><13C>[Oxidation]^2?{Hex}PEPTCDE"},
+		{"Ambiguous and range modifications", "PRT(ESFRMS)[+19.0523]IS{Phospho}K/2"},
+		{"Crosslinked chains", "PEPTK[XL:DSS#XL1]IDE//SEK[#XL1]QR"},
+		{"Chimeric peptidoforms", "<[Carbamidomethyl]@C>PEPTC[Oxidation]/2+ANOTHERC[Phospho]/3"},
+		{"Named peptidoform", "(>Tryptic)SEQUEN[Phospho]CE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seq, err := FromProforma(tt.proforma)
+			if err != nil {
+				t.Fatalf("Failed to parse ProForma '%s': %v", tt.proforma, err)
+			}
+
+			sequences := []*Sequence{seq}
+			if seq.IsMultiChain() {
+				sequences = seq.GetChains()
+			} else if seq.IsChimeric() {
+				sequences = seq.GetPeptidoforms()
+			}
+
+			checked := 0
+			for _, s := range sequences {
+				mods := make([]*Modification, 0)
+				for _, gm := range s.GetGlobalMods() {
+					mods = append(mods, &gm.Modification)
+				}
+				for _, loc := range s.GetModificationsByType("") {
+					mods = append(mods, loc.Modification)
+				}
+
+				for _, mod := range mods {
+					start, end, ok := mod.GetSourceSpan()
+					if !ok {
+						t.Errorf("Expected source span for modification '%s'", mod.GetValue())
+						continue
+					}
+					if start < 0 || end > len(tt.proforma) || start >= end {
+						t.Errorf("Invalid span [%d, %d) for modification '%s'", start, end, mod.GetValue())
+						continue
+					}
+					annotation := tt.proforma[start:end]
+					open, close := annotation[0], annotation[len(annotation)-1]
+					if !(open == '[' && close == ']') && !(open == '{' && close == '}') && !(open == '<' && close == '>') {
+						t.Errorf("Span [%d, %d) of '%s' does not cover an annotation: '%s'", start, end, mod.GetValue(), annotation)
+					}
+					checked++
+				}
+			}
+			if checked == 0 {
+				t.Errorf("Expected at least one modification to check")
+			}
+		})
+	}
+
+	seq, _ := FromProforma("PEPT[Phospho]IDE//SEK[Acetyl]QR")
+	start, end, _ := seq.GetChains()[1].GetSeq()[2].GetMods()[0].GetSourceSpan()
+	if start != 21 || end != 29 {
+		t.Errorf("Expected span [21, 29) for Acetyl in second chain, got [%d, %d)", start, end)
+	}
+
+	mod := NewModification("Phospho", nil, nil, nil, "static", false, 0, 0.0, false,
+		nil, false, false, false, nil, false, false, nil, nil, nil, nil,
+		nil, nil, false, false, false)
+	if _, _, ok := mod.GetSourceSpan(); ok {
+		t.Errorf("Expected no source span for a constructed modification")
+	}
+}
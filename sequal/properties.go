@@ -0,0 +1,181 @@
+package sequal
+
+import (
+	"math"
+	"strings"
+)
+
+// GRAVY returns the grand average of hydropathy of the sequence: the mean Kyte-Doolittle
+// hydropathy index (see KyteDoolittle) of its residues. Residues without an index, such as
+// X, U, O or ambiguous codes, are left out of both the sum and the count, and a sequence
+// without any standard residue has a GRAVY of 0. Modifications are not taken into account.
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("PEPTIDE")
+//	fmt.Printf("%.3f\n", seq.GRAVY()) // -1.414
+func (s *Sequence) GRAVY() float64 {
+	total := 0.0
+	count := 0
+	for _, aa := range s.seq {
+		if hydropathy, ok := KyteDoolittle[aa.GetValue()]; ok {
+			total += hydropathy
+			count++
+		}
+	}
+	if count == 0 {
+		return 0
+	}
+	return total / float64(count)
+}
+
+// pKa values of the free termini and of the two ionizable groups of a phosphate, used by
+// IsoelectricPoint
+const (
+	nTermPKa      = 8.6
+	cTermPKa      = 3.6
+	phosphatePKa1 = 1.2
+	phosphatePKa2 = 6.5
+)
+
+// phosphoMass is the monoisotopic mass of the HPO3 added by phosphorylation
+const phosphoMass = 79.966331
+
+// IsoelectricPoint estimates the pH at which the sequence has no net charge from its residues
+// and free termini, using the pKa values in AAPKa and the Henderson-Hasselbalch equation. The
+// pH is found by bisection between 0 and 14 to within 0.001. Modifications are ignored; use
+// IsoelectricPointWithModifications to account for phosphorylation.
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("PEPTIDEK")
+//	fmt.Printf("%.2f\n", seq.IsoelectricPoint()) // 3.93
+func (s *Sequence) IsoelectricPoint() float64 {
+	return s.isoelectricPoint(0)
+}
+
+// IsoelectricPointWithModifications estimates the isoelectric point like IsoelectricPoint, with
+// each phosphorylation adding the two acidic groups of a phosphate (pKa 1.2 and 6.5). A
+// phosphorylation is a modification resolving to Unimod accession 21, such as Phospho or
+// UNIMOD:21, or a mass shift within 0.002 Da of HPO3; a range modification is counted once,
+// and crosslink and ambiguity references are not counted. Other modifications, including
+// Sulfo, do not change the result.
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("PEPS[Phospho]TIDEK")
+//	fmt.Printf("%.2f %.2f\n", seq.IsoelectricPoint(), seq.IsoelectricPointWithModifications()) // 3.93 3.43
+func (s *Sequence) IsoelectricPointWithModifications() float64 {
+	phosphates := 0
+	counted := make(map[*Modification]bool)
+	for _, loc := range s.GetModificationsByType("") {
+		mod := loc.Modification
+		if counted[mod] || mod.IsCrosslinkRef() || mod.IsAmbiguityRef() {
+			continue
+		}
+		counted[mod] = true
+		if isPhosphorylation(mod) {
+			phosphates++
+		}
+	}
+	return s.isoelectricPoint(phosphates)
+}
+
+// isPhosphorylation reports whether mod is a phosphorylation. Only mass shifts are matched
+// by mass, and tightly enough to tell HPO3 from the SO3 of a sulfation.
+func isPhosphorylation(mod *Modification) bool {
+	if entry, ok := resolveUnimodValue(mod.GetSource(), mod.GetValue()); ok {
+		return entry.Accession == 21
+	}
+	mass, isShift := mod.massShift()
+	return isShift && math.Abs(mass-phosphoMass) <= 0.002
+}
+
+// isoelectricPoint finds the pH of zero net charge of the residues and termini together with
+// the given number of phosphate groups
+func (s *Sequence) isoelectricPoint(phosphates int) float64 {
+	netCharge := func(pH float64) float64 {
+		charge := positiveCharge(pH, nTermPKa) - negativeCharge(pH, cTermPKa)
+		for _, aa := range s.seq {
+			pKa, ok := AAPKa[aa.GetValue()]
+			if !ok {
+				continue
+			}
+			switch aa.GetValue() {
+			case "K", "R", "H":
+				charge += positiveCharge(pH, pKa)
+			default:
+				charge -= negativeCharge(pH, pKa)
+			}
+		}
+		charge -= float64(phosphates) * (negativeCharge(pH, phosphatePKa1) + negativeCharge(pH, phosphatePKa2))
+		return charge
+	}
+
+	// The net charge falls as the pH rises, so keep the half where it changes sign
+	low, high := 0.0, 14.0
+	for high-low > 0.001 {
+		mid := (low + high) / 2
+		if netCharge(mid) > 0 {
+			low = mid
+		} else {
+			high = mid
+		}
+	}
+	return (low + high) / 2
+}
+
+// positiveCharge returns the fraction of a basic group that is protonated at pH
+func positiveCharge(pH, pKa float64) float64 {
+	return 1 / (1 + math.Pow(10, pH-pKa))
+}
+
+// negativeCharge returns the fraction of an acidic group that is deprotonated at pH
+func negativeCharge(pH, pKa float64) float64 {
+	return 1 / (1 + math.Pow(10, pKa-pH))
+}
+
+// CountBasicResidues returns the number of basic residues (K, R and H) in the sequence, over
+// all chains of a multi-chain sequence. It counts residue identities only, so modifications
+// that change the charge of a residue, such as acetylation of a lysine, are not taken into
+// account.
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("PEPK[Acetyl]RHIDE")
+//	fmt.Println(seq.CountBasicResidues()) // 3
+func (s *Sequence) CountBasicResidues() int {
+	return s.countResidues("KRH")
+}
+
+// CountAcidicResidues returns the number of acidic residues (D and E) in the sequence. Like
+// CountBasicResidues it counts residue identities only, so a phosphorylated serine is not
+// counted as acidic.
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("PEPS[Phospho]TIDE")
+//	fmt.Println(seq.CountAcidicResidues()) // 3
+func (s *Sequence) CountAcidicResidues() int {
+	return s.countResidues("DE")
+}
+
+// countResidues returns the number of residues whose one-letter code is in codes, summed
+// over all chains of a multi-chain sequence
+func (s *Sequence) countResidues(codes string) int {
+	if s.isMultiChain && len(s.chains) > 0 {
+		total := 0
+		for _, chain := range s.chains {
+			total += chain.countResidues(codes)
+		}
+		return total
+	}
+
+	count := 0
+	for _, aa := range s.seq {
+		if value := aa.GetValue(); len(value) == 1 && strings.Contains(codes, value) {
+			count++
+		}
+	}
+	return count
+}
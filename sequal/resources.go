@@ -9,6 +9,67 @@ const (
 	O      = 15.99491463
 )
 
+// ElectronMass is the rest mass of an electron in Daltons
+const ElectronMass = 0.00054858
+
+// ElementMass maps element symbols to their monoisotopic masses
+var ElementMass = map[string]float64{
+	"H":  1.00782503207,
+	"Li": 7.01600455,
+	"B":  11.0093054,
+	"C":  12.0,
+	"N":  14.0030740048,
+	"O":  15.99491461956,
+	"F":  18.99840322,
+	"Na": 22.9897692809,
+	"Mg": 23.9850417,
+	"Al": 26.98153863,
+	"Si": 27.9769265325,
+	"P":  30.97376163,
+	"S":  31.97207100,
+	"Cl": 34.96885268,
+	"K":  38.96370668,
+	"Ca": 39.96259098,
+	"Cr": 51.9405075,
+	"Mn": 54.9380451,
+	"Fe": 55.9349375,
+	"Co": 58.933195,
+	"Ni": 57.9353429,
+	"Cu": 62.9295975,
+	"Zn": 63.9291422,
+	"As": 74.9215965,
+	"Se": 79.9165213,
+	"Br": 78.9183371,
+	"Mo": 97.9054082,
+	"Ag": 106.905097,
+	"Cd": 113.9033585,
+	"I":  126.904473,
+	"Pt": 194.9647911,
+	"Au": 196.9665687,
+	"Hg": 201.970643,
+	"Pb": 207.9766521,
+}
+
+// IsotopeMass maps isotope symbols (mass number followed by element) to their masses
+var IsotopeMass = map[string]float64{
+	"1H":   1.00782503207,
+	"2H":   2.0141017778,
+	"12C":  12.0,
+	"13C":  13.0033548378,
+	"14N":  14.0030740048,
+	"15N":  15.0001088982,
+	"16O":  15.99491461956,
+	"17O":  16.9991317,
+	"18O":  17.999161,
+	"32S":  31.972071,
+	"33S":  32.97145876,
+	"34S":  33.9678669,
+	"35Cl": 34.96885268,
+	"37Cl": 36.96590259,
+	"79Br": 78.9183371,
+	"81Br": 80.9162906,
+}
+
 // AAMass maps amino acid one-letter codes to their masses
 var AAMass = map[string]float64{
 	"A": 71.037114,
@@ -32,8 +93,73 @@ var AAMass = map[string]float64{
 	"Y": 163.06332,
 	"V": 99.068414,
 	"X": 0,
-	"O": 150.03794,
-	"U": 255.15829, // Note: U appears twice in original Python code
+	"O": 237.147727, // Pyrrolysine, C12H19N3O2
+	"U": 150.953636, // Selenocysteine, C3H5NOSe
+}
+
+// AAComposition maps amino acid one-letter codes to the elemental composition of their
+// residues (the free amino acid minus H2O)
+var AAComposition = map[string]map[string]int{
+	"A": {"C": 3, "H": 5, "N": 1, "O": 1},
+	"R": {"C": 6, "H": 12, "N": 4, "O": 1},
+	"N": {"C": 4, "H": 6, "N": 2, "O": 2},
+	"D": {"C": 4, "H": 5, "N": 1, "O": 3},
+	"C": {"C": 3, "H": 5, "N": 1, "O": 1, "S": 1},
+	"E": {"C": 5, "H": 7, "N": 1, "O": 3},
+	"Q": {"C": 5, "H": 8, "N": 2, "O": 2},
+	"G": {"C": 2, "H": 3, "N": 1, "O": 1},
+	"H": {"C": 6, "H": 7, "N": 3, "O": 1},
+	"I": {"C": 6, "H": 11, "N": 1, "O": 1},
+	"L": {"C": 6, "H": 11, "N": 1, "O": 1},
+	"K": {"C": 6, "H": 12, "N": 2, "O": 1},
+	"M": {"C": 5, "H": 9, "N": 1, "O": 1, "S": 1},
+	"F": {"C": 9, "H": 9, "N": 1, "O": 1},
+	"P": {"C": 5, "H": 7, "N": 1, "O": 1},
+	"S": {"C": 3, "H": 5, "N": 1, "O": 2},
+	"T": {"C": 4, "H": 7, "N": 1, "O": 2},
+	"W": {"C": 11, "H": 10, "N": 2, "O": 1},
+	"Y": {"C": 9, "H": 9, "N": 1, "O": 2},
+	"V": {"C": 5, "H": 9, "N": 1, "O": 1},
+	"O": {"C": 12, "H": 19, "N": 3, "O": 2},
+	"U": {"C": 3, "H": 5, "N": 1, "O": 1, "Se": 1},
+}
+
+// KyteDoolittle maps the 20 standard amino acid one-letter codes to their Kyte-Doolittle
+// hydropathy index
+var KyteDoolittle = map[string]float64{
+	"A": 1.8,
+	"R": -4.5,
+	"N": -3.5,
+	"D": -3.5,
+	"C": 2.5,
+	"E": -3.5,
+	"Q": -3.5,
+	"G": -0.4,
+	"H": -3.2,
+	"I": 4.5,
+	"L": 3.8,
+	"K": -3.9,
+	"M": 1.9,
+	"F": 2.8,
+	"P": -1.6,
+	"S": -0.8,
+	"T": -0.7,
+	"W": -0.9,
+	"Y": -1.3,
+	"V": 4.2,
+}
+
+// AAPKa maps the amino acids with an ionizable side chain to its pKa, using the EMBOSS
+// values. K, R and H are basic and become positively charged below their pKa; D, E, C and Y
+// are acidic and become negatively charged above it.
+var AAPKa = map[string]float64{
+	"K": 10.8,
+	"R": 12.5,
+	"H": 6.5,
+	"D": 3.9,
+	"E": 4.1,
+	"C": 8.5,
+	"Y": 10.1,
 }
 
 // GlycanBlockDict maps glycan block names to their masses
@@ -1,10 +1,16 @@
 package sequal
 
 import (
+	"bufio"
+	"encoding/csv"
+	"errors"
 	"fmt"
+	"io"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 )
 
 // Sequence represents a peptide sequence with modifications and supports ProForma notation.
@@ -24,6 +30,11 @@ type Sequence struct {
 	peptidoformName     *string
 	peptidoformIonName  *string
 	compoundIonName     *string
+	nTermMass           *float64
+	cTermMass           *float64
+	// viewOf is the multi-chain or chimeric sequence this sequence is the first chain or
+	// peptidoform view of; the two share their residues and modifications
+	viewOf *Sequence
 }
 
 // NewSequence creates a new Sequence instance with the specified parameters.
@@ -81,7 +92,8 @@ func NewSequence(seq interface{}, mods map[int][]*Modification, parse bool,
 
 // FromProforma creates a Sequence object from a ProForma notation string.
 // Supports all ProForma 2.0 features including multi-chain sequences (//),
-// chimeric sequences (+), and all modification types.
+// chimeric sequences (+), and all modification types. A leading UTF-8 byte order mark and
+// trailing line endings, as found in lines read from files, are ignored.
 //
 // Examples:
 //
@@ -107,20 +119,243 @@ func NewSequence(seq interface{}, mods map[int][]*Modification, parse bool,
 //	fmt.Println(seq.IsChimeric()) // true
 //	fmt.Println(len(seq.GetPeptidoforms())) // 2
 func FromProforma(proformaStr string) (*Sequence, error) {
+	return NewProFormaParser().ParseSequence(proformaStr)
+}
+
+// ParseMany parses a list of ProForma strings with a single shared ProFormaParser, so the
+// parser's patterns are compiled once rather than per input. The returned slices have the
+// same length as inputs; for each index either the sequence or the error is nil.
+//
+// Example:
+//
+//	seqs, errs := sequal.ParseMany([]string{"PEPTIDE", "PEP[Phospho]TIDE"})
+//	fmt.Println(len(seqs), errs[1] == nil) // 2 true
+func ParseMany(inputs []string) ([]*Sequence, []error) {
+	parser := NewProFormaParser()
+	sequences := make([]*Sequence, len(inputs))
+	errs := make([]error, len(inputs))
+
+	for i, input := range inputs {
+		sequences[i], errs[i] = parser.ParseSequence(input)
+	}
+
+	return sequences, errs
+}
+
+// ParseProformaPartial parses a ProForma string like FromProforma, but instead of stopping
+// at the first error it recovers and keeps going, returning the sequence parsed from the
+// repaired input together with every error found. The sequence is nil only when nothing
+// could be recovered, and the errors are nil when the input is valid.
+//
+// Recovery repairs the input at each error and parses it again:
+//   - an invalid residue, a stray ')' or an unclosed '(' is dropped
+//   - an invalid '^' multiplier is dropped together with its digits
+//   - an invalid global modification is dropped up to its closing '>'
+//   - an empty modification such as "[]" or "{}" is dropped, with its '-' or '?' separator
+//   - an unclosed bracket, brace or angle bracket and any other error truncate the input
+//     at the offending character, keeping the valid prefix
+//
+// Offsets in the returned errors refer to the original string. Errors that only surface
+// once an earlier one is repaired are reported as well, except past a truncation.
+//
+// Example:
+//
+//	seq, errs := sequal.ParseProformaPartial("PEP1TIDE[Phospho")
+//	fmt.Println(seq.GetSequence(), len(errs)) // PEPTIDE 2
+//	fmt.Println(errs[0].Kind, errs[1].Offset) // invalid_residue 8
+func ParseProformaPartial(proformaStr string) (*Sequence, []ParseError) {
+	parser := NewProFormaParser()
+	var errs []ParseError
+
+	// origins maps each byte of the repaired input to its offset in proformaStr
+	input := proformaStr
+	origins := make([]int, len(input))
+	for i := range origins {
+		origins[i] = i
+	}
+	remove := func(start, end int) {
+		input = input[:start] + input[end:]
+		origins = append(origins[:start:start], origins[end:]...)
+	}
+
+	for input != "" {
+		seq, err := parser.ParseSequence(input)
+		if err == nil {
+			return seq, errs
+		}
+
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) {
+			errs = append(errs, ParseError{Kind: ParseErrorUnsupportedFeature, Message: err.Error()})
+			break
+		}
+		at := parseErr.Offset
+		if at < 0 || at > len(input) {
+			at = len(input)
+		}
+		recorded := *parseErr
+		if at < len(origins) {
+			recorded.setOffset(origins[at])
+		} else {
+			recorded.setOffset(len(proformaStr))
+		}
+		errs = append(errs, recorded)
+
+		switch {
+		case at == len(input):
+			remove(0, len(input))
+		case parseErr.Kind == ParseErrorInvalidResidue:
+			_, width := utf8.DecodeRuneInString(input[at:])
+			remove(at, at+width)
+		case parseErr.Kind == ParseErrorUnmatchedParenthesis,
+			parseErr.Kind == ParseErrorUnclosedParenthesis:
+			remove(at, at+1)
+		case parseErr.Kind == ParseErrorInvalidMultiplier:
+			end := at + 1
+			for end < len(input) && input[end] >= '0' && input[end] <= '9' {
+				end++
+			}
+			remove(at, end)
+		case parseErr.Kind == ParseErrorInvalidGlobalModification && input[at] == '<':
+			end := parser.findBalancedAngleBracket(input[at:], 1)
+			if end == -1 {
+				end = len(input) - at
+			}
+			remove(at, at+end)
+		case parseErr.Kind == ParseErrorEmptyModification && input[at] == '<':
+			end := parser.findBalancedAngleBracket(input[at:], 1)
+			if end == -1 {
+				end = len(input) - at
+			}
+			remove(at, at+end)
+		case parseErr.Kind == ParseErrorEmptyModification:
+			closing := byte(']')
+			if input[at] == '{' {
+				closing = '}'
+			}
+			end := strings.IndexByte(input[at:], closing) + at + 1
+			// Drop the separator of an emptied terminal or unknown-position prefix as well
+			prefix := at == 0 || input[at-1] == '>' || input[at-1] == '}'
+			if prefix && end < len(input) && (input[end] == '-' || input[end] == '?') {
+				end++
+			} else if at > 0 && input[at-1] == '-' && (end == len(input) || input[end] == '/' || input[end] == '+') {
+				at--
+			}
+			remove(at, end)
+		default:
+			remove(at, len(input))
+		}
+	}
+
+	return nil, errs
+}
+
+// ParseProformaColumn reads a delimited file and parses the ProForma string in the given
+// zero-based column of each row with a single shared ProFormaParser. The delimiter is a tab
+// if the first line contains one, and a comma otherwise; quoted fields are supported. When
+// hasHeader is true the first row is skipped.
+//
+// The returned slices have one entry per data row. For each index at most one of the
+// sequence and the error is set; both are nil for empty cells. Rows without the column and
+// malformed rows are reported as errors, and reading stops at the first I/O error.
+//
+// Example:
+//
+//	data := "scan\tpeptidoform\n1\tPEP[Phospho]TIDE\n2\tELVISK/2\n"
+//	seqs, errs := sequal.ParseProformaColumn(strings.NewReader(data), 1, true)
+//	fmt.Println(len(seqs), seqs[0].ToProforma(), errs[1] == nil) // 2 PEP[Phospho]TIDE true
+func ParseProformaColumn(r io.Reader, column int, hasHeader bool) ([]*Sequence, []error) {
+	if column < 0 {
+		return nil, []error{fmt.Errorf("column must not be negative, got %d", column)}
+	}
+
+	buffered := bufio.NewReader(r)
+	reader := csv.NewReader(buffered)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	start, _ := buffered.Peek(buffered.Size())
+	if firstLine, _, _ := strings.Cut(string(start), "\n"); strings.Contains(firstLine, "\t") {
+		reader.Comma = '\t'
+	}
+
+	parser := NewProFormaParser()
+	sequences := make([]*Sequence, 0)
+	errs := make([]error, 0)
+
+	row := 0
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		row++
+
+		var csvErr *csv.ParseError
+		if err != nil && !errors.As(err, &csvErr) {
+			sequences = append(sequences, nil)
+			errs = append(errs, fmt.Errorf("row %d: %w", row, err))
+			break
+		}
+		if hasHeader && row == 1 {
+			continue
+		}
+
+		var seq *Sequence
+		if err != nil {
+			err = fmt.Errorf("row %d: %w", row, err)
+		} else if column >= len(record) {
+			err = fmt.Errorf("row %d: missing column %d", row, column)
+		} else if cell := strings.TrimSpace(record[column]); cell != "" {
+			seq, err = parser.ParseSequence(cell)
+			if err != nil {
+				err = fmt.Errorf("row %d: %w", row, err)
+			}
+		}
+
+		sequences = append(sequences, seq)
+		errs = append(errs, err)
+	}
+
+	return sequences, errs
+}
+
+// byteOrderMark is the UTF-8 encoding of U+FEFF written at the start of some text files
+const byteOrderMark = "\ufeff"
+
+// ParseSequence creates a Sequence object from a ProForma notation string using this
+// parser's pre-compiled patterns. It behaves like FromProforma.
+func (p *ProFormaParser) ParseSequence(proformaStr string) (*Sequence, error) {
+	// Lines read from files may start with a byte order mark and end with "\r\n"; offsets
+	// stay relative to the input including the mark
+	if strings.HasPrefix(proformaStr, byteOrderMark) {
+		return p.parseSequencePart(proformaStr[len(byteOrderMark):], len(byteOrderMark))
+	}
+	proformaStr = strings.TrimRight(proformaStr, "\r\n")
+
 	if strings.Contains(proformaStr, "//") {
 		chains := strings.Split(proformaStr, "//")
-		mainSeq, err := FromProforma(chains[0])
+		offsets := partOffsets(proformaStr, chains)
+		mainSeq, err := p.parseSequencePart(chains[0], offsets[0])
 		if err != nil {
 			return nil, err
 		}
 		mainSeq.isMultiChain = true
-		mainSeq.chains = []*Sequence{mainSeq}
+		mainSeq.chains = []*Sequence{mainSeq.firstChain()}
 
 		for i := 1; i < len(chains); i++ {
-			chain, err := FromProforma(chains[i])
+			chain, err := p.parseSequencePart(chains[i], offsets[i])
 			if err != nil {
 				return nil, err
 			}
+			// Compound and peptidoform ion names written before the first chain name the
+			// whole multi-chain ion, so every chain inherits them; a peptidoform name only
+			// names the chain it precedes
+			if chain.compoundIonName == nil {
+				chain.compoundIonName = mainSeq.compoundIonName
+			}
+			if chain.peptidoformIonName == nil {
+				chain.peptidoformIonName = mainSeq.peptidoformIonName
+			}
 			mainSeq.chains = append(mainSeq.chains, chain)
 		}
 
@@ -129,25 +364,31 @@ func FromProforma(proformaStr string) (*Sequence, error) {
 
 	peptidoforms := SplitChimericProforma(proformaStr)
 	if len(peptidoforms) > 1 {
-		mainSeq, err := FromProforma(peptidoforms[0])
+		offsets := partOffsets(proformaStr, peptidoforms)
+		mainSeq, err := p.parseSequencePart(peptidoforms[0], offsets[0])
 		if err != nil {
 			return nil, err
 		}
 		mainSeq.isChimeric = true
-		mainSeq.peptidoforms = []*Sequence{mainSeq}
+		mainSeq.peptidoforms = []*Sequence{mainSeq.firstPeptidoform()}
+
+		// Global modifications written before the first peptidoform apply to all of them
+		sharedGlobalMods := mainSeq.globalMods
 
 		for i := 1; i < len(peptidoforms); i++ {
-			peptidoform, err := FromProforma(peptidoforms[i])
+			peptidoform, err := p.parseSequencePart(peptidoforms[i], offsets[i])
 			if err != nil {
 				return nil, err
 			}
 			peptidoform.isChimeric = true
+			peptidoform.globalMods = append(append([]*GlobalModification{}, sharedGlobalMods...),
+				excludeGlobalMods(peptidoform.globalMods, sharedGlobalMods)...)
 			mainSeq.peptidoforms = append(mainSeq.peptidoforms, peptidoform)
 		}
 
 		return mainSeq, nil
 	}
-	result, err := ParseProFormaDetailed(proformaStr)
+	result, err := p.ParseDetailed(proformaStr)
 	if err != nil {
 		return nil, err
 	}
@@ -177,15 +418,11 @@ func FromProforma(proformaStr string) (*Sequence, error) {
 		compoundIonName,
 	)
 
-	if charge != nil {
-		seq.isChimeric = true
-	}
-
 	for posStr, mods := range modifications {
 		pos, _ := strconv.Atoi(posStr)
 		for _, mod := range mods {
 			switch pos {
-			case -1, -2, -3, -4:
+			case -1, -2, -3, -4, -5:
 				if seq.mods[pos] == nil {
 					seq.mods[pos] = make([]*Modification, 0)
 				}
@@ -202,6 +439,74 @@ func FromProforma(proformaStr string) (*Sequence, error) {
 	return seq, nil
 }
 
+// parseSequencePart parses one chain or peptidoform of a larger ProForma string that starts
+// at the given byte offset, shifting source spans and parse error offsets so they refer to
+// the larger string
+func (p *ProFormaParser) parseSequencePart(part string, offset int) (*Sequence, error) {
+	seq, err := p.ParseSequence(part)
+	if err != nil {
+		var parseErr *ParseError
+		if errors.As(err, &parseErr) {
+			shifted := *parseErr
+			shifted.setOffset(shifted.Offset + offset)
+			return nil, &shifted
+		}
+		return nil, err
+	}
+
+	if offset != 0 {
+		shiftSourceSpans(seq, offset, make(map[*Modification]bool))
+	}
+	return seq, nil
+}
+
+// partOffsets returns the byte offset of each part within s, where the parts appear in
+// order in s as produced by splitting it
+func partOffsets(s string, parts []string) []int {
+	offsets := make([]int, len(parts))
+	pos := 0
+	for i, part := range parts {
+		if idx := strings.Index(s[pos:], part); idx != -1 {
+			pos += idx
+		}
+		offsets[i] = pos
+		pos += len(part)
+	}
+	return offsets
+}
+
+// shiftSourceSpans moves the source spans of every modification in seq, including its
+// chains and peptidoforms, by delta. Modifications shared between positions, such as range
+// modifications, are shifted once.
+func shiftSourceSpans(seq *Sequence, delta int, shifted map[*Modification]bool) {
+	shift := func(mod *Modification) {
+		if shifted[mod] {
+			return
+		}
+		shifted[mod] = true
+		if start, end, ok := mod.GetSourceSpan(); ok {
+			mod.SetSourceSpan(start+delta, end+delta)
+		}
+	}
+
+	for _, gm := range seq.globalMods {
+		shift(&gm.Modification)
+	}
+	for _, loc := range seq.GetModificationsByType("") {
+		shift(loc.Modification)
+	}
+	for _, chain := range seq.chains {
+		if chain != seq {
+			shiftSourceSpans(chain, delta, shifted)
+		}
+	}
+	for _, pep := range seq.peptidoforms {
+		if pep != seq {
+			shiftSourceSpans(pep, delta, shifted)
+		}
+	}
+}
+
 // parseSequence parses the input sequence into a list of AminoAcid objects.
 // The modPosition parameter determines whether modifications are applied to the
 // left ("left") or right ("right") of the amino acid they modify.
@@ -230,7 +535,7 @@ func (s *Sequence) parseSequence(seq interface{}, modPosition string) error {
 	for _, block := range s.sequenceIterator(seqStr) {
 		if !block.IsMod {
 			if modPosition == "left" {
-				aa, err := NewAminoAcid(block.Value, &currentPosition, nil)
+				aa, err := newResidue(block.Value, &currentPosition, nil)
 				if err != nil {
 					return err
 				}
@@ -247,7 +552,7 @@ func (s *Sequence) parseSequence(seq interface{}, modPosition string) error {
 					}
 				}
 
-				aa, err := NewAminoAcid(block.Value, &currentPosition, nil)
+				aa, err := newResidue(block.Value, &currentPosition, nil)
 				if err != nil {
 					return err
 				}
@@ -343,24 +648,115 @@ func (s *Sequence) extractModValue(modStr string) string {
 //	proformaStr := seq.ToProforma()
 //	fmt.Println(proformaStr) // "PEPT[Phospho]IDE"
 func (s *Sequence) ToProforma() string {
+	return s.ToProformaWithOptions(DefaultProformaOptions())
+}
+
+// ToProformaWithOptions converts the sequence to ProForma format with control over mass
+// formatting and modification order. ToProforma is equivalent to passing DefaultProformaOptions.
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("PEPTX[-10.0]IDE")
+//	fmt.Println(seq.ToProforma()) // "PEPTX[-10.0]IDE"
+//	fmt.Println(seq.ToProformaWithOptions(sequal.ProformaOptions{})) // "PEPTX[-10]IDE"
+//	precision := 2
+//	fmt.Println(seq.ToProformaWithOptions(sequal.ProformaOptions{MassPrecision: &precision})) // "PEPTX[-10.00]IDE"
+func (s *Sequence) ToProformaWithOptions(opts ProformaOptions) string {
 	if s.isMultiChain {
+		// Each chain writes its own global modifications; the first chain is the receiver.
+		// Ion names shared with the first chain are only written before it.
 		chains := make([]string, len(s.chains))
 		for i, chain := range s.chains {
-			chains[i] = s.chainToProforma(chain)
+			globalMods := chain.globalMods
+			if i == 0 {
+				globalMods = s.globalMods
+			} else {
+				unshared := *chain
+				if equalNames(chain.compoundIonName, s.compoundIonName) {
+					unshared.compoundIonName = nil
+				}
+				if equalNames(chain.peptidoformIonName, s.peptidoformIonName) {
+					unshared.peptidoformIonName = nil
+				}
+				chain = &unshared
+			}
+			chains[i] = chainToProformaWithGlobalMods(chain, globalMods, opts)
 		}
 		return strings.Join(chains, "//")
 	} else if s.isChimeric && len(s.peptidoforms) > 0 {
+		// Shared global modifications are written once, before the first peptidoform,
+		// which is the receiver itself
 		peptidoforms := make([]string, len(s.peptidoforms))
-		for i, pep := range s.peptidoforms {
-			peptidoforms[i] = s.chainToProforma(pep)
+		peptidoforms[0] = chainToProformaWithGlobalMods(s, s.globalMods, opts)
+		for i := 1; i < len(s.peptidoforms); i++ {
+			pep := s.peptidoforms[i]
+			peptidoforms[i] = chainToProformaWithGlobalMods(pep, excludeGlobalMods(pep.globalMods, s.globalMods), opts)
 		}
 		return strings.Join(peptidoforms, "+")
 	}
-	return s.chainToProforma(s)
+	return chainToProformaWithGlobalMods(s, s.globalMods, opts)
+}
+
+// equalNames reports whether two optional names are both set and equal
+func equalNames(a, b *string) bool {
+	return a != nil && b != nil && *a == *b
+}
+
+// isRangeMod reports whether mod is a range modification with valid bounds for a sequence
+// of the given length. Range modifications with invalid bounds are written per residue.
+func isRangeMod(mod *Modification, seqLength int) bool {
+	if !mod.IsInRange() || mod.GetRangeStart() == nil || mod.GetRangeEnd() == nil {
+		return false
+	}
+	start, end := *mod.GetRangeStart(), *mod.GetRangeEnd()
+	return start >= 0 && start <= end && end < seqLength
+}
+
+// rangeSpansAt returns the distinct [start, end] spans of the range modifications on the
+// residue at position that open there (outermost first) and close there (innermost first)
+func rangeSpansAt(mods []*Modification, position int, seqLength int) ([][2]int, [][2]int) {
+	var opening, closing [][2]int
+	seen := make(map[[2]int]bool)
+	for _, mod := range mods {
+		if !isRangeMod(mod, seqLength) {
+			continue
+		}
+		span := [2]int{*mod.GetRangeStart(), *mod.GetRangeEnd()}
+		if seen[span] {
+			continue
+		}
+		seen[span] = true
+		if span[0] == position {
+			opening = append(opening, span)
+		}
+		if span[1] == position {
+			closing = append(closing, span)
+		}
+	}
+	sort.SliceStable(opening, func(i, j int) bool { return opening[i][1] > opening[j][1] })
+	sort.SliceStable(closing, func(i, j int) bool { return closing[i][0] > closing[j][0] })
+	return opening, closing
 }
 
-// chainToProforma converts a chain to ProForma format
-func (s *Sequence) chainToProforma(chain *Sequence) string {
+// excludeGlobalMods returns the global modifications in mods that are not in excluded,
+// comparing them by their ProForma representation
+func excludeGlobalMods(mods []*GlobalModification, excluded []*GlobalModification) []*GlobalModification {
+	excludedSet := make(map[string]bool, len(excluded))
+	for _, mod := range excluded {
+		excludedSet[mod.ToProforma()] = true
+	}
+	result := make([]*GlobalModification, 0, len(mods))
+	for _, mod := range mods {
+		if !excludedSet[mod.ToProforma()] {
+			result = append(result, mod)
+		}
+	}
+	return result
+}
+
+// chainToProformaWithGlobalMods converts a chain to ProForma format, writing the given
+// global modifications in front of it
+func chainToProformaWithGlobalMods(chain *Sequence, globalMods []*GlobalModification, opts ProformaOptions) string {
 	result := ""
 
 	// Add named entities (ProForma 2.1 Section 8.2)
@@ -375,32 +771,47 @@ func (s *Sequence) chainToProforma(chain *Sequence) string {
 	}
 
 	// Add global modifications
-	for _, mod := range s.globalMods {
-		result += mod.ToProforma()
+	for _, mod := range globalMods {
+		result += mod.ToProformaWithOptions(opts)
 	}
 
-	// Handle unknown position modifications (-4)
-	if unknownMods, exists := chain.mods[-4]; exists {
+	// Handle unknown position modifications (-4) as one block followed by '?', grouping
+	// repeats of a modification into a "^count" written in order of first appearance
+	if unknownMods, exists := chain.mods[-4]; exists && len(unknownMods) > 0 {
 		unknownModsByValue := make(map[string]int)
-		for _, mod := range unknownMods {
-			modProforma := mod.ToProforma()
+		var modValues []string
+		for _, mod := range opts.orderMods(unknownMods) {
+			modProforma := mod.ToProformaWithOptions(opts)
+			if unknownModsByValue[modProforma] == 0 {
+				modValues = append(modValues, modProforma)
+			}
 			unknownModsByValue[modProforma]++
 		}
 
-		for modValue, count := range unknownModsByValue {
+		for _, modValue := range modValues {
+			count := unknownModsByValue[modValue]
 			if count > 1 {
-				result += fmt.Sprintf("[%s]^%d?", modValue, count)
+				result += fmt.Sprintf("[%s]^%d", modValue, count)
 			} else {
-				result += fmt.Sprintf("[%s]?", modValue)
+				result += fmt.Sprintf("[%s]", modValue)
 			}
 		}
+		result += "?"
+	}
+
+	// Handle unknown terminus modifications (-5)
+	if unknownTermMods, exists := chain.mods[-5]; exists && len(unknownTermMods) > 0 {
+		for _, mod := range opts.orderMods(unknownTermMods) {
+			result += fmt.Sprintf("[%s]", mod.ToProformaWithOptions(opts))
+		}
+		result += "?-"
 	}
 
-	// Handle labile modifications (-3)
+	// Handle labile modifications (-3) in labile number order
 	if labileMods, exists := chain.mods[-3]; exists {
-		for _, mod := range labileMods {
+		for _, mod := range sortLabileMods(labileMods) {
 			if mod.GetModType() == "labile" {
-				result += fmt.Sprintf("{%s}", mod.ToProforma())
+				result += fmt.Sprintf("{%s}", mod.ToProformaWithOptions(opts))
 			}
 		}
 	}
@@ -408,8 +819,8 @@ func (s *Sequence) chainToProforma(chain *Sequence) string {
 	// Handle N-terminal modifications (-1)
 	if nTermMods, exists := chain.mods[-1]; exists {
 		nModStr := ""
-		for _, mod := range nTermMods {
-			nModStr += fmt.Sprintf("[%s]", mod.ToProforma())
+		for _, mod := range opts.orderMods(nTermMods) {
+			nModStr += fmt.Sprintf("[%s]", mod.ToProformaWithOptions(opts))
 		}
 		if nModStr != "" {
 			result += nModStr + "-"
@@ -417,15 +828,24 @@ func (s *Sequence) chainToProforma(chain *Sequence) string {
 	}
 
 	// Process each amino acid in the sequence
-	for _, aa := range chain.seq {
+	for i, aa := range chain.seq {
+		// Open ranges starting at this residue, outermost first
+		opening, closing := rangeSpansAt(aa.mods, i, len(chain.seq))
+		for range opening {
+			result += "("
+		}
+
 		// Add amino acid value
 		result += aa.GetValue()
 
 		// Add modifications for this position
 		mods := aa.GetMods()
 		if len(mods) > 0 {
-			for _, mod := range mods {
-				modStr := mod.ToProforma()
+			for _, mod := range opts.orderMods(mods) {
+				if isRangeMod(mod, len(chain.seq)) {
+					continue
+				}
+				modStr := mod.ToProformaWithOptions(opts)
 				if mod.GetModType() == "ambiguous" && !mod.HasAmbiguity() {
 					// Use curly braces for ambiguous modifications without ambiguity groups
 					result += fmt.Sprintf("{%s}", modStr)
@@ -435,13 +855,23 @@ func (s *Sequence) chainToProforma(chain *Sequence) string {
 				}
 			}
 		}
+
+		// Close ranges ending at this residue, innermost first, followed by their modifications
+		for _, span := range closing {
+			result += ")"
+			for _, mod := range aa.mods {
+				if isRangeMod(mod, len(chain.seq)) && *mod.GetRangeStart() == span[0] && *mod.GetRangeEnd() == span[1] {
+					result += fmt.Sprintf("[%s]", mod.ToProformaWithOptions(opts))
+				}
+			}
+		}
 	}
 
 	// Handle C-terminal modifications (-2)
 	if cTermMods, exists := chain.mods[-2]; exists {
 		cModStr := ""
-		for _, mod := range cTermMods {
-			cModStr += fmt.Sprintf("[%s]", mod.ToProforma())
+		for _, mod := range opts.orderMods(cTermMods) {
+			cModStr += fmt.Sprintf("[%s]", mod.ToProformaWithOptions(opts))
 		}
 		if cModStr != "" {
 			result += "-" + cModStr
@@ -459,6 +889,19 @@ func (s *Sequence) chainToProforma(chain *Sequence) string {
 	return result
 }
 
+// sortLabileMods returns labile modifications ordered by ascending labile number.
+// The parser numbers labile modifications by order of appearance starting at 1, so parsed
+// sequences keep their input order. Modifications with the same number, such as those
+// created without one, keep their insertion order.
+func sortLabileMods(mods []*Modification) []*Modification {
+	sorted := make([]*Modification, len(mods))
+	copy(sorted, mods)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].GetLabileNumber() < sorted[j].GetLabileNumber()
+	})
+	return sorted
+}
+
 // ToStrippedString returns the sequence as a string without any modification annotations.
 //
 // Example:
@@ -473,6 +916,160 @@ func (s *Sequence) ToStrippedString() string {
 	return result
 }
 
+// StripModifications returns a new sequence with the same residues but no residue, terminal,
+// labile, unknown-position or global modifications, and no charge or ionic species.
+// Chains and peptidoforms are stripped as well; the original sequence is not changed.
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("<[+57.021]@C>[Acetyl]-PEPC[Phospho]IDE/2")
+//	fmt.Println(seq.StripModifications().ToProforma()) // "PEPCIDE"
+func (s *Sequence) StripModifications() *Sequence {
+	stripped := s.stripChain()
+
+	if s.isMultiChain {
+		stripped.isMultiChain = true
+		stripped.chains = make([]*Sequence, len(s.chains))
+		for i, chain := range s.chains {
+			if i == 0 {
+				stripped.chains[i] = stripped.firstChain()
+			} else {
+				stripped.chains[i] = chain.stripChain()
+			}
+		}
+	}
+
+	if len(s.peptidoforms) > 1 {
+		stripped.isChimeric = true
+		stripped.peptidoforms = make([]*Sequence, len(s.peptidoforms))
+		for i, pep := range s.peptidoforms {
+			if i == 0 {
+				stripped.peptidoforms[i] = stripped.firstPeptidoform()
+			} else {
+				stripped.peptidoforms[i] = pep.stripChain()
+				stripped.peptidoforms[i].isChimeric = true
+			}
+		}
+	}
+
+	return stripped
+}
+
+// Clone returns a deep copy of the sequence: residues, modifications, global modifications,
+// sequence ambiguities, chains and peptidoforms are all copied, so changing the clone never
+// affects the original. A modification shared by several residues, such as a range
+// modification, is copied once and stays shared in the clone.
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("PEPT[Phospho]IDE")
+//	clone := seq.Clone()
+//	clone.GetSeq()[3].RemoveModification("Phospho")
+//	fmt.Println(seq.ToProforma(), clone.ToProforma()) // "PEPT[Phospho]IDE PEPTIDE"
+func (s *Sequence) Clone() *Sequence {
+	return s.cloneWith(make(map[*Sequence]*Sequence), make(map[*Modification]*Modification))
+}
+
+// cloneWith deep-copies the sequence, reusing the copies already made of sequences and
+// modifications reachable from more than one place
+func (s *Sequence) cloneWith(sequences map[*Sequence]*Sequence, mods map[*Modification]*Modification) *Sequence {
+	if clone, exists := sequences[s]; exists {
+		return clone
+	}
+	clone := &Sequence{}
+	*clone = *s
+	clone.viewOf = nil
+	sequences[s] = clone
+
+	cloneMods := func(modList []*Modification) []*Modification {
+		if modList == nil {
+			return nil
+		}
+		copied := make([]*Modification, len(modList))
+		for i, mod := range modList {
+			if existing, exists := mods[mod]; exists {
+				copied[i] = existing
+				continue
+			}
+			copied[i] = DeepCopyModifications([]*Modification{mod})[0]
+			mods[mod] = copied[i]
+		}
+		return copied
+	}
+
+	clone.seq = make([]*AminoAcid, 0, len(s.seq))
+	for _, aa := range s.seq {
+		residue := aa.copyResidue()
+		residue.mods = cloneMods(aa.mods)
+		clone.seq = append(clone.seq, residue)
+	}
+
+	clone.mods = make(map[int][]*Modification, len(s.mods))
+	for pos, modList := range s.mods {
+		clone.mods[pos] = cloneMods(modList)
+	}
+
+	if s.globalMods != nil {
+		clone.globalMods = make([]*GlobalModification, len(s.globalMods))
+		for i, gm := range s.globalMods {
+			copied := *gm
+			copied.Modification = *gm.Modification.Clone()
+			copied.targetResidues = append([]string(nil), gm.targetResidues...)
+			clone.globalMods[i] = &copied
+		}
+	}
+
+	if s.sequenceAmbiguities != nil {
+		clone.sequenceAmbiguities = make([]*SequenceAmbiguity, len(s.sequenceAmbiguities))
+		for i, ambiguity := range s.sequenceAmbiguities {
+			clone.sequenceAmbiguities[i] = NewSequenceAmbiguity(ambiguity.Value, ambiguity.Position)
+		}
+	}
+
+	if s.charge != nil {
+		charge := *s.charge
+		clone.charge = &charge
+	}
+
+	if s.chains != nil {
+		clone.chains = make([]*Sequence, len(s.chains))
+		for i, chain := range s.chains {
+			if i == 0 && s.isMultiChain {
+				clone.chains[i] = clone.firstChain()
+			} else {
+				clone.chains[i] = chain.cloneWith(sequences, mods)
+			}
+		}
+	}
+	if s.peptidoforms != nil {
+		clone.peptidoforms = make([]*Sequence, len(s.peptidoforms))
+		for i, pep := range s.peptidoforms {
+			if i == 0 && len(s.peptidoforms) > 1 {
+				clone.peptidoforms[i] = clone.firstPeptidoform()
+			} else {
+				clone.peptidoforms[i] = pep.cloneWith(sequences, mods)
+			}
+		}
+	}
+
+	return clone
+}
+
+// stripChain copies the residues and names of a single sequence without its modifications
+func (s *Sequence) stripChain() *Sequence {
+	ambiguities := append([]*SequenceAmbiguity{}, s.sequenceAmbiguities...)
+	stripped := NewSequence("", nil, false, "right", []*Sequence{}, []*GlobalModification{},
+		ambiguities, nil, nil, s.peptidoformName, s.peptidoformIonName, s.compoundIonName)
+
+	for _, aa := range s.seq {
+		stripped.seq = append(stripped.seq, aa.copyResidue())
+	}
+	stripped.seqLength = len(stripped.seq)
+	stripped.peptidoforms = []*Sequence{stripped}
+
+	return stripped
+}
+
 // GetLength returns the length of the sequence
 func (s *Sequence) GetLength() int {
 	return s.seqLength
@@ -487,6 +1084,124 @@ func (s *Sequence) String() string {
 	return result
 }
 
+// Summary returns a multi-line human-readable report of the sequence: the stripped sequence,
+// its length, the charge and ionic species, the number of modifications by type, the global
+// modifications and the named entities. Lines for values that are not set are left out, and
+// modification types are listed alphabetically. Chains are joined with "//" and chimeric
+// peptidoforms with "+". The charges, global modifications and names of the chains and
+// chimeric peptidoforms are listed in order, each name once.
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("<[Carbamidomethyl]@C>[Acetyl]-PEPC[Phospho]IDE/2")
+//	fmt.Print(seq.Summary())
+//	// Sequence: PEPCIDE
+//	// Length: 7
+//	// Charge: 2
+//	// Modifications: 2 (static: 1, terminal: 1)
+//	// Global modifications: <[Carbamidomethyl]@C>
+func (s *Sequence) Summary() string {
+	parts := []*Sequence{s}
+	separator := ""
+	if s.isMultiChain && len(s.chains) > 0 {
+		parts, separator = s.chains, "//"
+	} else if s.isChimeric && len(s.peptidoforms) > 0 {
+		parts, separator = s.peptidoforms, "+"
+	}
+
+	stripped := make([]string, len(parts))
+	var charges, species []string
+	length := 0
+	counts := make(map[string]int)
+	total := 0
+	for i, part := range parts {
+		stripped[i] = part.ToStrippedString()
+		length += len(part.seq)
+		if part.charge != nil {
+			charges = append(charges, strconv.Itoa(*part.charge))
+		}
+		if part.ionicSpecies != nil {
+			species = append(species, *part.ionicSpecies)
+		}
+		counted := make(map[*Modification]bool)
+		for _, loc := range part.GetModificationsByType("") {
+			if counted[loc.Modification] {
+				continue
+			}
+			counted[loc.Modification] = true
+			counts[loc.Modification.GetModType()]++
+			total++
+		}
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Sequence: %s\n", strings.Join(stripped, separator))
+	fmt.Fprintf(&sb, "Length: %d\n", length)
+	if len(charges) > 0 {
+		fmt.Fprintf(&sb, "Charge: %s\n", strings.Join(charges, ", "))
+	}
+	if len(species) > 0 {
+		fmt.Fprintf(&sb, "Ionic species: %s\n", strings.Join(species, ", "))
+	}
+
+	fmt.Fprintf(&sb, "Modifications: %d", total)
+	if total > 0 {
+		types := make([]string, 0, len(counts))
+		for modType := range counts {
+			types = append(types, modType)
+		}
+		sort.Strings(types)
+		for i, modType := range types {
+			if i == 0 {
+				sb.WriteString(" (")
+			} else {
+				sb.WriteString(", ")
+			}
+			fmt.Fprintf(&sb, "%s: %d", modType, counts[modType])
+		}
+		sb.WriteString(")")
+	}
+	sb.WriteString("\n")
+
+	var globalMods []string
+	seenGlobal := make(map[*GlobalModification]bool)
+	for _, part := range parts {
+		for _, gm := range part.globalMods {
+			if !seenGlobal[gm] {
+				seenGlobal[gm] = true
+				globalMods = append(globalMods, gm.ToProforma())
+			}
+		}
+	}
+	if len(globalMods) > 0 {
+		fmt.Fprintf(&sb, "Global modifications: %s\n", strings.Join(globalMods, ""))
+	}
+
+	// Chains share the names written before the first chain, so each name is listed once
+	names := func(get func(*Sequence) *string) string {
+		var values []string
+		seen := make(map[*string]bool)
+		for _, part := range append([]*Sequence{s}, parts...) {
+			if name := get(part); name != nil && !seen[name] {
+				seen[name] = true
+				values = append(values, *name)
+			}
+		}
+		return strings.Join(values, ", ")
+	}
+	if name := names(func(p *Sequence) *string { return p.peptidoformName }); name != "" {
+		fmt.Fprintf(&sb, "Peptidoform name: %s\n", name)
+	}
+	if name := names(func(p *Sequence) *string { return p.peptidoformIonName }); name != "" {
+		fmt.Fprintf(&sb, "Peptidoform ion name: %s\n", name)
+	}
+	if name := names(func(p *Sequence) *string { return p.compoundIonName }); name != "" {
+		fmt.Fprintf(&sb, "Compound ion name: %s\n", name)
+	}
+
+	return sb.String()
+}
+
 // GetItem returns an amino acid at the specified position or a slice
 func (s *Sequence) GetItem(key interface{}) interface{} {
 	switch k := key.(type) {
@@ -510,7 +1225,9 @@ func (s *Sequence) GetItem(key interface{}) interface{} {
 	return nil
 }
 
-// Equal checks if two sequences are equal
+// Equal checks if two sequences are equal.
+// Modifications on the same residue are compared in order, so "S[Phospho][Acetyl]" and
+// "S[Acetyl][Phospho]" are not equal. Use EqualIgnoringModOrder to ignore that order.
 func (s *Sequence) Equal(other *Sequence) bool {
 	if other == nil {
 		return false
@@ -526,25 +1243,203 @@ func (s *Sequence) Equal(other *Sequence) bool {
 	return true
 }
 
-// AddModifications adds modifications to residues at specified positions
-func (s *Sequence) AddModifications(modDict map[int][]*Modification) {
-	for _, aa := range s.seq {
-		if aa.GetPosition() != nil {
-			pos := *aa.GetPosition()
-			if mods, exists := modDict[pos]; exists {
-				for _, mod := range mods {
-					aa.AddModification(mod)
-				}
-			}
+// EqualIgnoringModOrder checks if two sequences are equal, comparing the modifications
+// at each residue as a multiset rather than an ordered list. Residues themselves are still
+// compared position by position, exactly as in Equal.
+//
+// Example:
+//
+//	a, _ := sequal.FromProforma("PEPS[Phospho][Acetyl]")
+//	b, _ := sequal.FromProforma("PEPS[Acetyl][Phospho]")
+//	fmt.Println(a.Equal(b))                 // false
+//	fmt.Println(a.EqualIgnoringModOrder(b)) // true
+func (s *Sequence) EqualIgnoringModOrder(other *Sequence) bool {
+	if other == nil {
+		return false
+	}
+	if s.seqLength != other.seqLength {
+		return false
+	}
+	for i := 0; i < s.seqLength; i++ {
+		if !s.seq[i].EqualIgnoringModOrder(other.seq[i]) {
+			return false
 		}
 	}
+	return true
 }
 
-// FindWithRegex finds positions in the sequence that match a given regex motif
-func (s *Sequence) FindWithRegex(motif string, ignore []bool) ([][]int, error) {
-	pattern, err := regexp.Compile(motif)
-	if err != nil {
-		return nil, err
+// IsobaricClass is a set of residues treated as interchangeable by EqualIsobaric, written as
+// the one-letter codes of its residues
+type IsobaricClass string
+
+// Constants for the residue equivalence classes used by EqualIsobaric
+const (
+	// IsobaricLeucine groups isoleucine and leucine, which have identical masses
+	IsobaricLeucine IsobaricClass = "IL"
+	// IsobaricLysineGlutamine groups lysine and glutamine, which differ by 0.036 Da and
+	// can only be told apart at high resolution
+	IsobaricLysineGlutamine IsobaricClass = "KQ"
+)
+
+// EqualIsobaric checks if two sequences are equal when residues that cannot be told apart by
+// mass are treated as the same residue. I and L are always equivalent; further classes such as
+// IsobaricLysineGlutamine can be passed for low-resolution data. Apart from that, residues and
+// their modifications are compared position by position exactly as in Equal, so a
+// modification must sit at the same position in both sequences.
+//
+// Example:
+//
+//	a, _ := sequal.FromProforma("PEPTIDEK")
+//	b, _ := sequal.FromProforma("PEPTLDEQ")
+//	fmt.Println(sequal.EqualIsobaric(a, b))                                  // false
+//	fmt.Println(sequal.EqualIsobaric(a, b, sequal.IsobaricLysineGlutamine)) // true
+func EqualIsobaric(a, b *Sequence, classes ...IsobaricClass) bool {
+	if a == nil || b == nil {
+		return false
+	}
+	if a.seqLength != b.seqLength {
+		return false
+	}
+
+	classes = append([]IsobaricClass{IsobaricLeucine}, classes...)
+	for i := 0; i < a.seqLength; i++ {
+		residueA, residueB := a.seq[i], b.seq[i]
+		if residueA.GetValue() == residueB.GetValue() {
+			if !residueA.Equal(residueB) {
+				return false
+			}
+			continue
+		}
+		if !isobaricResidues(residueA.GetValue(), residueB.GetValue(), classes) {
+			return false
+		}
+		if !equalPtr(residueA.GetPosition(), residueB.GetPosition()) || len(residueA.mods) != len(residueB.mods) {
+			return false
+		}
+		for j, mod := range residueA.mods {
+			if !mod.Equal(*residueB.mods[j]) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// isobaricResidues reports whether two different residues belong to one of the classes
+func isobaricResidues(a, b string, classes []IsobaricClass) bool {
+	if len(a) != 1 || len(b) != 1 {
+		return false
+	}
+	for _, class := range classes {
+		if strings.Contains(string(class), a) && strings.Contains(string(class), b) {
+			return true
+		}
+	}
+	return false
+}
+
+// MapToProtein returns the zero-based offsets of every occurrence, overlapping ones included,
+// of the stripped sequence of peptide in the parent protein sequence, for placing the peptide
+// and its modifications on the protein. Residues are compared exactly unless equivalence
+// classes are passed, so IsobaricLeucine matches an I of the peptide with an L of the protein
+// and the other way round. An empty slice is returned when the peptide does not occur in the
+// protein or is empty. For multi-chain and chimeric sequences the first chain or peptidoform
+// is mapped.
+//
+// Example:
+//
+//	peptide, _ := sequal.FromProforma("PEPT[Phospho]IDE")
+//	fmt.Println(sequal.MapToProtein(peptide, "MKPEPTLDEK"))                         // []
+//	fmt.Println(sequal.MapToProtein(peptide, "MKPEPTLDEK", sequal.IsobaricLeucine)) // [2]
+func MapToProtein(peptide *Sequence, protein string, classes ...IsobaricClass) []int {
+	offsets := make([]int, 0)
+	if peptide == nil || len(peptide.seq) == 0 {
+		return offsets
+	}
+
+	residues := make([]string, len(peptide.seq))
+	for i, aa := range peptide.seq {
+		residues[i] = aa.GetValue()
+	}
+	for start := 0; start+len(residues) <= len(protein); start++ {
+		matched := true
+		for i, residue := range residues {
+			proteinResidue := protein[start+i : start+i+1]
+			if residue != proteinResidue && !isobaricResidues(residue, proteinResidue, classes) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			offsets = append(offsets, start)
+		}
+	}
+	return offsets
+}
+
+// AddModifications adds modifications to residues at specified positions
+func (s *Sequence) AddModifications(modDict map[int][]*Modification) {
+	for _, aa := range s.seq {
+		if aa.GetPosition() != nil {
+			pos := *aa.GetPosition()
+			if mods, exists := modDict[pos]; exists {
+				for _, mod := range mods {
+					aa.AddModification(mod)
+				}
+			}
+		}
+	}
+}
+
+// RemoveModificationAt removes the first modification with the given value at pos and reports
+// whether one was removed. Positions 0 and above address residues; negative positions address
+// the N-terminal (-1), C-terminal (-2), labile (-3), unknown-position (-4) and unknown-terminus
+// (-5) slots of the modifications map. A range modification is removed from every residue of
+// its range.
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("[Acetyl]-PEPS[Phospho]IDE")
+//	seq.RemoveModificationAt(3, "Phospho")
+//	seq.RemoveModificationAt(-1, "Acetyl")
+//	fmt.Println(seq.ToProforma()) // "PEPSIDE"
+func (s *Sequence) RemoveModificationAt(pos int, value string) bool {
+	if pos >= 0 {
+		if pos >= len(s.seq) {
+			return false
+		}
+		for _, mod := range s.seq[pos].mods {
+			if mod.GetValue() != value {
+				continue
+			}
+			if isRangeMod(mod, len(s.seq)) {
+				for i := *mod.rangeStart; i <= *mod.rangeEnd; i++ {
+					s.seq[i].RemoveModification(mod)
+				}
+				return true
+			}
+			return s.seq[pos].RemoveModification(mod)
+		}
+		return false
+	}
+
+	for i, mod := range s.mods[pos] {
+		if mod.GetValue() == value {
+			s.mods[pos] = append(s.mods[pos][:i], s.mods[pos][i+1:]...)
+			if len(s.mods[pos]) == 0 {
+				delete(s.mods, pos)
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// FindWithRegex finds positions in the sequence that match a given regex motif
+func (s *Sequence) FindWithRegex(motif string, ignore []bool) ([][]int, error) {
+	pattern, err := regexp.Compile(motif)
+	if err != nil {
+		return nil, err
 	}
 
 	var seqStr string
@@ -580,6 +1475,69 @@ func (s *Sequence) FindWithRegex(motif string, ignore []bool) ([][]int, error) {
 	return results, nil
 }
 
+// nGlycosylationSequon matches the N-X-S/T motif of N-linked glycosylation, where X is any
+// residue but proline
+const nGlycosylationSequon = "N[^P][ST]"
+
+// FindNGlycosylationSequons returns the positions of the asparagines that start an N-X-S/T
+// sequon (X != P) in ascending order. Overlapping sequons such as the two in "NNST" are both
+// reported.
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("PENGTNPSNNST")
+//	fmt.Println(seq.FindNGlycosylationSequons()) // [2 8 9]
+func (s *Sequence) FindNGlycosylationSequons() []int {
+	matches, err := s.FindWithRegex(nGlycosylationSequon, nil)
+	if err != nil {
+		return nil
+	}
+
+	positions := make([]int, 0, len(matches))
+	for _, match := range matches {
+		start := match[0]
+		positions = append(positions, start)
+		// Matches do not overlap, so a sequon starting on the X of this one is checked here;
+		// the next residue is the S or T of this match and never a proline
+		if end := start + 3; end < len(s.seq) && s.seq[start+1].GetValue() == "N" {
+			if next := s.seq[end].GetValue(); next == "S" || next == "T" {
+				positions = append(positions, start+1)
+			}
+		}
+	}
+	return positions
+}
+
+// FindNGlycansOffSequon returns the positions of asparagines carrying a glycan modification,
+// written with the "Glycan:" or "GNO:" prefix, that do not start an N-glycosylation sequon
+// (see FindNGlycosylationSequons). Glycans on other residues are O-linked or C-linked and are
+// not reported.
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("PEN[Glycan:HexNAc2Hex5]GTN[Glycan:HexNAc]PS")
+//	fmt.Println(seq.FindNGlycansOffSequon()) // [5]
+func (s *Sequence) FindNGlycansOffSequon() []int {
+	sequons := make(map[int]bool)
+	for _, position := range s.FindNGlycosylationSequons() {
+		sequons[position] = true
+	}
+
+	positions := make([]int, 0)
+	for i, aa := range s.seq {
+		if aa.GetValue() != "N" || sequons[i] {
+			continue
+		}
+		for _, mod := range aa.mods {
+			if mod.isGlycan() {
+				positions = append(positions, i)
+				break
+			}
+		}
+	}
+	return positions
+}
+
 // Gaps identifies gaps in the sequence
 func (s *Sequence) Gaps() []bool {
 	gaps := make([]bool, len(s.seq))
@@ -632,22 +1590,398 @@ func (s *Sequence) GetSeq() []*AminoAcid {
 	return s.seq
 }
 
+// FirstResidue returns the N-terminal residue, or nil for an empty sequence
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("[Acetyl]-PEPTIDEK")
+//	fmt.Println(seq.FirstResidue().GetValue()) // "P"
+func (s *Sequence) FirstResidue() *AminoAcid {
+	if len(s.seq) == 0 {
+		return nil
+	}
+	return s.seq[0]
+}
+
+// LastResidue returns the C-terminal residue, or nil for an empty sequence
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("PEPTIDEK")
+//	fmt.Println(seq.LastResidue().GetValue()) // "K"
+func (s *Sequence) LastResidue() *AminoAcid {
+	if len(s.seq) == 0 {
+		return nil
+	}
+	return s.seq[len(s.seq)-1]
+}
+
+// ForEachResidue calls fn for each residue in order with its index, the residue and its
+// modifications, stopping early when fn returns false. The modifications slice is the
+// residue's own and is not copied, so it must not be modified or retained; modifying aa
+// through its methods (e.g. AddModification) mutates the sequence.
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("PEPS[Phospho]TIDE")
+//	seq.ForEachResidue(func(index int, aa *sequal.AminoAcid, mods []*sequal.Modification) bool {
+//		if len(mods) > 0 {
+//			fmt.Println(index, aa.GetValue(), mods[0].GetValue()) // 3 S Phospho
+//			return false
+//		}
+//		return true
+//	})
+func (s *Sequence) ForEachResidue(fn func(index int, aa *AminoAcid, mods []*Modification) bool) {
+	for i, aa := range s.seq {
+		if !fn(i, aa, aa.mods) {
+			return
+		}
+	}
+}
+
+// InsertResidue inserts the residue aa with the given modifications before index pos, or at
+// the end when pos equals the sequence length. Downstream residues and range modifications
+// shift by one; inserting inside a range modification extends the range over the new residue.
+// An error is returned for an out-of-range position or an unknown amino acid.
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("PEPS[Phospho]IDE")
+//	_ = seq.InsertResidue(3, "T", nil)
+//	fmt.Println(seq.ToProforma()) // "PEPTS[Phospho]IDE"
+func (s *Sequence) InsertResidue(pos int, aa string, mods []*Modification) error {
+	if pos < 0 || pos > len(s.seq) {
+		return fmt.Errorf("insert position %d out of range for sequence of length %d", pos, len(s.seq))
+	}
+	residue, err := NewAminoAcid(aa, &pos, nil)
+	if err != nil {
+		return err
+	}
+	for _, mod := range mods {
+		residue.AddModification(mod)
+	}
+
+	shifted := make(map[*Modification]bool)
+	for _, existing := range s.seq {
+		for _, mod := range existing.mods {
+			if shifted[mod] || !isRangeMod(mod, len(s.seq)) {
+				continue
+			}
+			shifted[mod] = true
+			start, end := *mod.rangeStart, *mod.rangeEnd
+			if pos <= start {
+				start++
+				end++
+			} else if pos <= end {
+				end++
+				residue.AddModification(mod)
+			}
+			mod.rangeStart, mod.rangeEnd = &start, &end
+		}
+	}
+
+	s.seq = append(s.seq, nil)
+	copy(s.seq[pos+1:], s.seq[pos:])
+	s.seq[pos] = residue
+	s.reindexResidues()
+	return nil
+}
+
+// DeleteResidue removes the residue at index pos together with its modifications.
+// Downstream residues and range modifications shift back by one, and a range modification
+// only covering the deleted residue is removed. An error is returned for an out-of-range position.
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("PEPTS[Phospho]IDE")
+//	_ = seq.DeleteResidue(3)
+//	fmt.Println(seq.ToProforma()) // "PEPS[Phospho]IDE"
+func (s *Sequence) DeleteResidue(pos int) error {
+	if pos < 0 || pos >= len(s.seq) {
+		return fmt.Errorf("delete position %d out of range for sequence of length %d", pos, len(s.seq))
+	}
+
+	shifted := make(map[*Modification]bool)
+	for _, existing := range s.seq {
+		for _, mod := range existing.mods {
+			if shifted[mod] || !isRangeMod(mod, len(s.seq)) {
+				continue
+			}
+			shifted[mod] = true
+			start, end := *mod.rangeStart, *mod.rangeEnd
+			if pos < start {
+				start--
+				end--
+			} else if pos <= end {
+				end--
+			}
+			mod.rangeStart, mod.rangeEnd = &start, &end
+		}
+	}
+
+	s.seq = append(s.seq[:pos], s.seq[pos+1:]...)
+	s.reindexResidues()
+	return nil
+}
+
+// ReplaceResidue changes the residue at index pos to the amino acid newAA, as in a point
+// mutation, updating its mass from AAMass. The modifications of the residue, including range
+// modifications covering it, are kept. An error is returned for an out-of-range position or
+// an unknown amino acid.
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("PEPS[Phospho]IDE")
+//	_ = seq.ReplaceResidue(3, "T")
+//	fmt.Println(seq.ToProforma()) // "PEPT[Phospho]IDE"
+func (s *Sequence) ReplaceResidue(pos int, newAA string) error {
+	if pos < 0 || pos >= len(s.seq) {
+		return fmt.Errorf("replace position %d out of range for sequence of length %d", pos, len(s.seq))
+	}
+	residue, err := NewAminoAcid(newAA, &pos, nil)
+	if err != nil {
+		return err
+	}
+	residue.mods = s.seq[pos].mods
+
+	s.seq[pos] = residue
+	s.reindexResidues()
+	return nil
+}
+
+// reindexResidues sets each residue's position to its index and updates the sequence length
+func (s *Sequence) reindexResidues() {
+	for i, aa := range s.seq {
+		position := i
+		aa.SetPosition(&position)
+	}
+	s.seqLength = len(s.seq)
+	for _, linked := range s.residueViews() {
+		linked.seq = s.seq
+		linked.seqLength = s.seqLength
+	}
+}
+
+// residueViews returns the sequences sharing the residues of s: the sequence it is the first
+// chain or peptidoform view of, its own first chain and peptidoform views, and theirs
+func (s *Sequence) residueViews() []*Sequence {
+	var views []*Sequence
+	visited := map[*Sequence]bool{s: true}
+	var visit func(seq *Sequence)
+	visit = func(seq *Sequence) {
+		if seq == nil || visited[seq] {
+			return
+		}
+		visited[seq] = true
+		views = append(views, seq)
+		visit(seq.viewOf)
+		if seq.isMultiChain && len(seq.chains) > 0 {
+			visit(seq.chains[0])
+		}
+		if seq.isChimeric && len(seq.peptidoforms) > 1 {
+			visit(seq.peptidoforms[0])
+		}
+	}
+	visit(s.viewOf)
+	if s.isMultiChain && len(s.chains) > 0 {
+		visit(s.chains[0])
+	}
+	if s.isChimeric && len(s.peptidoforms) > 1 {
+		visit(s.peptidoforms[0])
+	}
+	return views
+}
+
 // GetMods returns the modifications map
 func (s *Sequence) GetMods() map[int][]*Modification {
 	return s.mods
 }
 
+// GetModificationAt returns the modifications at a position without copying them: the
+// residue modifications for an index into the sequence, or for the negative positions of
+// the modifications map the N-terminal (-1), C-terminal (-2), labile (-3), unknown
+// position (-4) and unknown terminal (-5) modifications. It returns nil for a position
+// out of range. The returned slice is shared with the sequence and must not be modified.
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("[Acetyl]-PEPS[Phospho]TIDE")
+//	fmt.Println(seq.GetModificationAt(3)[0].GetValue())  // "Phospho"
+//	fmt.Println(seq.GetModificationAt(-1)[0].GetValue()) // "Acetyl"
+func (s *Sequence) GetModificationAt(pos int) []*Modification {
+	switch {
+	case pos >= 0 && pos < len(s.seq):
+		return s.seq[pos].mods
+	case pos >= -5 && pos < 0:
+		return s.mods[pos]
+	}
+	return nil
+}
+
+// DeduplicateModifications removes every modification that repeats an earlier one on the
+// same residue or terminus, comparing them with Modification.Hash, and returns how many
+// were removed. Labile and unknown position modifications are kept, since repeating them
+// adds copies as a "^n" multiplier does. Parsing never removes duplicates; call this to
+// clean up input from tools that write the same modification twice.
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("PEPS[Phospho][Phospho]TIDE")
+//	fmt.Println(seq.DeduplicateModifications(), seq.ToProforma()) // 1 PEPS[Phospho]TIDE
+func (s *Sequence) DeduplicateModifications() int {
+	removed := 0
+	deduplicate := func(mods []*Modification) []*Modification {
+		seen := make(map[string]bool, len(mods))
+		kept := make([]*Modification, 0, len(mods))
+		for _, mod := range mods {
+			hash, err := mod.Hash()
+			if err == nil && seen[hash] {
+				removed++
+				continue
+			}
+			seen[hash] = true
+			kept = append(kept, mod)
+		}
+		return kept
+	}
+
+	parts := []*Sequence{s}
+	if s.isMultiChain && len(s.chains) > 1 {
+		parts = append(parts, s.chains[1:]...)
+	} else if s.isChimeric && len(s.peptidoforms) > 1 {
+		parts = append(parts, s.peptidoforms[1:]...)
+	}
+	for _, part := range parts {
+		for _, aa := range part.seq {
+			aa.mods = deduplicate(aa.mods)
+		}
+		for _, pos := range []int{-1, -2, -5} {
+			if mods, ok := part.mods[pos]; ok {
+				part.mods[pos] = deduplicate(mods)
+			}
+		}
+	}
+
+	return removed
+}
+
+// LongSourceNames maps the one-letter controlled vocabulary prefixes of ProForma to their
+// long names, the default form written by CanonicalizeSources
+var LongSourceNames = map[string]string{
+	"U": "Unimod",
+	"M": "PSI-MOD",
+	"R": "RESID",
+	"X": "XL-MOD",
+	"G": "GNO",
+}
+
+// ShortSourceNames maps the long controlled vocabulary names to their one-letter prefixes,
+// for use with CanonicalizeSourcesWith
+var ShortSourceNames = map[string]string{
+	"Unimod":  "U",
+	"PSI-MOD": "M",
+	"RESID":   "R",
+	"XL-MOD":  "X",
+	"GNO":     "G",
+}
+
+// CanonicalizeSources rewrites the source prefixes of all modifications to their long names
+// (see LongSourceNames), so "U:Phospho" is written as "Unimod:Phospho", reducing differences
+// between tools that use another prefix style. It is CanonicalizeSourcesWith(LongSourceNames).
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("PEPS[U:Phospho|M:MOD:00046]TIDE")
+//	seq.CanonicalizeSources()
+//	fmt.Println(seq.ToProforma()) // "PEPS[Unimod:Phospho|PSI-MOD:MOD:00046]TIDE"
+func (s *Sequence) CanonicalizeSources() {
+	s.CanonicalizeSourcesWith(LongSourceNames)
+}
+
+// CanonicalizeSourcesWith rewrites the source prefixes of all modifications, including global
+// modifications and those of every chain or peptidoform, using names, which maps each source
+// as written to its canonical form. Sources missing from names, such as "Formula" and "Obs",
+// and the accession forms "UNIMOD:21", "MOD:00046" and "XLMOD:02001" are left unchanged
+// unless listed.
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("PEPS[Unimod:Phospho]TIDE")
+//	seq.CanonicalizeSourcesWith(sequal.ShortSourceNames)
+//	fmt.Println(seq.ToProforma()) // "PEPS[U:Phospho]TIDE"
+func (s *Sequence) CanonicalizeSourcesWith(names map[string]string) {
+	parts := []*Sequence{s}
+	if s.isMultiChain && len(s.chains) > 1 {
+		parts = append(parts, s.chains[1:]...)
+	} else if s.isChimeric && len(s.peptidoforms) > 1 {
+		parts = append(parts, s.peptidoforms[1:]...)
+	}
+	for _, part := range parts {
+		for _, aa := range part.seq {
+			for _, mod := range aa.mods {
+				mod.canonicalizeSources(names)
+			}
+		}
+		for _, mods := range part.mods {
+			for _, mod := range mods {
+				mod.canonicalizeSources(names)
+			}
+		}
+		for _, gm := range part.globalMods {
+			gm.canonicalizeSources(names)
+		}
+	}
+}
+
 // GetGlobalMods returns the global modifications
 func (s *Sequence) GetGlobalMods() []*GlobalModification {
 	return s.globalMods
 }
 
+// GetGlobalModSites materializes the fixed global modifications of the sequence and returns
+// them keyed by the residue position they apply to. Position constraints and limits are
+// honored as described in GlobalModification.FindSites.
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("<[Oxidation|Position:M|Limit:1]@M>MPMM")
+//	sites := seq.GetGlobalModSites()
+//	fmt.Println(len(sites)) // 1
+//	fmt.Println(sites[0][0].GetValue()) // "Oxidation"
+func (s *Sequence) GetGlobalModSites() map[int][]*GlobalModification {
+	sites := make(map[int][]*GlobalModification)
+	stripped := s.ToStrippedString()
+
+	for _, gm := range s.globalMods {
+		for _, pos := range gm.FindSites(stripped) {
+			sites[pos] = append(sites[pos], gm)
+		}
+	}
+
+	return sites
+}
+
 // GetSequenceAmbiguities returns the sequence ambiguities
 func (s *Sequence) GetSequenceAmbiguities() []*SequenceAmbiguity {
 	return s.sequenceAmbiguities
 }
 
-// GetCharge returns the charge state
+// GetCharge returns the charge state as written. See GetEffectiveCharge for a charge derived
+// from the ionic species when none is given.
+//
+// In a chimeric sequence each peptidoform ion has its own charge, as in ProForma 2.0:
+// a charge binds to the peptidoform it follows, never to the whole mixture. In
+// "PEPTIDE+ANOTHER/2" only ANOTHER carries charge 2, and since FromProforma returns the first
+// peptidoform, GetCharge returns nil; use GetPeptidoforms to read each charge.
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("PEPTIDE/2+ANOTHER/3")
+//	for _, pep := range seq.GetPeptidoforms() {
+//		fmt.Println(pep.ToStrippedString(), *pep.GetCharge())
+//	}
+//	// PEPTIDE 2
+//	// ANOTHER 3
 func (s *Sequence) GetCharge() *int {
 	return s.charge
 }
@@ -667,16 +2001,139 @@ func (s *Sequence) SetIonicSpecies(species *string) {
 	s.ionicSpecies = species
 }
 
+// WithCharge returns a copy of the sequence with the charge state set, leaving the receiver
+// unchanged. The copy is shallow: residues, modifications, global modifications and the
+// other chains or peptidoforms are shared with the receiver, so use Clone first when they
+// will be changed.
+//
+// Example:
+//
+//	base, _ := sequal.FromProforma("PEPT[Phospho]IDE")
+//	fmt.Println(base.WithCharge(2).ToProforma(), base.ToProforma()) // "PEPT[Phospho]IDE/2 PEPT[Phospho]IDE"
+func (s *Sequence) WithCharge(charge int) *Sequence {
+	return s.with(func(clone *Sequence) {
+		clone.charge = &charge
+	})
+}
+
+// WithIonicSpecies returns a shallow copy of the sequence with the ionic species set, such
+// as "+2Na+,+H+", leaving the receiver unchanged. As with WithCharge, residues and
+// modifications are shared with the receiver.
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("PEPTIDE/3")
+//	fmt.Println(seq.WithIonicSpecies("+2Na+,+H+").ToProforma()) // "PEPTIDE/3[+2Na+,+H+]"
+func (s *Sequence) WithIonicSpecies(species string) *Sequence {
+	return s.with(func(clone *Sequence) {
+		clone.ionicSpecies = &species
+	})
+}
+
+// with returns a shallow copy of the sequence changed by set. The first chain or
+// peptidoform views and the self-reference of a single peptidoform are rebuilt so they
+// refer to the copy.
+func (s *Sequence) with(set func(clone *Sequence)) *Sequence {
+	clone := *s
+	clone.viewOf = nil
+	set(&clone)
+	if len(s.peptidoforms) == 1 && s.peptidoforms[0] == s {
+		clone.peptidoforms = []*Sequence{&clone}
+	} else if s.isChimeric && len(s.peptidoforms) > 1 {
+		clone.peptidoforms = append([]*Sequence{clone.firstPeptidoform()}, s.peptidoforms[1:]...)
+	}
+	if s.isMultiChain && len(s.chains) > 0 {
+		clone.chains = append([]*Sequence{clone.firstChain()}, s.chains[1:]...)
+	}
+	return &clone
+}
+
 // IsChimeric returns whether the sequence is chimeric
 func (s *Sequence) IsChimeric() bool {
 	return s.isChimeric
 }
 
-// GetPeptidoforms returns the peptidoforms for chimeric sequences
+// GetPeptidoforms returns the peptidoforms for chimeric sequences. Each peptidoform is
+// serialized on its own with its own charge; the first one shares its residues and
+// modifications with the receiver, which holds the whole mixture.
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("PEPTIDE/2+ANOTHER/3")
+//	fmt.Println(seq.GetPeptidoforms()[0].ToProforma()) // "PEPTIDE/2"
+//	fmt.Println(seq.GetPeptidoforms()[1].ToProforma()) // "ANOTHER/3"
 func (s *Sequence) GetPeptidoforms() []*Sequence {
 	return s.peptidoforms
 }
 
+// IsChargeVariantSet reports whether a chimeric sequence lists one peptidoform at several
+// charge states, as some deconvolution tools write "PEPTIDE/2+PEPTIDE/3", rather than
+// different peptidoforms. It is true when every peptidoform has the same residues and
+// modifications and no two share both charge and ionic species. Sequences that are not
+// chimeric return false.
+//
+// Example:
+//
+//	ladder, _ := sequal.FromProforma("PEPTIDE/2+PEPTIDE/3")
+//	mixture, _ := sequal.FromProforma("PEPTIDE/2+PEPT[Phospho]IDE/3")
+//	fmt.Println(ladder.IsChargeVariantSet(), mixture.IsChargeVariantSet()) // true false
+func (s *Sequence) IsChargeVariantSet() bool {
+	if !s.isChimeric || len(s.peptidoforms) < 2 {
+		return false
+	}
+
+	var base string
+	chargeStates := make(map[string]bool)
+	for i, peptidoform := range s.peptidoforms {
+		if peptidoform.charge == nil {
+			return false
+		}
+		uncharged := peptidoform.with(func(clone *Sequence) {
+			clone.charge = nil
+			clone.ionicSpecies = nil
+		}).ToProforma()
+		if i == 0 {
+			base = uncharged
+		} else if uncharged != base {
+			return false
+		}
+
+		state := strconv.Itoa(*peptidoform.charge)
+		if peptidoform.ionicSpecies != nil {
+			state += "[" + *peptidoform.ionicSpecies + "]"
+		}
+		if chargeStates[state] {
+			return false
+		}
+		chargeStates[state] = true
+	}
+	return true
+}
+
+// GetChargeStates returns the charges of the peptidoforms of a chimeric sequence in order,
+// or the charge of any other sequence, skipping peptidoforms without a charge. It returns an
+// empty slice when no charge is set.
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("PEPTIDE/2+PEPTIDE/3")
+//	fmt.Println(seq.GetChargeStates()) // [2 3]
+func (s *Sequence) GetChargeStates() []int {
+	charges := make([]int, 0)
+	if !s.isChimeric || len(s.peptidoforms) < 2 {
+		if charge := s.GetCharge(); charge != nil {
+			charges = append(charges, *charge)
+		}
+		return charges
+	}
+	for _, peptidoform := range s.peptidoforms {
+		if peptidoform.charge != nil {
+			charges = append(charges, *peptidoform.charge)
+		}
+	}
+	return charges
+}
+
 // IsMultiChain returns whether the sequence is multi-chain
 func (s *Sequence) IsMultiChain() bool {
 	return s.isMultiChain
@@ -687,17 +2144,169 @@ func (s *Sequence) GetChains() []*Sequence {
 	return s.chains
 }
 
+// NumChains returns the number of chains, which is 1 for a single-chain sequence
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("PEPTIDE//SEQUENCE//THIRD")
+//	fmt.Println(seq.NumChains()) // 3
+func (s *Sequence) NumChains() int {
+	if s.isMultiChain {
+		return len(s.chains)
+	}
+	return 1
+}
+
+// Chain returns the chain at index i, or nil if i is out of range. Each chain of a multi-chain
+// sequence is a single-chain sequence; the first chain shares its residues and modifications
+// with the receiver, which also holds it for compatibility. A single-chain sequence is its own
+// chain 0.
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("PEPTIDE//SEQUENCE")
+//	fmt.Println(seq.Chain(0).ToProforma()) // "PEPTIDE"
+//	fmt.Println(seq.Chain(1).ToProforma()) // "SEQUENCE"
+func (s *Sequence) Chain(i int) *Sequence {
+	if i < 0 || i >= s.NumChains() {
+		return nil
+	}
+	if !s.isMultiChain {
+		return s
+	}
+	return s.chains[i]
+}
+
+// firstChain returns the first chain of a multi-chain sequence as a single-chain view
+// sharing the residues and modifications of s, so residues inserted or deleted through
+// either one are seen by both
+func (s *Sequence) firstChain() *Sequence {
+	chain := *s
+	chain.isMultiChain = false
+	chain.chains = nil
+	chain.viewOf = s
+	if len(s.peptidoforms) == 1 && s.peptidoforms[0] == s {
+		chain.peptidoforms = []*Sequence{&chain}
+	}
+	return &chain
+}
+
+// firstPeptidoform returns the first peptidoform of a chimeric sequence as a view of its
+// own, sharing the residues and modifications of s as firstChain does
+func (s *Sequence) firstPeptidoform() *Sequence {
+	pep := *s
+	pep.peptidoforms = []*Sequence{&pep}
+	pep.viewOf = s
+	return &pep
+}
+
+// ChainResidue identifies a residue within a multi-chain sequence by chain index and position.
+// Position is the residue index within the chain, or -1 and -2 for the N- and C-terminus.
+type ChainResidue struct {
+	ChainIndex int
+	Position   int
+}
+
+// GetInterChainCrosslinks resolves crosslink IDs across all chains of a multi-chain sequence
+// and returns those linking residues in two or more different chains, keyed by crosslink ID.
+// Both the crosslink definition and its references are included, ordered by chain and position.
+// Crosslinks confined to a single chain are omitted; single-chain sequences return an empty map.
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("PEPC[Disulfide#XL1]TIDE//SEC[#XL1]")
+//	links := seq.GetInterChainCrosslinks()
+//	fmt.Println(links["XL1"]) // [{0 3} {1 2}]
+func (s *Sequence) GetInterChainCrosslinks() map[string][]ChainResidue {
+	result := make(map[string][]ChainResidue)
+	if !s.isMultiChain {
+		return result
+	}
+
+	crosslinks := make(map[string][]ChainResidue)
+	addCrosslink := func(chainIndex int, position int, mod *Modification) {
+		if id := mod.GetCrosslinkID(); id != nil {
+			crosslinks[*id] = append(crosslinks[*id], ChainResidue{ChainIndex: chainIndex, Position: position})
+		}
+	}
+
+	for chainIndex, chain := range s.chains {
+		for _, mod := range chain.mods[-1] {
+			addCrosslink(chainIndex, -1, mod)
+		}
+		for i, aa := range chain.seq {
+			for _, mod := range aa.GetMods() {
+				addCrosslink(chainIndex, i, mod)
+			}
+		}
+		for _, mod := range chain.mods[-2] {
+			addCrosslink(chainIndex, -2, mod)
+		}
+	}
+
+	for id, residues := range crosslinks {
+		for _, residue := range residues[1:] {
+			if residue.ChainIndex != residues[0].ChainIndex {
+				result[id] = residues
+				break
+			}
+		}
+	}
+
+	return result
+}
+
 // GetPeptidoformName returns the peptidoform name (ProForma 2.1)
 func (s *Sequence) GetPeptidoformName() *string {
 	return s.peptidoformName
 }
 
-// GetPeptidoformIonName returns the peptidoform ion name (ProForma 2.1)
+// uniprotHeaderPattern matches a UniProt FASTA header "db|ACCESSION|ENTRY_NAME ..."
+var uniprotHeaderPattern = regexp.MustCompile(`^(?:sp|tr)\|([^|\s]+)\|([^|\s]+)(?:\s|$)`)
+
+// GetProteinAccession returns the accession of a UniProt-style peptidoform name such as
+// "sp|P02768|ALBU_HUMAN", or nil if the name is not a UniProt header.
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("(>sp|P02768|ALBU_HUMAN (Albumin))PEPTIDE")
+//	fmt.Println(*seq.GetProteinAccession()) // "P02768"
+func (s *Sequence) GetProteinAccession() *string {
+	return s.uniprotHeaderField(1)
+}
+
+// GetProteinName returns the entry name of a UniProt-style peptidoform name such as
+// "sp|P02768|ALBU_HUMAN", or nil if the name is not a UniProt header.
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("(>sp|P02768|ALBU_HUMAN (Albumin))PEPTIDE")
+//	fmt.Println(*seq.GetProteinName()) // "ALBU_HUMAN"
+func (s *Sequence) GetProteinName() *string {
+	return s.uniprotHeaderField(2)
+}
+
+// uniprotHeaderField returns a submatch of uniprotHeaderPattern in the peptidoform name
+func (s *Sequence) uniprotHeaderField(index int) *string {
+	if s.peptidoformName == nil {
+		return nil
+	}
+	match := uniprotHeaderPattern.FindStringSubmatch(*s.peptidoformName)
+	if match == nil {
+		return nil
+	}
+	return &match[index]
+}
+
+// GetPeptidoformIonName returns the peptidoform ion name (ProForma 2.1). Every chain of a
+// multi-chain sequence reports the ion name written before the first chain unless it has
+// its own.
 func (s *Sequence) GetPeptidoformIonName() *string {
 	return s.peptidoformIonName
 }
 
-// GetCompoundIonName returns the compound ion name for chimeric spectra (ProForma 2.1)
+// GetCompoundIonName returns the compound ion name for chimeric spectra (ProForma 2.1). Like
+// the peptidoform ion name, it is inherited by every chain of a multi-chain sequence.
 func (s *Sequence) GetCompoundIonName() *string {
 	return s.compoundIonName
 }
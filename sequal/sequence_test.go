@@ -1,6 +1,8 @@
 package sequal
 
 import (
+	"encoding/json"
+	"math"
 	"testing"
 )
 
@@ -230,7 +232,7 @@ func TestBasicSequenceParsing(t *testing.T) {
 				t.Errorf("Expected '-10.0', got '%s'", seq2.seq[4].GetMods()[0].GetValue())
 			}
 		}
-		expectedRoundtrip := "PEPTX[-10]IDE"
+		expectedRoundtrip := "PEPTX[-10.0]IDE"
 		if seq2.ToProforma() != expectedRoundtrip {
 			t.Errorf("Expected '%s', got '%s'", expectedRoundtrip, seq2.ToProforma())
 		}
@@ -522,6 +524,188 @@ func TestGlobalModifications(t *testing.T) {
 	}
 }
 
+func TestGlobalModificationMass(t *testing.T) {
+	bare, _ := FromProforma("PEPTCDE")
+	bareMass, err := bare.GetNeutralMass()
+	if err != nil {
+		t.Fatalf("Failed to calculate mass: %v", err)
+	}
+
+	tests := []struct {
+		proforma string
+		mass     float64
+	}{
+		{"<[Carbamidomethyl]@C>PEPTCDE", 57.021464},
+		{"<[UNIMOD:4]@C>PEPTCDE", 57.021464},
+		{"<[Formula:C2H3NO]@C>PEPTCDE", 57.021464},
+		{"<[+57.021]@C>PEPTCDE", 57.021},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.proforma, func(t *testing.T) {
+			seq, err := FromProforma(tt.proforma)
+			if err != nil {
+				t.Fatalf("Failed to parse: %v", err)
+			}
+			mass := seq.GetGlobalMods()[0].GetMass()
+			if mass == nil || math.Abs(*mass-tt.mass) > 1e-5 {
+				t.Errorf("Expected global modification mass %f, got %v", tt.mass, mass)
+			}
+			total, err := seq.GetNeutralMass()
+			if err != nil {
+				t.Fatalf("Failed to calculate mass: %v", err)
+			}
+			if math.Abs(total-(bareMass+tt.mass)) > 1e-5 {
+				t.Errorf("Expected mass %f, got %f", bareMass+tt.mass, total)
+			}
+		})
+	}
+
+	isotope, _ := FromProforma("<15N>PEPTIDE")
+	if mass := isotope.GetGlobalMods()[0].GetMass(); mass != nil {
+		t.Errorf("Expected no mass for an isotope label, got %f", *mass)
+	}
+	unknown, _ := FromProforma("<[Foo]@C>PEPTCDE")
+	if _, err := unknown.GetNeutralMass(); err == nil {
+		t.Error("Expected an error for an unresolvable global modification")
+	}
+}
+
+func TestUnknownResidues(t *testing.T) {
+	tests := []struct {
+		proforma string
+		unknown  []int
+	}{
+		{"PEBTIDE", []int{2}},
+		{"ZPEPJ", []int{0, 4}},
+		{"PEB[Phospho]TIDE", []int{2}},
+		{"PEPTXIDE", []int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.proforma, func(t *testing.T) {
+			seq, err := FromProforma(tt.proforma)
+			if err != nil {
+				t.Fatalf("Failed to parse: %v", err)
+			}
+			if seq.GetLength() != len(seq.ToStrippedString()) || seq.ToProforma() != tt.proforma {
+				t.Errorf("Roundtrip failed: expected '%s', got '%s'", tt.proforma, seq.ToProforma())
+			}
+
+			unknown := make([]int, 0)
+			for i, aa := range seq.GetSeq() {
+				if aa.IsUnknown() {
+					unknown = append(unknown, i)
+					if aa.GetMass() != nil {
+						t.Errorf("Expected no mass for unknown residue '%s'", aa.GetValue())
+					}
+				}
+			}
+			if len(unknown) != len(tt.unknown) {
+				t.Fatalf("Expected unknown residues at %v, got %v", tt.unknown, unknown)
+			}
+			for i, position := range tt.unknown {
+				if unknown[i] != position {
+					t.Errorf("Expected unknown residues at %v, got %v", tt.unknown, unknown)
+				}
+			}
+
+			if clone := seq.Clone(); clone.ToProforma() != tt.proforma {
+				t.Errorf("Expected clone '%s', got '%s'", tt.proforma, clone.ToProforma())
+			}
+		})
+	}
+
+	seq, _ := FromProforma("PEBTIDE")
+	if _, err := seq.GetNeutralMass(); err == nil {
+		t.Errorf("Expected mass calculation to fail for an unknown residue")
+	}
+	if seq.StripModifications().ToProforma() != "PEBTIDE" {
+		t.Errorf("Expected stripped sequence 'PEBTIDE', got '%s'", seq.StripModifications().ToProforma())
+	}
+	if _, err := NewAminoAcid("B", nil, nil); err == nil {
+		t.Errorf("Expected NewAminoAcid to reject an unknown residue without a mass")
+	}
+}
+
+func TestUnknownPositionOutputOrder(t *testing.T) {
+	tests := []struct {
+		proforma string
+		expected string
+		sorted   string
+	}{
+		{"[Phospho]^3[Oxidation]^2?MSTPEPTMSTY", "[Phospho]^3[Oxidation]^2?MSTPEPTMSTY", "[Oxidation]^2[Phospho]^3?MSTPEPTMSTY"},
+		{"[Oxidation][Phospho][Oxidation]?PEPTMIDE", "[Oxidation]^2[Phospho]?PEPTMIDE", "[Oxidation]^2[Phospho]?PEPTMIDE"},
+		{"[Phospho][Acetyl][Methyl]?PEPTIDE", "[Phospho][Acetyl][Methyl]?PEPTIDE", "[Acetyl][Methyl][Phospho]?PEPTIDE"},
+		{"[Phospho]?[Acetyl]?PEPTIDE", "[Phospho][Acetyl]?PEPTIDE", "[Acetyl][Phospho]?PEPTIDE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.proforma, func(t *testing.T) {
+			seq, err := FromProforma(tt.proforma)
+			if err != nil {
+				t.Fatalf("Failed to parse: %v", err)
+			}
+			for i := 0; i < 20; i++ {
+				if output := seq.ToProforma(); output != tt.expected {
+					t.Fatalf("Expected '%s', got '%s'", tt.expected, output)
+				}
+			}
+			opts := DefaultProformaOptions()
+			opts.SortModifications = true
+			if output := seq.ToProformaWithOptions(opts); output != tt.sorted {
+				t.Errorf("Expected sorted '%s', got '%s'", tt.sorted, output)
+			}
+		})
+	}
+}
+
+func TestUnknownPositionWithLabile(t *testing.T) {
+	tests := []struct {
+		proforma        string
+		expected        string
+		labile          int
+		unknownPosition int
+		unknownTerminus int
+	}{
+		{"[Phospho]?{Glycan:Hex1}PEPTIDE", "[Phospho]?{Glycan:Hex1}PEPTIDE", 1, 1, 0},
+		{"{Glycan:Hex1}[Phospho]?PEPTIDE", "[Phospho]?{Glycan:Hex1}PEPTIDE", 1, 1, 0},
+		{"{Hex}[Phospho]?{Fuc}PEPTIDE", "[Phospho]?{Hex}{Fuc}PEPTIDE", 2, 1, 0},
+		{"[Phospho]?{Hex}[Acetyl]?-PEPTIDE", "[Phospho]?[Acetyl]?-{Hex}PEPTIDE", 1, 1, 1},
+		{"{Hex}[Acetyl]?-[Methyl]-PEPTIDE", "[Acetyl]?-{Hex}[Methyl]-PEPTIDE", 1, 0, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.proforma, func(t *testing.T) {
+			seq, err := FromProforma(tt.proforma)
+			if err != nil {
+				t.Fatalf("Failed to parse: %v", err)
+			}
+			if got := len(seq.mods[-3]); got != tt.labile {
+				t.Errorf("Expected %d labile modifications, got %d", tt.labile, got)
+			}
+			if got := len(seq.mods[-4]); got != tt.unknownPosition {
+				t.Errorf("Expected %d unknown position modifications, got %d", tt.unknownPosition, got)
+			}
+			if got := len(seq.mods[-5]); got != tt.unknownTerminus {
+				t.Errorf("Expected %d unknown terminus modifications, got %d", tt.unknownTerminus, got)
+			}
+
+			output := seq.ToProforma()
+			if output != tt.expected {
+				t.Errorf("Expected '%s', got '%s'", tt.expected, output)
+			}
+			reparsed, err := FromProforma(output)
+			if err != nil {
+				t.Fatalf("Failed to parse output '%s': %v", output, err)
+			}
+			if roundTrip := reparsed.ToProforma(); roundTrip != output {
+				t.Errorf("Expected round trip '%s', got '%s'", output, roundTrip)
+			}
+		})
+	}
+}
+
 func TestChimericSequences(t *testing.T) {
 	tests := []struct {
 		name               string
@@ -529,6 +713,7 @@ func TestChimericSequences(t *testing.T) {
 		expectedPeptidoforms int
 		expectedFirstSeq   string
 		expectedSecondSeq  string
+		expectedParts      []string
 	}{
 		{
 			name:               "Basic chimeric",
@@ -536,6 +721,7 @@ func TestChimericSequences(t *testing.T) {
 			expectedPeptidoforms: 2,
 			expectedFirstSeq:   "PEPTIDE",
 			expectedSecondSeq:  "ANOTHER",
+			expectedParts:      []string{"PEPTIDE/2", "ANOTHER/3"},
 		},
 		{
 			name:               "Complex chimeric with modifications",
@@ -543,6 +729,7 @@ func TestChimericSequences(t *testing.T) {
 			expectedPeptidoforms: 2,
 			expectedFirstSeq:   "PEPTIDE",
 			expectedSecondSeq:  "SEQ",
+			expectedParts:      []string{"[Acetyl]-PEP[+79.966]TIDE-[Amidated]/2[+Na+]", "S[Phospho]EQ/3"},
 		},
 	}
 
@@ -575,133 +762,519 @@ func TestChimericSequences(t *testing.T) {
 					t.Errorf("Expected second sequence '%s', got '%s'", tt.expectedSecondSeq, secondSeq)
 				}
 			}
+
+			if seq.ToProforma() != tt.proforma {
+				t.Errorf("Roundtrip failed: expected '%s', got '%s'", tt.proforma, seq.ToProforma())
+			}
+			for i, expected := range tt.expectedParts {
+				if i < len(peptidoforms) && peptidoforms[i].ToProforma() != expected {
+					t.Errorf("Expected peptidoform %d to be '%s', got '%s'", i, expected, peptidoforms[i].ToProforma())
+				}
+			}
 		})
 	}
 }
 
-func TestMultiChainSequences(t *testing.T) {
-	proforma := "PEPTIDE//SEQUENCE//THIRD"
-	seq, err := FromProforma(proforma)
+func TestChimericFirstPeptidoform(t *testing.T) {
+	seq, err := FromProforma("PEPT[Phospho]IDE/2+ANOTHER/3")
 	if err != nil {
-		t.Fatalf("Failed to parse multi-chain ProForma '%s': %v", proforma, err)
+		t.Fatalf("Failed to parse: %v", err)
 	}
 
-	if !seq.IsMultiChain() {
-		t.Errorf("Expected sequence to be multi-chain")
+	for name, s := range map[string]*Sequence{"original": seq, "clone": seq.Clone()} {
+		first := s.GetPeptidoforms()[0]
+		if first.GetSeq()[0] != s.GetSeq()[0] {
+			t.Errorf("Expected the %s first peptidoform to share residues with the mixture", name)
+		}
+		if first.ToProforma() != "PEPT[Phospho]IDE/2" {
+			t.Errorf("Expected %s first peptidoform 'PEPT[Phospho]IDE/2', got '%s'", name, first.ToProforma())
+		}
 	}
 
-	chains := seq.GetChains()
-	if len(chains) != 3 {
-		t.Errorf("Expected 3 chains, got %d", len(chains))
+	stripped := seq.StripModifications()
+	if stripped.ToProforma() != "PEPTIDE+ANOTHER" {
+		t.Errorf("Expected 'PEPTIDE+ANOTHER', got '%s'", stripped.ToProforma())
+	}
+	if stripped.GetPeptidoforms()[0].ToProforma() != "PEPTIDE" {
+		t.Errorf("Expected stripped first peptidoform 'PEPTIDE', got '%s'", stripped.GetPeptidoforms()[0].ToProforma())
 	}
 
-	expectedChains := []string{"PEPTIDE", "SEQUENCE", "THIRD"}
-	for i, chain := range chains {
-		stripped := chain.ToStrippedString()
-		if stripped != expectedChains[i] {
-			t.Errorf("Expected chain %d to be '%s', got '%s'", i, expectedChains[i], stripped)
-		}
+	if err := seq.InsertResidue(0, "K", nil); err != nil {
+		t.Fatalf("Failed to insert residue: %v", err)
+	}
+	if seq.GetPeptidoforms()[0].ToProforma() != "KPEPT[Phospho]IDE/2" {
+		t.Errorf("Expected the first peptidoform to follow residue insertion, got '%s'", seq.GetPeptidoforms()[0].ToProforma())
+	}
+
+	viewed, _ := FromProforma("PEPTIDE+ELVIS")
+	first := viewed.GetPeptidoforms()[0]
+	if err := first.InsertResidue(0, "K", nil); err != nil {
+		t.Fatalf("Failed to insert residue: %v", err)
+	}
+	if viewed.ToProforma() != "KPEPTIDE+ELVIS" {
+		t.Errorf("Expected insertion through the first peptidoform to give 'KPEPTIDE+ELVIS', got '%s'", viewed.ToProforma())
+	}
+	if err := first.DeleteResidue(1); err != nil {
+		t.Fatalf("Failed to delete residue: %v", err)
+	}
+	if viewed.ToProforma() != "KEPTIDE+ELVIS" || viewed.GetLength() != 7 {
+		t.Errorf("Expected deletion through the first peptidoform to give 'KEPTIDE+ELVIS', got '%s'", viewed.ToProforma())
 	}
 }
 
-func TestRoundTripConversion(t *testing.T) {
+func TestChimericGlobalModifications(t *testing.T) {
+	tests := []struct {
+		proforma     string
+		peptidoforms []string
+	}{
+		{
+			"<[Carbamidomethyl]@C>PEPTC/2+<[Oxidation]@M>ANOTHERM/3",
+			[]string{"<[Carbamidomethyl]@C>PEPTC/2", "<[Carbamidomethyl]@C><[Oxidation]@M>ANOTHERM/3"},
+		},
+		{
+			"PEPTC/2+<[Oxidation]@M>ANOTHERM/3+<[Deamidated]@N>PEPNIDE/2",
+			[]string{"PEPTC/2", "<[Oxidation]@M>ANOTHERM/3", "<[Deamidated]@N>PEPNIDE/2"},
+		},
+		{
+			"<13C>PEPTIDE/2+<15N>ANOTHER/3",
+			[]string{"<13C>PEPTIDE/2", "<13C><15N>ANOTHER/3"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.proforma, func(t *testing.T) {
+			seq, err := FromProforma(tt.proforma)
+			if err != nil {
+				t.Fatalf("Failed to parse: %v", err)
+			}
+			if output := seq.ToProforma(); output != tt.proforma {
+				t.Errorf("Expected '%s', got '%s'", tt.proforma, output)
+			}
+			if output := seq.Clone().ToProforma(); output != tt.proforma {
+				t.Errorf("Expected clone '%s', got '%s'", tt.proforma, output)
+			}
+			peptidoforms := seq.GetPeptidoforms()
+			if len(peptidoforms) != len(tt.peptidoforms) {
+				t.Fatalf("Expected %d peptidoforms, got %d", len(tt.peptidoforms), len(peptidoforms))
+			}
+			for i, expected := range tt.peptidoforms {
+				if output := peptidoforms[i].ToProforma(); output != expected {
+					t.Errorf("Expected peptidoform %d '%s', got '%s'", i, expected, output)
+				}
+			}
+		})
+	}
+}
+
+func TestMultiChainGlobalModifications(t *testing.T) {
 	tests := []string{
-		"PEPTIDE",
-		"ELVIS[Phospho]K",
-		"[Acetyl]-PEPTIDE-[Amidated]",
-		"PEPTIDE/2",
-		"PEPTIDE/2[+Na+]",
-		"<[Carbamidomethyl]@C>PEPTCDE",
-		// Note: Some complex cases might have minor formatting differences
+		"<[Carbamidomethyl]@C>PEPTC//ANOTHERC",
+		"PEPTC//<[Oxidation]@M>ANOTHERM",
+		"<[Acetyl]@N-term>PEPTIDE//<[TMT6plex]@K,N-term>KSEQUENCEK",
 	}
 
 	for _, proforma := range tests {
 		t.Run(proforma, func(t *testing.T) {
 			seq, err := FromProforma(proforma)
 			if err != nil {
-				t.Fatalf("Failed to parse ProForma '%s': %v", proforma, err)
+				t.Fatalf("Failed to parse: %v", err)
 			}
-
-			regenerated := seq.ToProforma()
-			if regenerated == "" {
-				t.Errorf("Failed to regenerate ProForma for '%s'", proforma)
+			if output := seq.ToProforma(); output != proforma {
+				t.Errorf("Expected '%s', got '%s'", proforma, output)
 			}
+		})
+	}
 
-			// Parse the regenerated ProForma to ensure it's valid
-			seq2, err := FromProforma(regenerated)
+	seq, _ := FromProforma("<[Carbamidomethyl]@C>PEPTC//ANOTHERC")
+	single, _ := FromProforma("ANOTHERC")
+	chain, _ := seq.GetChains()[1].GetNeutralMass()
+	bare, _ := single.GetNeutralMass()
+	if math.Abs(chain-bare) > 1e-6 {
+		t.Errorf("Expected the second chain without global modifications to weigh %f, got %f", bare, chain)
+	}
+}
+
+func TestMultiChainNamedEntities(t *testing.T) {
+	tests := []struct {
+		input         string
+		compoundNames []string
+		ionNames      []string
+		chainNames    []string
+	}{
+		{
+			"(>>>Disulfide-linked)(>>Ion pair)(>Chain1)PEPTIDEC//CSEQUENCE",
+			[]string{"Disulfide-linked", "Disulfide-linked"},
+			[]string{"Ion pair", "Ion pair"},
+			[]string{"Chain1", ""},
+		},
+		{
+			"(>>>Disulfide-linked)(>Chain1)PEPTIDEC//(>Chain2)CSEQUENCE//(>Chain3)KSEQ",
+			[]string{"Disulfide-linked", "Disulfide-linked", "Disulfide-linked"},
+			[]string{"", "", ""},
+			[]string{"Chain1", "Chain2", "Chain3"},
+		},
+		{
+			"PEPTIDE//(>Chain2)SEQUENCE",
+			[]string{"", ""},
+			[]string{"", ""},
+			[]string{"", "Chain2"},
+		},
+	}
+
+	name := func(value *string) string {
+		if value == nil {
+			return ""
+		}
+		return *value
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			seq, err := FromProforma(tt.input)
 			if err != nil {
-				t.Errorf("Failed to parse regenerated ProForma '%s': %v", regenerated, err)
+				t.Fatalf("Failed to parse: %v", err)
 			}
-
-			// Compare the stripped sequences
-			if seq.ToStrippedString() != seq2.ToStrippedString() {
-				t.Errorf("Stripped sequences don't match: '%s' vs '%s'", 
-					seq.ToStrippedString(), seq2.ToStrippedString())
+			chains := seq.GetChains()
+			if len(chains) != len(tt.chainNames) {
+				t.Fatalf("Expected %d chains, got %d", len(tt.chainNames), len(chains))
+			}
+			for i, chain := range chains {
+				if got := name(chain.GetCompoundIonName()); got != tt.compoundNames[i] {
+					t.Errorf("Chain %d: expected compound ion name '%s', got '%s'", i, tt.compoundNames[i], got)
+				}
+				if got := name(chain.GetPeptidoformIonName()); got != tt.ionNames[i] {
+					t.Errorf("Chain %d: expected peptidoform ion name '%s', got '%s'", i, tt.ionNames[i], got)
+				}
+				if got := name(chain.GetPeptidoformName()); got != tt.chainNames[i] {
+					t.Errorf("Chain %d: expected peptidoform name '%s', got '%s'", i, tt.chainNames[i], got)
+				}
+			}
+			if seq.ToProforma() != tt.input {
+				t.Errorf("Expected '%s', got '%s'", tt.input, seq.ToProforma())
 			}
 		})
 	}
 }
 
-func TestInfoTags(t *testing.T) {
-	proforma := "ELVIS[Phospho|INFO:newly discovered]K"
-	seq, err := FromProforma(proforma)
-	if err != nil {
-		t.Fatalf("Failed to parse ProForma '%s': %v", proforma, err)
+func TestChimericChargeBinding(t *testing.T) {
+	// A charge binds to the peptidoform it follows, not to the whole mixture
+	testCases := []struct {
+		proforma string
+		charges  []*int
+	}{
+		{"PEPTIDE+ANOTHER/2", []*int{nil, IntPtr(2)}},
+		{"PEPTIDE/2+ANOTHER", []*int{IntPtr(2), nil}},
+		{"PEPTIDE/2+ANOTHER/3", []*int{IntPtr(2), IntPtr(3)}},
+		{"PEPTIDE/2[+2Na+]+ANOTHER/3+THIRD", []*int{IntPtr(2), IntPtr(3), nil}},
 	}
 
-	// Check that the modification has info tags
-	aa := seq.seq[4] // Position of S
-	mods := aa.GetMods()
-	if len(mods) == 0 {
-		t.Errorf("Expected modification with info tag, but found no modifications")
-		return
-	}
+	for _, tc := range testCases {
+		t.Run(tc.proforma, func(t *testing.T) {
+			seq, err := FromProforma(tc.proforma)
+			if err != nil {
+				t.Fatalf("Failed to parse ProForma '%s': %v", tc.proforma, err)
+			}
 
-	mod := mods[0]
-	infoTags := mod.GetInfoTags()
-	if len(infoTags) == 0 {
-		t.Errorf("Expected info tags, but found none")
-		return
+			peptidoforms := seq.GetPeptidoforms()
+			if len(peptidoforms) != len(tc.charges) {
+				t.Fatalf("Expected %d peptidoforms, got %d", len(tc.charges), len(peptidoforms))
+			}
+			for i, expected := range tc.charges {
+				charge := peptidoforms[i].GetCharge()
+				if !equalCharge(charge, expected) {
+					t.Errorf("Expected peptidoform %d charge %s, got %s", i, formatCharge(expected), formatCharge(charge))
+				}
+			}
+			if !equalCharge(seq.GetCharge(), tc.charges[0]) {
+				t.Errorf("Expected sequence charge to be the first peptidoform's, got %s", formatCharge(seq.GetCharge()))
+			}
+
+			if seq.ToProforma() != tc.proforma {
+				t.Errorf("Roundtrip failed: expected '%s', got '%s'", tc.proforma, seq.ToProforma())
+			}
+		})
 	}
 
-	expectedTag := "newly discovered"
-	if infoTags[0] != expectedTag {
-		t.Errorf("Expected info tag '%s', got '%s'", expectedTag, infoTags[0])
+	seq, _ := FromProforma("PEPTIDE/2")
+	if seq.IsChimeric() {
+		t.Error("Expected a single charged peptidoform not to be chimeric")
 	}
 }
 
-func TestUtilityFunctions(t *testing.T) {
-	seq, err := FromProforma("PEPTIDE")
-	if err != nil {
-		t.Fatalf("Failed to parse ProForma: %v", err)
+func TestChimericPeptidoformNames(t *testing.T) {
+	testCases := []struct {
+		proforma string
+		names    []string
+	}{
+		{"(>Pep1)PEPTIDE/2+(>Pep2)ANOTHER/3", []string{"Pep1", "Pep2"}},
+		{"(>Pep1)EM[Oxidation]EVTK/2+(>Pep2)ELVIS[Phospho]K/3", []string{"Pep1", "Pep2"}},
+		{"PEPTIDE/2+(>Pep2)ANOTHER/3", []string{"", "Pep2"}},
+		{"(>Tryptic peptide)PEPTIDE/2[+2Na+]+(>Semi-tryptic peptide)ANOTHER/3", []string{"Tryptic peptide", "Semi-tryptic peptide"}},
+		{"(>Pep1)<[Carbamidomethyl]@C>PEPTC/2+(>Pep2)ANOTHERC/3", []string{"Pep1", "Pep2"}},
 	}
 
-	// Test Count function
-	count := seq.Count("P", 0, 7)
-	if count != 2 {
-		t.Errorf("Expected 2 P residues, got %d", count)
+	for _, tc := range testCases {
+		t.Run(tc.proforma, func(t *testing.T) {
+			seq, err := FromProforma(tc.proforma)
+			if err != nil {
+				t.Fatalf("Failed to parse ProForma '%s': %v", tc.proforma, err)
+			}
+
+			peptidoforms := seq.GetPeptidoforms()
+			if len(peptidoforms) != len(tc.names) {
+				t.Fatalf("Expected %d peptidoforms, got %d", len(tc.names), len(peptidoforms))
+			}
+			for i, expected := range tc.names {
+				name := peptidoforms[i].GetPeptidoformName()
+				if expected == "" {
+					if name != nil {
+						t.Errorf("Expected peptidoform %d to have no name, got '%s'", i, *name)
+					}
+				} else if name == nil || *name != expected {
+					t.Errorf("Expected peptidoform %d name '%s', got %v", i, expected, name)
+				}
+			}
+
+			if seq.ToProforma() != tc.proforma {
+				t.Errorf("Roundtrip failed: expected '%s', got '%s'", tc.proforma, seq.ToProforma())
+			}
+		})
 	}
+}
 
-	// Test Gaps function
-	gapSeq, err := FromProforma("PEP-TIDE")
+func TestChimericSharedGlobalMods(t *testing.T) {
+	proforma := "<[Carbamidomethyl]@C>PEPTC/2+ANOTHERC/3"
+	seq, err := FromProforma(proforma)
 	if err != nil {
-		t.Fatalf("Failed to parse ProForma with gap: %v", err)
+		t.Fatalf("Failed to parse ProForma '%s': %v", proforma, err)
 	}
 
-	gaps := gapSeq.Gaps()
-	expectedGaps := []bool{false, false, false, true, false, false, false, false}
-	for i, gap := range gaps {
-		if i < len(expectedGaps) && gap != expectedGaps[i] {
-			t.Errorf("Expected gap at position %d to be %v, got %v", i, expectedGaps[i], gap)
+	peptidoforms := seq.GetPeptidoforms()
+	if len(peptidoforms) != 2 {
+		t.Fatalf("Expected 2 peptidoforms, got %d", len(peptidoforms))
+	}
+
+	expectedSites := []int{4, 7}
+	for i, pep := range peptidoforms {
+		globalMods := pep.GetGlobalMods()
+		if len(globalMods) != 1 || globalMods[0].GetValue() != "Carbamidomethyl" {
+			t.Errorf("Expected peptidoform %d to share Carbamidomethyl, got %v", i, globalMods)
+			continue
+		}
+		sites := pep.GetGlobalModSites()
+		if len(sites[expectedSites[i]]) != 1 {
+			t.Errorf("Expected peptidoform %d to have Carbamidomethyl at position %d, got %v", i, expectedSites[i], sites)
 		}
 	}
 
-	// Test GetLength
-	if seq.GetLength() != 7 {
-		t.Errorf("Expected length 7, got %d", seq.GetLength())
+	if seq.ToProforma() != proforma {
+		t.Errorf("Roundtrip failed: expected '%s', got '%s'", proforma, seq.ToProforma())
 	}
 
-	// Test ToMap
+	withOwn := "<[Carbamidomethyl]@C>PEPTC/2+<13C>ANOTHERC/3"
+	seq, err = FromProforma(withOwn)
+	if err != nil {
+		t.Fatalf("Failed to parse ProForma '%s': %v", withOwn, err)
+	}
+	if len(seq.GetPeptidoforms()[1].GetGlobalMods()) != 2 {
+		t.Errorf("Expected second peptidoform to have shared and own global mods, got %d", len(seq.GetPeptidoforms()[1].GetGlobalMods()))
+	}
+	if seq.ToProforma() != withOwn {
+		t.Errorf("Roundtrip failed: expected '%s', got '%s'", withOwn, seq.ToProforma())
+	}
+}
+func TestMultiChainSequences(t *testing.T) {
+	proforma := "PEPTIDE//SEQUENCE//THIRD"
+	seq, err := FromProforma(proforma)
+	if err != nil {
+		t.Fatalf("Failed to parse multi-chain ProForma '%s': %v", proforma, err)
+	}
+
+	if !seq.IsMultiChain() {
+		t.Errorf("Expected sequence to be multi-chain")
+	}
+
+	chains := seq.GetChains()
+	if len(chains) != 3 {
+		t.Errorf("Expected 3 chains, got %d", len(chains))
+	}
+
+	expectedChains := []string{"PEPTIDE", "SEQUENCE", "THIRD"}
+	for i, chain := range chains {
+		stripped := chain.ToStrippedString()
+		if stripped != expectedChains[i] {
+			t.Errorf("Expected chain %d to be '%s', got '%s'", i, expectedChains[i], stripped)
+		}
+	}
+}
+
+func TestSequenceChainAccess(t *testing.T) {
+	seq, err := FromProforma("PEPT[Phospho]IDE//SEQUENCE//THIRD")
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+
+	if seq.NumChains() != 3 {
+		t.Fatalf("Expected 3 chains, got %d", seq.NumChains())
+	}
+	expectedChains := []string{"PEPT[Phospho]IDE", "SEQUENCE", "THIRD"}
+	for i, expected := range expectedChains {
+		chain := seq.Chain(i)
+		if chain == seq {
+			t.Errorf("Expected chain %d not to be the multi-chain sequence itself", i)
+		}
+		if chain.IsMultiChain() || chain.NumChains() != 1 {
+			t.Errorf("Expected chain %d to be a single chain", i)
+		}
+		if chain.ToProforma() != expected {
+			t.Errorf("Expected chain %d to be '%s', got '%s'", i, expected, chain.ToProforma())
+		}
+	}
+	if seq.Chain(3) != nil || seq.Chain(-1) != nil {
+		t.Errorf("Expected nil for out-of-range chain index")
+	}
+
+	if err := seq.InsertResidue(0, "K", nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := "KPEPT[Phospho]IDE//SEQUENCE//THIRD"
+	if seq.ToProforma() != expected {
+		t.Errorf("Expected '%s', got '%s'", expected, seq.ToProforma())
+	}
+
+	first := seq.Chain(0)
+	if err := first.InsertResidue(0, "M", nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := first.DeleteResidue(first.GetLength() - 1); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected = "MKPEPT[Phospho]ID//SEQUENCE//THIRD"
+	if seq.ToProforma() != expected || seq.GetLength() != 8 {
+		t.Errorf("Expected edits through the first chain to give '%s', got '%s'", expected, seq.ToProforma())
+	}
+	if first.ToProforma() != "MKPEPT[Phospho]ID" {
+		t.Errorf("Expected first chain 'MKPEPT[Phospho]ID', got '%s'", first.ToProforma())
+	}
+
+	viewed, _ := FromProforma("PEPTIDE//ELVIS")
+	if err := viewed.Chain(0).InsertResidue(0, "K", nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if viewed.ToStrippedString() != "KPEPTIDE" || viewed.ToProforma() != "KPEPTIDE//ELVIS" {
+		t.Errorf("Expected 'KPEPTIDE//ELVIS', got '%s'", viewed.ToProforma())
+	}
+	if clone := viewed.Chain(0).Clone(); clone.InsertResidue(0, "A", nil) != nil || viewed.ToStrippedString() != "KPEPTIDE" {
+		t.Errorf("Expected edits to a clone of the first chain to leave the sequence unchanged, got '%s'", viewed.ToProforma())
+	}
+
+	single, _ := FromProforma("PEPTIDE")
+	if single.NumChains() != 1 || single.Chain(0) != single {
+		t.Errorf("Expected a single-chain sequence to be its own only chain")
+	}
+	if single.Chain(1) != nil {
+		t.Errorf("Expected nil for out-of-range chain index")
+	}
+}
+
+func TestRoundTripConversion(t *testing.T) {
+	tests := []string{
+		"PEPTIDE",
+		"ELVIS[Phospho]K",
+		"[Acetyl]-PEPTIDE-[Amidated]",
+		"PEPTIDE/2",
+		"PEPTIDE/2[+Na+]",
+		"<[Carbamidomethyl]@C>PEPTCDE",
+		// Note: Some complex cases might have minor formatting differences
+	}
+
+	for _, proforma := range tests {
+		t.Run(proforma, func(t *testing.T) {
+			seq, err := FromProforma(proforma)
+			if err != nil {
+				t.Fatalf("Failed to parse ProForma '%s': %v", proforma, err)
+			}
+
+			regenerated := seq.ToProforma()
+			if regenerated == "" {
+				t.Errorf("Failed to regenerate ProForma for '%s'", proforma)
+			}
+
+			// Parse the regenerated ProForma to ensure it's valid
+			seq2, err := FromProforma(regenerated)
+			if err != nil {
+				t.Errorf("Failed to parse regenerated ProForma '%s': %v", regenerated, err)
+			}
+
+			// Compare the stripped sequences
+			if seq.ToStrippedString() != seq2.ToStrippedString() {
+				t.Errorf("Stripped sequences don't match: '%s' vs '%s'", 
+					seq.ToStrippedString(), seq2.ToStrippedString())
+			}
+		})
+	}
+}
+
+func TestInfoTags(t *testing.T) {
+	proforma := "ELVIS[Phospho|INFO:newly discovered]K"
+	seq, err := FromProforma(proforma)
+	if err != nil {
+		t.Fatalf("Failed to parse ProForma '%s': %v", proforma, err)
+	}
+
+	// Check that the modification has info tags
+	aa := seq.seq[4] // Position of S
+	mods := aa.GetMods()
+	if len(mods) == 0 {
+		t.Errorf("Expected modification with info tag, but found no modifications")
+		return
+	}
+
+	mod := mods[0]
+	infoTags := mod.GetInfoTags()
+	if len(infoTags) == 0 {
+		t.Errorf("Expected info tags, but found none")
+		return
+	}
+
+	expectedTag := "newly discovered"
+	if infoTags[0] != expectedTag {
+		t.Errorf("Expected info tag '%s', got '%s'", expectedTag, infoTags[0])
+	}
+}
+
+func TestUtilityFunctions(t *testing.T) {
+	seq, err := FromProforma("PEPTIDE")
+	if err != nil {
+		t.Fatalf("Failed to parse ProForma: %v", err)
+	}
+
+	// Test Count function
+	count := seq.Count("P", 0, 7)
+	if count != 2 {
+		t.Errorf("Expected 2 P residues, got %d", count)
+	}
+
+	// Test Gaps function
+	gapSeq, err := FromProforma("PEP-TIDE")
+	if err != nil {
+		t.Fatalf("Failed to parse ProForma with gap: %v", err)
+	}
+
+	gaps := gapSeq.Gaps()
+	expectedGaps := []bool{false, false, false, true, false, false, false, false}
+	for i, gap := range gaps {
+		if i < len(expectedGaps) && gap != expectedGaps[i] {
+			t.Errorf("Expected gap at position %d to be %v, got %v", i, expectedGaps[i], gap)
+		}
+	}
+
+	// Test GetLength
+	if seq.GetLength() != 7 {
+		t.Errorf("Expected length 7, got %d", seq.GetLength())
+	}
+
+	// Test ToMap
 	seqMap := seq.ToMap()
 	if seqMap["sequence"] != "PEPTIDE" {
 		t.Errorf("Expected sequence 'PEPTIDE' in map, got %v", seqMap["sequence"])
@@ -758,4 +1331,2711 @@ func TestComplexProFormaExamples(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+
+func TestInfoTagOrderRoundtrip(t *testing.T) {
+	testCases := []struct {
+		proforma string
+		tags     []string
+	}{
+		{"ELVIS[Phospho|INFO:newly discovered|INFO:Created on 2021-06]K", []string{"newly discovered", "Created on 2021-06"}},
+		{"ELVIS[Phospho|INFO:b|INFO:a]K", []string{"b", "a"}},
+		{"ELVIS[Phospho|INFO:checked|INFO:checked]K", []string{"checked", "checked"}},
+		{"ELVIS[Phospho|INFO:x|Obs:+79.978|INFO:y]K", []string{"x", "y"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.proforma, func(t *testing.T) {
+			seq, err := FromProforma(tc.proforma)
+			if err != nil {
+				t.Fatalf("Failed to parse '%s': %v", tc.proforma, err)
+			}
+			if seq.ToProforma() != tc.proforma {
+				t.Errorf("Expected '%s', got '%s'", tc.proforma, seq.ToProforma())
+			}
+			tags := seq.GetSeq()[4].GetMods()[0].GetInfoTags()
+			if len(tags) != len(tc.tags) {
+				t.Fatalf("Expected tags %v, got %v", tc.tags, tags)
+			}
+			for i, tag := range tc.tags {
+				if tags[i] != tag {
+					t.Errorf("Expected tag %d to be '%s', got '%s'", i, tag, tags[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSequenceValidate(t *testing.T) {
+	tests := []struct {
+		name             string
+		proforma         string
+		expectedSeverity []ValidationSeverity
+		expectedPosition []int
+	}{
+		{
+			name:     "Valid sequence",
+			proforma: "[Acetyl]-PEPS[Phospho]TM[Oxidation]C[Disulfide#XL1]K-[Amidated]",
+		},
+		{
+			name:             "Phospho on non-phosphorylatable residue",
+			proforma:         "PEPG[Phospho]TIDE",
+			expectedSeverity: []ValidationSeverity{ValidationSeverityWarning},
+			expectedPosition: []int{3},
+		},
+		{
+			name:     "Non-Unimod source is not checked",
+			proforma: "PEPG[M:Phospho]TIDE",
+		},
+		{
+			name:             "Unimod accession on non-phosphorylatable residue",
+			proforma:         "PEPG[UNIMOD:21]TIDE",
+			expectedSeverity: []ValidationSeverity{ValidationSeverityWarning},
+			expectedPosition: []int{3},
+		},
+		{
+			name:     "Mass shift matching an accession number",
+			proforma: "PEPG[+21]IDE",
+		},
+		{
+			name:     "Gap modification on X residue",
+			proforma: "RTAAX[+367.0537]WT",
+		},
+		{
+			name:             "Crosslink reference without definition",
+			proforma:         "PEPC[#XL1]TIDE",
+			expectedSeverity: []ValidationSeverity{ValidationSeverityError},
+			expectedPosition: []int{3},
+		},
+		{
+			name:             "Problems in a later chimeric peptidoform",
+			proforma:         "PEPTIDE+PEPG[Phospho]C[#XL1]",
+			expectedSeverity: []ValidationSeverity{ValidationSeverityWarning, ValidationSeverityError},
+			expectedPosition: []int{3, 4},
+		},
+		{
+			name:     "Crosslink defined in another chain",
+			proforma: "PEPC[Disulfide#XL1]TIDE//SEC[#XL1]",
+		},
+		{
+			name:             "Conflicting crosslink definitions",
+			proforma:         "PEPTK[XL:DSS#XL1]IDK[XL:BS3#XL1]E",
+			expectedSeverity: []ValidationSeverity{ValidationSeverityError},
+			expectedPosition: []int{7},
+		},
+		{
+			name:             "Repeated identical crosslink definition",
+			proforma:         "PEPTK[XL:DSS#XL1]IDK[XL:DSS#XL1]E",
+			expectedSeverity: []ValidationSeverity{ValidationSeverityWarning},
+			expectedPosition: []int{7},
+		},
+		{
+			name:     "Crosslink definition with references",
+			proforma: "PEPTK[XL:DSS#XL1]IDK[#XL1]EK[XL:BS3#XL2]YK[#XL2]",
+		},
+		{
+			name:             "Sources implying different masses",
+			proforma:         "PEPS[U:Phospho|U:+42.011]TIDE",
+			expectedSeverity: []ValidationSeverity{ValidationSeverityWarning},
+			expectedPosition: []int{3},
+		},
+		{
+			name:             "Terminal formula disagreeing with name",
+			proforma:         "[Acetyl|Formula:HPO3]-PEPTIDE",
+			expectedSeverity: []ValidationSeverity{ValidationSeverityWarning},
+			expectedPosition: []int{-1},
+		},
+		{
+			name:     "Sources implying the same mass",
+			proforma: "PEPS[U:Phospho|+79.966|Formula:HPO3]TIDE",
+		},
+		{
+			name:     "PSI-MOD synonym is not compared",
+			proforma: "PEPS[U:Phospho|M:MOD:00046]TIDE",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seq, err := FromProforma(tt.proforma)
+			if err != nil {
+				t.Fatalf("Failed to parse ProForma '%s': %v", tt.proforma, err)
+			}
+
+			issues := seq.Validate()
+			if len(issues) != len(tt.expectedSeverity) {
+				t.Fatalf("Expected %d issues, got %d: %v", len(tt.expectedSeverity), len(issues), issues)
+			}
+			for i, issue := range issues {
+				if issue.Severity != tt.expectedSeverity[i] {
+					t.Errorf("Expected severity '%s', got '%s'", tt.expectedSeverity[i], issue.Severity)
+				}
+				if issue.Position != tt.expectedPosition[i] {
+					t.Errorf("Expected position %d, got %d", tt.expectedPosition[i], issue.Position)
+				}
+				if issue.Message == "" {
+					t.Errorf("Expected a message for issue %d", i)
+				}
+			}
+		})
+	}
+
+	t.Run("Gap and terminal modifications on wrong residues", func(t *testing.T) {
+		seq, err := FromProforma("PEPTIDE")
+		if err != nil {
+			t.Fatalf("Failed to parse: %v", err)
+		}
+		seq.GetSeq()[2].AddModification(NewModification("+10.0", nil, nil, nil, "gap", false, 0, 10.0, false,
+			nil, false, false, false, nil, false, false, nil, nil, nil, nil,
+			nil, nil, false, false, false))
+		seq.GetSeq()[3].AddModification(NewModification("Acetyl", nil, nil, nil, "terminal", false, 0, 42.011, false,
+			nil, false, false, false, nil, false, false, nil, nil, nil, nil,
+			nil, nil, false, false, false))
+
+		issues := seq.Validate()
+		if len(issues) != 2 {
+			t.Fatalf("Expected 2 issues, got %d: %v", len(issues), issues)
+		}
+		if issues[0].Position != 2 || issues[0].Severity != ValidationSeverityError {
+			t.Errorf("Expected gap error at position 2, got %v", issues[0])
+		}
+		if issues[1].Position != 3 || issues[1].Severity != ValidationSeverityError {
+			t.Errorf("Expected terminal error at position 3, got %v", issues[1])
+		}
+	})
+
+	t.Run("Chimeric issues name their peptidoform", func(t *testing.T) {
+		seq, err := FromProforma("PEPTIDE+PEPG[Phospho]C[#XL1]")
+		if err != nil {
+			t.Fatalf("Failed to parse: %v", err)
+		}
+		for _, issue := range seq.Validate() {
+			if issue.Chain != 1 {
+				t.Errorf("Expected the issue in peptidoform 1, got %v", issue)
+			}
+		}
+	})
+}
+
+func TestFindConflictingModifications(t *testing.T) {
+	type expectedConflict struct {
+		kind     ConflictKind
+		chain    int
+		position int
+	}
+	testCases := []struct {
+		input    string
+		expected []expectedConflict
+	}{
+		{"PEPS[Phospho]IDE", nil},
+		{"PEPS[Phospho][Oxidation]IDE", nil},
+		{"PEPS[Phospho][+79.966]IDE", []expectedConflict{{ConflictSameMass, 0, 3}}},
+		{"PEPS[Phospho][Phospho]IDE", []expectedConflict{{ConflictSameMass, 0, 3}}},
+		{"PEPS[UnknownModification][UnknownModification]IDE", nil},
+		{"PEPTK[XL:DSS#XL1][#BRANCH]IDEK[#XL1]", []expectedConflict{{ConflictCrosslinkBranch, 0, 4}}},
+		{"PEPTK[#XL1][Ubiquitin#BRANCH]IDEK[DSS#XL1]", []expectedConflict{{ConflictCrosslinkBranch, 0, 4}}},
+		{"PEPTIDE//SEM[Oxidation][+15.995]K", []expectedConflict{{ConflictSameMass, 1, 2}}},
+		{"PEPTIDE+SEM[Oxidation][+15.995]K+ELVIS[Phospho][Phospho]K", []expectedConflict{{ConflictSameMass, 1, 2}, {ConflictSameMass, 2, 4}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			seq, err := FromProforma(tc.input)
+			if err != nil {
+				t.Fatalf("Failed to parse '%s': %v", tc.input, err)
+			}
+			conflicts := seq.FindConflictingModifications()
+			if len(conflicts) != len(tc.expected) {
+				t.Fatalf("Expected %d conflicts, got %d: %v", len(tc.expected), len(conflicts), conflicts)
+			}
+			for i, expected := range tc.expected {
+				conflict := conflicts[i]
+				if conflict.Kind != expected.kind || conflict.Chain != expected.chain || conflict.Position != expected.position {
+					t.Errorf("Expected %s conflict at chain %d position %d, got %s at chain %d position %d",
+						expected.kind, expected.chain, expected.position, conflict.Kind, conflict.Chain, conflict.Position)
+				}
+				if len(conflict.Modifications) != 2 {
+					t.Errorf("Expected 2 conflicting modifications, got %d", len(conflict.Modifications))
+				}
+			}
+		})
+	}
+}
+
+func TestGetInterChainCrosslinks(t *testing.T) {
+	seq, err := FromProforma("EVQLC[Disulfide#XL1]PEC[Disulfide#XL2]K//DIQMC[#XL1]TQ//SEC[#XL2]AC[#XL2]")
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+
+	links := seq.GetInterChainCrosslinks()
+	if len(links) != 2 {
+		t.Fatalf("Expected 2 inter-chain crosslinks, got %d", len(links))
+	}
+
+	expected := map[string][]ChainResidue{
+		"XL1": {{ChainIndex: 0, Position: 4}, {ChainIndex: 1, Position: 4}},
+		"XL2": {{ChainIndex: 0, Position: 7}, {ChainIndex: 2, Position: 2}, {ChainIndex: 2, Position: 4}},
+	}
+	for id, residues := range expected {
+		got := links[id]
+		if len(got) != len(residues) {
+			t.Errorf("Expected %v for %s, got %v", residues, id, got)
+			continue
+		}
+		for i := range residues {
+			if got[i] != residues[i] {
+				t.Errorf("Expected %v for %s, got %v", residues, id, got)
+				break
+			}
+		}
+	}
+
+	t.Run("intra-chain crosslinks are omitted", func(t *testing.T) {
+		seq, err := FromProforma("PEC[Disulfide#XL1]TC[#XL1]IDE//SEQUENCE")
+		if err != nil {
+			t.Fatalf("Failed to parse: %v", err)
+		}
+		if len(seq.GetInterChainCrosslinks()) != 0 {
+			t.Errorf("Expected no inter-chain crosslinks")
+		}
+	})
+
+	t.Run("single chain", func(t *testing.T) {
+		seq, err := FromProforma("PEC[Disulfide#XL1]TC[#XL1]IDE")
+		if err != nil {
+			t.Fatalf("Failed to parse: %v", err)
+		}
+		if len(seq.GetInterChainCrosslinks()) != 0 {
+			t.Errorf("Expected no inter-chain crosslinks for a single chain")
+		}
+	})
+}
+
+func TestSequenceEqualIgnoringModOrder(t *testing.T) {
+	tests := []struct {
+		name          string
+		a             string
+		b             string
+		strictEqual   bool
+		ignoringOrder bool
+	}{
+		{"identical", "PEPS[Phospho][Acetyl]TIDE", "PEPS[Phospho][Acetyl]TIDE", true, true},
+		{"reordered mods", "PEPS[Phospho][Acetyl]TIDE", "PEPS[Acetyl][Phospho]TIDE", false, true},
+		{"different mods", "PEPS[Phospho][Acetyl]TIDE", "PEPS[Phospho][Methyl]TIDE", false, false},
+		{"repeated mod counts differ", "PEPS[Phospho][Phospho][Acetyl]TIDE", "PEPS[Phospho][Acetyl][Acetyl]TIDE", false, false},
+		{"mod on different residue", "PEPS[Phospho]TIDE", "PEPST[Phospho]IDE", false, false},
+		{"different residues", "PEPTIDE", "PEPTIDA", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := FromProforma(tt.a)
+			if err != nil {
+				t.Fatalf("Failed to parse '%s': %v", tt.a, err)
+			}
+			b, err := FromProforma(tt.b)
+			if err != nil {
+				t.Fatalf("Failed to parse '%s': %v", tt.b, err)
+			}
+
+			if a.Equal(b) != tt.strictEqual {
+				t.Errorf("Expected Equal to be %v", tt.strictEqual)
+			}
+			if a.EqualIgnoringModOrder(b) != tt.ignoringOrder {
+				t.Errorf("Expected EqualIgnoringModOrder to be %v", tt.ignoringOrder)
+			}
+			if b.EqualIgnoringModOrder(a) != tt.ignoringOrder {
+				t.Errorf("Expected EqualIgnoringModOrder to be symmetric")
+			}
+		})
+	}
+
+	seq, _ := FromProforma("PEPTIDE")
+	if seq.EqualIgnoringModOrder(nil) {
+		t.Errorf("Expected comparison with nil to be false")
+	}
+}
+
+func TestMapToProtein(t *testing.T) {
+	tests := []struct {
+		name     string
+		peptide  string
+		protein  string
+		classes  []IsobaricClass
+		expected []int
+	}{
+		{"single match", "PEPT[Phospho]IDE", "MKPEPTIDEK", nil, []int{2}},
+		{"repeated matches", "[Acetyl]-PEP", "PEPAPEPEP", nil, []int{0, 4, 6}},
+		{"not found", "PEPTIDE", "MKPEPTLDEK", nil, []int{}},
+		{"longer than the protein", "PEPTIDEK", "PEPTIDE", nil, []int{}},
+		{"leucine equivalent", "PEPTIDE", "MKPEPTLDEK", []IsobaricClass{IsobaricLeucine}, []int{2}},
+		{"isoleucine equivalent", "LEAK", "IEAKLEAK", []IsobaricClass{IsobaricLeucine}, []int{0, 4}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			peptide, err := FromProforma(tt.peptide)
+			if err != nil {
+				t.Fatalf("Failed to parse: %v", err)
+			}
+			offsets := MapToProtein(peptide, tt.protein, tt.classes...)
+			if offsets == nil || len(offsets) != len(tt.expected) {
+				t.Fatalf("Expected %v, got %v", tt.expected, offsets)
+			}
+			for i, offset := range tt.expected {
+				if offsets[i] != offset {
+					t.Errorf("Expected %v, got %v", tt.expected, offsets)
+				}
+			}
+		})
+	}
+}
+
+func TestEqualIsobaric(t *testing.T) {
+	tests := []struct {
+		name     string
+		a        string
+		b        string
+		classes  []IsobaricClass
+		expected bool
+	}{
+		{"identical", "PEPTIDE", "PEPTIDE", nil, true},
+		{"I and L", "PEPTIDE", "PEPTLDE", nil, true},
+		{"I and L with mods", "PEPT[Phospho]IDE", "PEPT[Phospho]LDE", nil, true},
+		{"modified I and L", "PEPTI[Oxidation]DE", "PEPTL[Oxidation]DE", nil, true},
+		{"different mods on I and L", "PEPTI[Oxidation]DE", "PEPTL[Methyl]DE", nil, false},
+		{"mod on different residue", "PEPT[Phospho]IDE", "PEPTLD[Phospho]E", nil, false},
+		{"K and Q not equivalent by default", "PEPTIDEK", "PEPTIDEQ", nil, false},
+		{"K and Q at low resolution", "PEPTIDEK", "PEPTLDEQ", []IsobaricClass{IsobaricLysineGlutamine}, true},
+		{"other residues", "PEPTIDE", "PEPTADE", []IsobaricClass{IsobaricLysineGlutamine}, false},
+		{"different lengths", "PEPTIDE", "PEPTLDEK", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := FromProforma(tt.a)
+			if err != nil {
+				t.Fatalf("Failed to parse '%s': %v", tt.a, err)
+			}
+			b, err := FromProforma(tt.b)
+			if err != nil {
+				t.Fatalf("Failed to parse '%s': %v", tt.b, err)
+			}
+
+			if EqualIsobaric(a, b, tt.classes...) != tt.expected {
+				t.Errorf("Expected EqualIsobaric to be %v", tt.expected)
+			}
+			if EqualIsobaric(b, a, tt.classes...) != tt.expected {
+				t.Errorf("Expected EqualIsobaric to be symmetric")
+			}
+			if tt.a != tt.b && a.Equal(b) {
+				t.Errorf("Expected strict Equal to be false")
+			}
+		})
+	}
+
+	seq, _ := FromProforma("PEPTIDE")
+	if EqualIsobaric(seq, nil) {
+		t.Errorf("Expected comparison with nil to be false")
+	}
+}
+
+func TestFindNGlycosylationSequons(t *testing.T) {
+	tests := []struct {
+		proforma string
+		expected []int
+	}{
+		{"PENGTNPSNNST", []int{2, 8, 9}},
+		{"NAS", []int{0}},
+		{"NPS", []int{}},
+		{"NNNST", []int{1, 2}},
+		{"PEPTIDEN", []int{}},
+		{"N[Glycan:HexNAc2]GT", []int{0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.proforma, func(t *testing.T) {
+			seq, err := FromProforma(tt.proforma)
+			if err != nil {
+				t.Fatalf("Failed to parse: %v", err)
+			}
+			positions := seq.FindNGlycosylationSequons()
+			if len(positions) != len(tt.expected) {
+				t.Fatalf("Expected %v, got %v", tt.expected, positions)
+			}
+			for i, position := range tt.expected {
+				if positions[i] != position {
+					t.Errorf("Expected %v, got %v", tt.expected, positions)
+				}
+			}
+		})
+	}
+}
+
+func TestFindNGlycansOffSequon(t *testing.T) {
+	tests := []struct {
+		proforma string
+		expected []int
+	}{
+		{"PEN[Glycan:HexNAc2Hex5]GTN[Glycan:HexNAc]PS", []int{5}},
+		{"N[GNO:G59626AS]PS", []int{0}},
+		{"N[Glycan:HexNAc]AS", []int{}},
+		{"PEPT[Glycan:HexNAc]N[Deamidated]", []int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.proforma, func(t *testing.T) {
+			seq, err := FromProforma(tt.proforma)
+			if err != nil {
+				t.Fatalf("Failed to parse: %v", err)
+			}
+			positions := seq.FindNGlycansOffSequon()
+			if len(positions) != len(tt.expected) {
+				t.Fatalf("Expected %v, got %v", tt.expected, positions)
+			}
+			for i, position := range tt.expected {
+				if positions[i] != position {
+					t.Errorf("Expected %v, got %v", tt.expected, positions)
+				}
+			}
+		})
+	}
+}
+
+func TestGetModificationsByType(t *testing.T) {
+	seq, err := FromProforma("[Phospho]?{Glycan:Hex}[Acetyl]-PEPS[Phospho]TIDE-[Amidated]")
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+
+	terminal := seq.GetModificationsByType("terminal")
+	if len(terminal) != 2 {
+		t.Fatalf("Expected 2 terminal modifications, got %d", len(terminal))
+	}
+	if terminal[0].Side != TerminalSideNTerm || terminal[0].Position != -1 || terminal[0].Modification.GetValue() != "Acetyl" {
+		t.Errorf("Expected N-terminal Acetyl, got %v %d %s", terminal[0].Side, terminal[0].Position, terminal[0].Modification.GetValue())
+	}
+	if terminal[1].Side != TerminalSideCTerm || terminal[1].Position != -2 || terminal[1].Modification.GetValue() != "Amidated" {
+		t.Errorf("Expected C-terminal Amidated, got %v %d %s", terminal[1].Side, terminal[1].Position, terminal[1].Modification.GetValue())
+	}
+
+	all := seq.GetModificationsByType("")
+	expectedSides := []TerminalSide{TerminalSideUnknown, TerminalSideLabile, TerminalSideNTerm, TerminalSideResidue, TerminalSideCTerm}
+	if len(all) != len(expectedSides) {
+		t.Fatalf("Expected %d modifications, got %d", len(expectedSides), len(all))
+	}
+	for i, side := range expectedSides {
+		if all[i].Side != side {
+			t.Errorf("Expected side '%s' at index %d, got '%s'", side, i, all[i].Side)
+		}
+	}
+	if all[3].Position != 3 {
+		t.Errorf("Expected residue modification at position 3, got %d", all[3].Position)
+	}
+
+	data, err := json.Marshal(terminal[0])
+	if err != nil {
+		t.Fatalf("Failed to marshal location: %v", err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Failed to unmarshal location: %v", err)
+	}
+	if decoded["side"] != "n-term" {
+		t.Errorf("Expected side 'n-term' in JSON, got %v", decoded["side"])
+	}
+	if decoded["position"] != float64(-1) {
+		t.Errorf("Expected position -1 in JSON, got %v", decoded["position"])
+	}
+}
+
+func TestSequenceCountModifications(t *testing.T) {
+	testCases := []struct {
+		input           string
+		expectedTotal   int
+		expectedResidue int
+	}{
+		{"PEPTIDE", 0, 0},
+		{"<[Carbamidomethyl]@C>[Acetyl]-PEPS[Phospho]C(IDE)[+1]", 4, 2},
+		{"[Phospho]?{Hex}PEPTIDE-[Amidated]", 3, 0},
+		{"PEPS[Phospho#g1]T[#g1]IDE", 1, 1},
+		{"PEPC[Disulfide#XL1]TIDE//SEC[#XL1]K[Acetyl]", 2, 2},
+		{"PEPT[Phospho]IDE+ELVIS[Oxidation]K", 2, 2},
+		{"<[Carbamidomethyl]@C>PEPC//<[Oxidation]@M>ELMC", 2, 0},
+		{"<[Carbamidomethyl]@C>PEPC+ELMC[Oxidation]", 2, 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			seq, err := FromProforma(tc.input)
+			if err != nil {
+				t.Fatalf("Failed to parse '%s': %v", tc.input, err)
+			}
+			if seq.CountModifications() != tc.expectedTotal {
+				t.Errorf("Expected %d modifications, got %d", tc.expectedTotal, seq.CountModifications())
+			}
+			if seq.CountResidueModifications() != tc.expectedResidue {
+				t.Errorf("Expected %d residue modifications, got %d", tc.expectedResidue, seq.CountResidueModifications())
+			}
+		})
+	}
+}
+
+func TestGetModifiedPositions(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected []int
+	}{
+		{"ELVIS[Phospho]K", []int{4}},
+		{"PEPTIDE", []int{}},
+		{"[Acetyl]-PEPTIDE-[Amidated]", []int{}},
+		{"[Phospho]?{Hex}PEPTIDE", []int{}},
+		{"M[Oxidation]PEPS[Phospho][+1]TIDE", []int{0, 4}},
+		{"(PEP)[+79.966]TIDE", []int{0, 1, 2}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			seq, err := FromProforma(tc.input)
+			if err != nil {
+				t.Fatalf("Failed to parse '%s': %v", tc.input, err)
+			}
+			positions := seq.GetModifiedPositions()
+			if len(positions) != len(tc.expected) {
+				t.Fatalf("Expected %v, got %v", tc.expected, positions)
+			}
+			for i, position := range tc.expected {
+				if positions[i] != position {
+					t.Errorf("Expected %v, got %v", tc.expected, positions)
+				}
+			}
+		})
+	}
+}
+
+func TestGetObservedMasses(t *testing.T) {
+	proforma := "[Acetyl|Obs:+42.01]-ELVIS[U:Phospho|Obs:+79.978]KT[Obs:-17.03]"
+	seq, err := FromProforma(proforma)
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+
+	sites := seq.GetObservedMasses()
+	expected := []struct {
+		position int
+		side     TerminalSide
+		mass     float64
+	}{
+		{-1, TerminalSideNTerm, 42.01},
+		{4, TerminalSideResidue, 79.978},
+		{6, TerminalSideResidue, -17.03},
+	}
+	if len(sites) != len(expected) {
+		t.Fatalf("Expected %d observed masses, got %d", len(expected), len(sites))
+	}
+	for i, e := range expected {
+		if sites[i].Position != e.position || sites[i].Side != e.side || sites[i].ObservedMass != e.mass {
+			t.Errorf("Expected %v, got position %d side %s mass %f", e, sites[i].Position, sites[i].Side, sites[i].ObservedMass)
+		}
+	}
+
+	if seq.ToProforma() != proforma {
+		t.Errorf("Roundtrip failed: expected '%s', got '%s'", proforma, seq.ToProforma())
+	}
+
+	plain, _ := FromProforma("ELVIS[Phospho]K")
+	if len(plain.GetObservedMasses()) != 0 {
+		t.Errorf("Expected no observed masses")
+	}
+}
+
+func TestObservedMassOnlyModification(t *testing.T) {
+	tests := []struct {
+		proforma string
+		position int
+		mass     float64
+	}{
+		{"ELVIS[Obs:+79.978]K", 4, 79.978},
+		{"ELVIS[Obs:-18.01]K", 4, -18.01},
+		{"[Obs:+42.01]-PEPTIDE", -1, 42.01},
+		{"PEPTIDE[Obs:-18.011]", 6, -18.011},
+		{"PEPTIDE-[Obs:-0.984]", -2, -0.984},
+		{"[Obs:-42.0100]-PEPTIDE", -1, -42.01},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.proforma, func(t *testing.T) {
+			seq, err := FromProforma(tc.proforma)
+			if err != nil {
+				t.Fatalf("Failed to parse: %v", err)
+			}
+
+			var mods []*Modification
+			if tc.position < 0 {
+				mods = seq.mods[tc.position]
+			} else {
+				mods = seq.seq[tc.position].GetMods()
+			}
+			if len(mods) != 1 {
+				t.Fatalf("Expected 1 modification, got %d", len(mods))
+			}
+
+			observed := mods[0].GetObservedMass()
+			if observed == nil || math.Abs(*observed-tc.mass) > 1e-9 {
+				t.Errorf("Expected observed mass %f, got %v", tc.mass, observed)
+			}
+			if mods[0].GetResolvedMass() != nil {
+				t.Errorf("Expected no resolved mass, got %f", *mods[0].GetResolvedMass())
+			}
+			pipeValues := mods[0].GetModificationValue().GetPipeValues()
+			if len(pipeValues) != 1 || pipeValues[0].GetType() != PipeValueTypeObservedMass {
+				t.Errorf("Expected a single observed mass pipe value, got %d", len(pipeValues))
+			}
+
+			if len(seq.GetObservedMasses()) != 1 {
+				t.Errorf("Expected 1 observed mass site, got %d", len(seq.GetObservedMasses()))
+			}
+			if seq.ToProforma() != tc.proforma {
+				t.Errorf("Roundtrip failed: expected '%s', got '%s'", tc.proforma, seq.ToProforma())
+			}
+		})
+	}
+}
+
+func TestSequenceMatchesPrecursor(t *testing.T) {
+	tests := []struct {
+		proforma     string
+		observedMz   float64
+		charge       int
+		tolerancePpm float64
+		expectMatch  bool
+	}{
+		{"PEPTIDE", 400.6875, 2, 10, true},
+		{"PEPTIDE", 400.6875, 2, 0.1, false},
+		{"PEPTIDE", 800.3672, 1, 10, true},
+		{"EM[Oxidation]EVTSESPEK", 641.2794, 2, 10, true},
+		{"EMEVTSESPEK", 641.2794, 2, 10, false},
+		{"<[Carbamidomethyl]@C>PEPCIDE", 430.1787, 2, 10, true},
+		{"<[Carbamidomethyl]@C>PEPCIDE", 401.6680, 2, 10, false},
+		{"PRT(ESFRMS)[+19.0523]ISK", 729.4033, 2, 10, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.proforma, func(t *testing.T) {
+			seq, err := FromProforma(tt.proforma)
+			if err != nil {
+				t.Fatalf("Failed to parse: %v", err)
+			}
+			match, ppm := seq.MatchesPrecursor(tt.observedMz, tt.charge, tt.tolerancePpm)
+			if match != tt.expectMatch {
+				t.Errorf("Expected match %v, got %v (ppm error %f)", tt.expectMatch, match, ppm)
+			}
+		})
+	}
+
+	seq, _ := FromProforma("PEPTIDE")
+	mass, err := seq.GetNeutralMass()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if math.Abs(mass-799.35996) > 1e-4 {
+		t.Errorf("Expected neutral mass 799.35996, got %f", mass)
+	}
+	if _, err := seq.GetPrecursorMz(0); err == nil {
+		t.Errorf("Expected error for zero charge")
+	}
+
+	unresolved, _ := FromProforma("PEPT[UnknownModification]IDE")
+	if match, ppm := unresolved.MatchesPrecursor(400.6875, 2, 10); match || !math.IsNaN(ppm) {
+		t.Errorf("Expected no match and NaN for unresolvable modification, got %v %f", match, ppm)
+	}
+}
+
+func TestSequenceGetPrecursorMzWithConfig(t *testing.T) {
+	seq, err := FromProforma("PEPTIDE")
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	mass, _ := seq.GetNeutralMass()
+
+	defaultMz, _ := seq.GetPrecursorMz(2)
+	if configMz, _ := seq.GetPrecursorMzWithConfig(2, DefaultMassConfig()); configMz != defaultMz {
+		t.Errorf("Expected the default configuration to give %f, got %f", defaultMz, configMz)
+	}
+
+	// Adding a hydrogen atom per charge leaves its electron on the ion
+	hydrogenMz, _ := seq.GetPrecursorMzWithConfig(2, MassConfig{ChargeCarrierMass: H})
+	if math.Abs(hydrogenMz-defaultMz-(H-Proton)) > 1e-9 {
+		t.Errorf("Expected a difference of %f, got %f", H-Proton, hydrogenMz-defaultMz)
+	}
+	if math.Abs(hydrogenMz-(mass+2*H)/2) > 1e-9 {
+		t.Errorf("Expected %f, got %f", (mass+2*H)/2, hydrogenMz)
+	}
+
+	// Removing the electron mass brings the hydrogen convention back to the proton
+	correctedMz, _ := seq.GetPrecursorMzWithConfig(2, MassConfig{ChargeCarrierMass: H, ElectronMass: ElectronMass})
+	if math.Abs(hydrogenMz-correctedMz-ElectronMass) > 1e-9 {
+		t.Errorf("Expected the electron to lower the m/z by %f, got %f", ElectronMass, hydrogenMz-correctedMz)
+	}
+	if math.Abs(correctedMz-defaultMz) > 1e-6 {
+		t.Errorf("Expected %f, got %f", defaultMz, correctedMz)
+	}
+
+	if _, err := seq.GetPrecursorMzWithConfig(0, DefaultMassConfig()); err == nil {
+		t.Errorf("Expected an error for charge 0")
+	}
+}
+
+func TestSequenceMzMethodsWithConfig(t *testing.T) {
+	seq, err := FromProforma("PEPTIDE")
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	hydrogen := MassConfig{ChargeCarrierMass: H}
+
+	mzs, err := seq.GetMzForChargesWithConfig(1, 3, hydrogen)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for charge := 1; charge <= 3; charge++ {
+		expected, _ := seq.GetPrecursorMzWithConfig(charge, hydrogen)
+		if mzs[charge] != expected {
+			t.Errorf("Expected %f at charge %d, got %f", expected, charge, mzs[charge])
+		}
+	}
+
+	// The electron left on by the hydrogen convention moves the m/z by about 0.7 ppm
+	hydrogenMz, _ := seq.GetPrecursorMzWithConfig(2, hydrogen)
+	if ok, ppm := seq.MatchesPrecursorWithConfig(hydrogenMz, 2, 0.1, hydrogen); !ok || math.Abs(ppm) > 1e-9 {
+		t.Errorf("Expected an exact match with the same configuration, got %v %f", ok, ppm)
+	}
+	_, defaultPpm := seq.MatchesPrecursor(hydrogenMz, 2, 0.1)
+	if math.Abs(defaultPpm) < 0.1 {
+		t.Errorf("Expected the default configuration to miss by more than 0.1 ppm, got %f", defaultPpm)
+	}
+
+	b, y, err := seq.ComplementaryPairWithConfig(3, 2, hydrogen)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defaultB, defaultY, _ := seq.ComplementaryPair(3, 2)
+	if b.NeutralMass != defaultB.NeutralMass || y.NeutralMass != defaultY.NeutralMass {
+		t.Errorf("Expected the neutral masses not to depend on the configuration")
+	}
+	if math.Abs(b.Mz-hydrogen.Mz(b.NeutralMass, 2)) > 1e-9 || math.Abs(y.Mz-hydrogen.Mz(y.NeutralMass, 2)) > 1e-9 {
+		t.Errorf("Expected m/z %f and %f, got %f and %f", hydrogen.Mz(b.NeutralMass, 2), hydrogen.Mz(y.NeutralMass, 2), b.Mz, y.Mz)
+	}
+	if b.Mz == defaultB.Mz {
+		t.Errorf("Expected the configuration to change the b ion m/z")
+	}
+}
+
+func TestSequenceGetMzForCharges(t *testing.T) {
+	seq, _ := FromProforma("PEPTIDE")
+	mzs, err := seq.GetMzForCharges(2, 4)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(mzs) != 3 {
+		t.Fatalf("Expected 3 charge states, got %d", len(mzs))
+	}
+	for charge := 2; charge <= 4; charge++ {
+		expected, _ := seq.GetPrecursorMz(charge)
+		if math.Abs(mzs[charge]-expected) > 1e-9 {
+			t.Errorf("Expected m/z %f for charge %d, got %f", expected, charge, mzs[charge])
+		}
+	}
+	if math.Abs(mzs[2]-400.6873) > 1e-4 {
+		t.Errorf("Expected m/z 400.6873 for charge 2, got %f", mzs[2])
+	}
+
+	if single, err := seq.GetMzForCharges(3, 3); err != nil || len(single) != 1 {
+		t.Errorf("Expected a single charge state, got %v (%v)", single, err)
+	}
+	for _, charges := range [][2]int{{0, 2}, {3, 2}, {-1, 1}} {
+		if _, err := seq.GetMzForCharges(charges[0], charges[1]); err == nil {
+			t.Errorf("Expected error for charge range %d to %d", charges[0], charges[1])
+		}
+	}
+
+	unresolved, _ := FromProforma("PEPT[UnknownModification]IDE")
+	if _, err := unresolved.GetMzForCharges(2, 4); err == nil {
+		t.Errorf("Expected error for unresolvable modification")
+	}
+}
+
+func TestSequenceTerminalMasses(t *testing.T) {
+	seq, _ := FromProforma("PEPTIDE")
+	if nTerm, cTerm := seq.GetTerminalMasses(); nTerm != H || cTerm != Hydroxyl {
+		t.Errorf("Expected default terminal masses %f and %f, got %f and %f", H, Hydroxyl, nTerm, cTerm)
+	}
+
+	seq.SetTerminalMasses(0, 0)
+	mass, err := seq.GetNeutralMass()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if math.Abs(mass-(799.35996-Water)) > 1e-4 {
+		t.Errorf("Expected cyclic mass %f, got %f", 799.35996-Water, mass)
+	}
+
+	amidatedMod, _ := FromProforma("PEPTIDE-[Amidated]")
+	modMass, err := amidatedMod.GetNeutralMass()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	amidatedGroup, _ := FromProforma("PEPTIDE")
+	amidatedGroup.SetTerminalMasses(H, ElementMass["N"]+2*H)
+	groupMass, err := amidatedGroup.GetNeutralMass()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if math.Abs(modMass-groupMass) > 1e-4 {
+		t.Errorf("Expected amidated group mass %f to match amidated modification mass %f", groupMass, modMass)
+	}
+
+	clone := amidatedGroup.Clone()
+	if _, cTerm := clone.GetTerminalMasses(); cTerm != ElementMass["N"]+2*H {
+		t.Errorf("Expected clone to keep the C-terminal group mass, got %f", cTerm)
+	}
+
+	multi, _ := FromProforma("PEPTIDE//ELVIS")
+	before, err := multi.GetNeutralMass()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	multi.SetTerminalMasses(0, 0)
+	after, err := multi.GetNeutralMass()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if math.Abs(before-after-2*Water) > 1e-6 {
+		t.Errorf("Expected both chains to lose their water, got %f then %f", before, after)
+	}
+	if nTerm, _ := multi.Chain(1).GetTerminalMasses(); nTerm != 0 {
+		t.Errorf("Expected the second chain to get the N-terminal group mass, got %f", nTerm)
+	}
+}
+
+func TestSequenceGetFormulaString(t *testing.T) {
+	tests := []struct {
+		proforma string
+		expected string
+	}{
+		{"PEPTIDE", "C34H53N7O15"},
+		{"PEPS[Formula:HPO3]", "C18H29N4O11P"},
+		{"[Formula:C2H2O]-PEPTIDE", "C36H55N7O16"},
+		{"S[Formula:[13C2]C-2]", "C[13C2]H7NO3"},
+		{"PEPTIDE//ACK", "C46H77N11O19S"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.proforma, func(t *testing.T) {
+			seq, err := FromProforma(tt.proforma)
+			if err != nil {
+				t.Fatalf("Failed to parse: %v", err)
+			}
+			formula, err := seq.GetFormulaString()
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if formula != tt.expected {
+				t.Errorf("Expected '%s', got '%s'", tt.expected, formula)
+			}
+
+			mass, err := seq.GetNeutralMass()
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			formulaMass, err := CalculateFormulaMass(formula)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if math.Abs(mass-formulaMass) > 1e-4 {
+				t.Errorf("Expected formula mass %f to match neutral mass %f", formulaMass, mass)
+			}
+		})
+	}
+
+	seq, _ := FromProforma("PEPTIDE")
+	composition, err := seq.GetComposition()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := map[string]int{"C": 34, "H": 53, "N": 7, "O": 15}
+	if len(composition) != len(expected) {
+		t.Errorf("Expected %v, got %v", expected, composition)
+	}
+	for element, count := range expected {
+		if composition[element] != count {
+			t.Errorf("Expected %d %s, got %d", count, element, composition[element])
+		}
+	}
+
+	global, _ := FromProforma("<[Formula:C2H3NO]@C>CC")
+	if formula, err := global.GetFormulaString(); err != nil || formula != "C10H18N4O5S2" {
+		t.Errorf("Expected 'C10H18N4O5S2', got '%s' (%v)", formula, err)
+	}
+
+	for _, proforma := range []string{"PEPS[+79.966]IDE", "PEPS[Phospho]IDE", "PEPXIDE"} {
+		seq, _ := FromProforma(proforma)
+		if _, err := seq.GetFormulaString(); err == nil {
+			t.Errorf("Expected error for '%s'", proforma)
+		}
+	}
+	seq.SetTerminalMasses(0, 0)
+	if _, err := seq.GetFormulaString(); err == nil {
+		t.Errorf("Expected error for terminal groups set by mass")
+	}
+}
+
+func TestSequenceIsotopeLabelMass(t *testing.T) {
+	unlabeled, _ := FromProforma("PEPTIDE")
+	bare, err := unlabeled.GetNeutralMass()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		proforma string
+		formula  string
+		shift    float64
+	}{
+		{"<15N>PEPTIDE", "C34H53[15N7]O15", 7 * (IsotopeMass["15N"] - ElementMass["N"])},
+		{"<13C>PEPTIDE", "[13C34]H53N7O15", 34 * (IsotopeMass["13C"] - ElementMass["C"])},
+		{"<13C><15N>PEPTIDE", "[13C34]H53[15N7]O15", 34*(IsotopeMass["13C"]-ElementMass["C"]) + 7*(IsotopeMass["15N"]-ElementMass["N"])},
+		{"<D>PEPTIDE", "C34[2H53]N7O15", 53 * (IsotopeMass["2H"] - ElementMass["H"])},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.proforma, func(t *testing.T) {
+			seq, err := FromProforma(tt.proforma)
+			if err != nil {
+				t.Fatalf("Failed to parse: %v", err)
+			}
+			formula, err := seq.GetFormulaString()
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if formula != tt.formula {
+				t.Errorf("Expected '%s', got '%s'", tt.formula, formula)
+			}
+			mass, err := seq.GetNeutralMass()
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if math.Abs(mass-bare-tt.shift) > 1e-4 {
+				t.Errorf("Expected mass shift %f, got %f", tt.shift, mass-bare)
+			}
+		})
+	}
+
+	for _, proforma := range []string{"<15N>PEPS[Phospho]TIDE", "<15N>PEPS[+79.966]TIDE"} {
+		seq, _ := FromProforma(proforma)
+		if _, err := seq.GetNeutralMass(); err == nil {
+			t.Errorf("Expected error for '%s'", proforma)
+		}
+	}
+}
+
+func TestSequenceMultipleIsotopeLabels(t *testing.T) {
+	separate, _ := FromProforma("<15N><13C>PEPTIDE")
+	expected, err := separate.GetNeutralMass()
+	if err != nil {
+		t.Fatalf("Failed to calculate mass: %v", err)
+	}
+
+	seq, err := FromProforma("<15N,13C>PEPTIDE")
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	if len(seq.GetGlobalMods()) != 1 {
+		t.Fatalf("Expected 1 global modification, got %d", len(seq.GetGlobalMods()))
+	}
+	labels := seq.GetGlobalMods()[0].GetIsotopeLabels()
+	if len(labels) != 2 || labels[0] != "15N" || labels[1] != "13C" {
+		t.Errorf("Expected labels [15N 13C], got %v", labels)
+	}
+	if seq.ToProforma() != "<15N,13C>PEPTIDE" {
+		t.Errorf("Expected '<15N,13C>PEPTIDE', got '%s'", seq.ToProforma())
+	}
+
+	mass, err := seq.GetNeutralMass()
+	if err != nil {
+		t.Fatalf("Failed to calculate mass: %v", err)
+	}
+	if math.Abs(mass-expected) > 1e-9 {
+		t.Errorf("Expected mass %f, got %f", expected, mass)
+	}
+	formula, _ := seq.GetFormulaString()
+	if formula != "[13C34]H53[15N7]O15" {
+		t.Errorf("Expected '[13C34]H53[15N7]O15', got '%s'", formula)
+	}
+
+	fixed, _ := FromProforma("<[Carbamidomethyl]@C>PEPTCDE")
+	if labels := fixed.GetGlobalMods()[0].GetIsotopeLabels(); labels != nil {
+		t.Errorf("Expected no labels for a fixed modification, got %v", labels)
+	}
+}
+
+func TestSequenceSummary(t *testing.T) {
+	tests := []struct {
+		proforma string
+		expected string
+	}{
+		{"PEPTIDE", "Sequence: PEPTIDE\nLength: 7\nModifications: 0\n"},
+		{
+			"<[Carbamidomethyl]@C>[Acetyl]-PEPC[Phospho]IDE/2[+Na+]",
+			"Sequence: PEPCIDE\nLength: 7\nCharge: 2\nIonic species: +Na+\nModifications: 2 (static: 1, terminal: 1)\nGlobal modifications: <[Carbamidomethyl]@C>\n",
+		},
+		{
+			"PR[Methyl]T(EP)[Phospho]IDE",
+			"Sequence: PRTEPIDE\nLength: 8\nModifications: 2 (ambiguous: 1, static: 1)\n",
+		},
+		{
+			"PEPTIDE/2+ANOTHER/3",
+			"Sequence: PEPTIDE+ANOTHER\nLength: 14\nCharge: 2, 3\nModifications: 0\n",
+		},
+		{
+			"(>>>mix)(>>ion)(>name)PEPT[Phospho]IDE//ANOTHER/2",
+			"Sequence: PEPTIDE//ANOTHER\nLength: 14\nCharge: 2\nModifications: 1 (static: 1)\nPeptidoform name: name\nPeptidoform ion name: ion\nCompound ion name: mix\n",
+		},
+		{
+			"PEPTIDE/2+(>b)ELVIS[Oxidation]/3",
+			"Sequence: PEPTIDE+ELVIS\nLength: 12\nCharge: 2, 3\nModifications: 1 (static: 1)\nPeptidoform name: b\n",
+		},
+		{
+			"(>>>mix)(>>ion)(>a)PEPTIDE//(>b)ANOTHER/2",
+			"Sequence: PEPTIDE//ANOTHER\nLength: 14\nCharge: 2\nModifications: 0\nPeptidoform name: a, b\nPeptidoform ion name: ion\nCompound ion name: mix\n",
+		},
+		{
+			"(>>i1)PEP/2+(>>i2)ELV/3",
+			"Sequence: PEP+ELV\nLength: 6\nCharge: 2, 3\nModifications: 0\nPeptidoform ion name: i1, i2\n",
+		},
+		{
+			"<[Carbamidomethyl]@C>PEPC//<[Oxidation]@M>ELMC",
+			"Sequence: PEPC//ELMC\nLength: 8\nModifications: 0\nGlobal modifications: <[Carbamidomethyl]@C><[Oxidation]@M>\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.proforma, func(t *testing.T) {
+			seq, err := FromProforma(tt.proforma)
+			if err != nil {
+				t.Fatalf("Failed to parse: %v", err)
+			}
+			if summary := seq.Summary(); summary != tt.expected {
+				t.Errorf("Expected '%s', got '%s'", tt.expected, summary)
+			}
+		})
+	}
+}
+
+func TestSequenceGRAVY(t *testing.T) {
+	tests := []struct {
+		proforma string
+		expected float64
+	}{
+		{"PEPTIDE", -9.9 / 7},
+		{"ILV", (4.5 + 3.8 + 4.2) / 3},
+		{"PEPS[Phospho]TIDE", -10.7 / 8},
+		{"AXA", 1.8},
+		{"XX", 0},
+		{"RKDE", (-4.5 - 3.9 - 3.5 - 3.5) / 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.proforma, func(t *testing.T) {
+			seq, err := FromProforma(tt.proforma)
+			if err != nil {
+				t.Fatalf("Failed to parse: %v", err)
+			}
+			if gravy := seq.GRAVY(); math.Abs(gravy-tt.expected) > 1e-9 {
+				t.Errorf("Expected GRAVY %f, got %f", tt.expected, gravy)
+			}
+		})
+	}
+}
+
+func TestSequenceIsoelectricPoint(t *testing.T) {
+	tests := []struct {
+		proforma    string
+		expected    float64
+		withPhospho float64
+	}{
+		{"PEPTIDE", 3.424, 3.424},
+		{"PEPTIDEK", 3.928, 3.928},
+		{"KKKK", 11.278, 11.278},
+		{"GG", 6.1, 6.1},
+		{"PEPS[Phospho]TIDEK", 3.928, 3.428},
+		{"PEPS[UNIMOD:21]TIDEK", 3.928, 3.428},
+		{"PEPS[+79.966]TIDEK", 3.928, 3.428},
+		{"[Phospho]?PEPSTIDEK", 3.928, 3.428},
+		{"PEPS[Oxidation]TIDEK", 3.928, 3.928},
+		{"PEPS[Sulfo]TIDEK", 3.928, 3.928},
+		{"PEPS[+79.957]TIDEK", 3.928, 3.928},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.proforma, func(t *testing.T) {
+			seq, err := FromProforma(tt.proforma)
+			if err != nil {
+				t.Fatalf("Failed to parse: %v", err)
+			}
+			if pI := seq.IsoelectricPoint(); math.Abs(pI-tt.expected) > 0.002 {
+				t.Errorf("Expected pI %.3f, got %.3f", tt.expected, pI)
+			}
+			if pI := seq.IsoelectricPointWithModifications(); math.Abs(pI-tt.withPhospho) > 0.002 {
+				t.Errorf("Expected pI with modifications %.3f, got %.3f", tt.withPhospho, pI)
+			}
+		})
+	}
+}
+
+func TestSequenceCountChargedResidues(t *testing.T) {
+	tests := []struct {
+		proforma string
+		basic    int
+		acidic   int
+	}{
+		{"PEPTIDE", 0, 3},
+		{"KRHDE", 3, 2},
+		{"PEPK[Acetyl]RHIDE", 3, 3},
+		{"PEPS[Phospho]T[Phospho]IDE", 0, 3},
+		{"GGGG", 0, 0},
+		{"ELVISK//RRDE", 3, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.proforma, func(t *testing.T) {
+			seq, err := FromProforma(tt.proforma)
+			if err != nil {
+				t.Fatalf("Failed to parse: %v", err)
+			}
+			if got := seq.CountBasicResidues(); got != tt.basic {
+				t.Errorf("Expected %d basic residues, got %d", tt.basic, got)
+			}
+			if got := seq.CountAcidicResidues(); got != tt.acidic {
+				t.Errorf("Expected %d acidic residues, got %d", tt.acidic, got)
+			}
+		})
+	}
+}
+
+func TestDiffSequences(t *testing.T) {
+	type expectedDiff struct {
+		kind     SequenceDiffKind
+		position int
+	}
+	tests := []struct {
+		name     string
+		a        string
+		b        string
+		expected []expectedDiff
+	}{
+		{
+			name: "Identical sequences",
+			a:    "PEPS[Phospho]IDE/2",
+			b:    "PEPS[Phospho]IDE/2",
+		},
+		{
+			name: "Modification order is ignored",
+			a:    "PEPS[Phospho][Acetyl]IDE",
+			b:    "PEPS[Acetyl][Phospho]IDE",
+		},
+		{
+			name: "Substitution, terminal modification and charge",
+			a:    "PEPTIDE/2",
+			b:    "[Acetyl]-PEPS[Phospho]IDE/3",
+			expected: []expectedDiff{
+				{SequenceDiffModificationAdded, -1},
+				{SequenceDiffSubstitution, 3},
+				{SequenceDiffModificationAdded, 3},
+				{SequenceDiffChargeChanged, 0},
+			},
+		},
+		{
+			name: "Length mismatch and removed modifications",
+			a:    "PEPTIDEK[Oxidation]-[Amidated]",
+			b:    "PEPT[Phospho]IDE",
+			expected: []expectedDiff{
+				{SequenceDiffModificationAdded, 3},
+				{SequenceDiffLengthMismatch, 7},
+				{SequenceDiffModificationRemoved, 7},
+				{SequenceDiffModificationRemoved, -2},
+			},
+		},
+		{
+			name: "Changed modification",
+			a:    "PEPM[Oxidation]IDE",
+			b:    "PEPM[Dioxidation]IDE",
+			expected: []expectedDiff{
+				{SequenceDiffModificationRemoved, 3},
+				{SequenceDiffModificationAdded, 3},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := FromProforma(tt.a)
+			if err != nil {
+				t.Fatalf("Failed to parse '%s': %v", tt.a, err)
+			}
+			b, err := FromProforma(tt.b)
+			if err != nil {
+				t.Fatalf("Failed to parse '%s': %v", tt.b, err)
+			}
+
+			diffs := DiffSequences(a, b)
+			if len(diffs) != len(tt.expected) {
+				t.Fatalf("Expected %d diffs, got %d: %v", len(tt.expected), len(diffs), diffs)
+			}
+			for i, e := range tt.expected {
+				if diffs[i].Kind != e.kind || diffs[i].Position != e.position {
+					t.Errorf("Expected %s at %d, got %s at %d", e.kind, e.position, diffs[i].Kind, diffs[i].Position)
+				}
+				if diffs[i].Description == "" {
+					t.Errorf("Expected a description for diff %d", i)
+				}
+			}
+		})
+	}
+}
+
+func TestSequenceToProformaWithOptions(t *testing.T) {
+	precision := func(p int) *int { return &p }
+
+	tests := []struct {
+		name     string
+		proforma string
+		opts     ProformaOptions
+		expected string
+	}{
+		{
+			name:     "Zero options normalize masses",
+			proforma: "PEPTX[-10.0]IDE",
+			opts:     ProformaOptions{},
+			expected: "PEPTX[-10]IDE",
+		},
+		{
+			name:     "Fixed mass precision",
+			proforma: "PEPTX[-10.0]IDE",
+			opts:     ProformaOptions{MassPrecision: precision(1)},
+			expected: "PEPTX[-10.0]IDE",
+		},
+		{
+			name:     "Mass precision rounds",
+			proforma: "[+42.010565]-PEPT[+79.966331]IDE",
+			opts:     ProformaOptions{MassPrecision: precision(2)},
+			expected: "[+42.01]-PEPT[+79.97]IDE",
+		},
+		{
+			name:     "Mass precision applies to pipe masses",
+			proforma: "PEPT[U:Phospho|+79.966331]IDE",
+			opts:     ProformaOptions{MassPrecision: precision(3)},
+			expected: "PEPT[U:Phospho|+79.966]IDE",
+		},
+		{
+			name:     "Preserve original mass formatting",
+			proforma: "PEPT[+79.9660]IDEX[-10.00]",
+			opts:     ProformaOptions{PreserveMassFormatting: true, MassPrecision: precision(1)},
+			expected: "PEPT[+79.9660]IDEX[-10.00]",
+		},
+		{
+			name:     "Default options preserve masses",
+			proforma: "PEPTX[-10.0]IDE",
+			opts:     DefaultProformaOptions(),
+			expected: "PEPTX[-10.0]IDE",
+		},
+		{
+			name:     "Sort modifications",
+			proforma: "[Formyl][Acetyl]-PEPS[Phospho][Methyl]IDE-[Methyl][Amidated]",
+			opts:     ProformaOptions{SortModifications: true},
+			expected: "[Acetyl][Formyl]-PEPS[Methyl][Phospho]IDE-[Amidated][Methyl]",
+		},
+		{
+			name:     "Sort keeps labile order",
+			proforma: "{Hex}{Fuc}[Formyl][Acetyl]-PEPTIDE",
+			opts:     ProformaOptions{SortModifications: true},
+			expected: "{Hex}{Fuc}[Acetyl][Formyl]-PEPTIDE",
+		},
+		{
+			name:     "Compact glycan notation",
+			proforma: "EN[Glycan:HexNAc(2)Hex(5)Fuc]K",
+			opts:     ProformaOptions{GlycanNotation: GlycanNotationCompact},
+			expected: "EN[Glycan:Fuc1Hex5HexNAc2]K",
+		},
+		{
+			name:     "Expanded glycan notation",
+			proforma: "EN[Glycan:HexNAc2Hex5Fuc]K",
+			opts:     ProformaOptions{GlycanNotation: GlycanNotationExpanded},
+			expected: "EN[Glycan:Fuc(1)Hex(5)HexNAc(2)]K",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seq, err := FromProforma(tt.proforma)
+			if err != nil {
+				t.Fatalf("Failed to parse ProForma '%s': %v", tt.proforma, err)
+			}
+			result := seq.ToProformaWithOptions(tt.opts)
+			if result != tt.expected {
+				t.Errorf("Expected '%s', got '%s'", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestMassFormattingRoundtrip(t *testing.T) {
+	tests := []string{
+		"PEPT[+79.9660]IDE",
+		"PEPT[+79.966]IDE",
+		"[+42.0100]-PEPTIDE-[-0.9840]",
+		"PEPT[U:Phospho|+79.96633]IDE",
+		"RTAAX[+367.0537]WT",
+	}
+
+	for _, proforma := range tests {
+		t.Run(proforma, func(t *testing.T) {
+			seq, err := FromProforma(proforma)
+			if err != nil {
+				t.Fatalf("Failed to parse ProForma '%s': %v", proforma, err)
+			}
+			if seq.ToProforma() != proforma {
+				t.Errorf("Expected '%s', got '%s'", proforma, seq.ToProforma())
+			}
+		})
+	}
+
+	seq, _ := FromProforma("PEPT[+79.9660]IDE")
+	for _, pv := range seq.GetSeq()[3].GetMods()[0].GetModificationValue().GetPipeValues() {
+		if pv.GetType() == PipeValueTypeMass {
+			pv.SetMass(80.5)
+		}
+	}
+	if seq.ToProforma() != "PEPT[+80.5]IDE" {
+		t.Errorf("Expected modified mass to be reformatted, got '%s'", seq.ToProforma())
+	}
+}
+
+func TestAnnotateMassShifts(t *testing.T) {
+	dict := map[string]float64{
+		"Phospho":   79.966331,
+		"Sulfo":     79.956815,
+		"Oxidation": 15.994915,
+		"Acetyl":    42.010565,
+	}
+
+	seq, err := FromProforma("[+42.011]-PEPS[+79.966]TM[Oxidation]K[Obs:+15.995]IDE[U:+100.0]")
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+
+	annotations := seq.AnnotateMassShifts(dict, 0.02)
+	expected := []struct {
+		position   int
+		side       TerminalSide
+		delta      float64
+		candidates []string
+	}{
+		{-1, TerminalSideNTerm, 42.011, []string{"Acetyl"}},
+		{3, TerminalSideResidue, 79.966, []string{"Phospho", "Sulfo"}},
+		{6, TerminalSideResidue, 15.995, []string{"Oxidation"}},
+		{9, TerminalSideResidue, 100.0, []string{}},
+	}
+	if len(annotations) != len(expected) {
+		t.Fatalf("Expected %d annotations, got %d", len(expected), len(annotations))
+	}
+	for i, exp := range expected {
+		annotation := annotations[i]
+		if annotation.Position != exp.position || annotation.Side != exp.side {
+			t.Errorf("Expected position %d (%s), got %d (%s)", exp.position, exp.side, annotation.Position, annotation.Side)
+		}
+		if annotation.Delta != exp.delta {
+			t.Errorf("Expected delta %f, got %f", exp.delta, annotation.Delta)
+		}
+		if len(annotation.Candidates) != len(exp.candidates) {
+			t.Errorf("Expected candidates %v, got %v", exp.candidates, annotation.Candidates)
+			continue
+		}
+		for j, name := range exp.candidates {
+			candidate := annotation.Candidates[j]
+			if candidate.Name != name {
+				t.Errorf("Expected candidate '%s', got '%s'", name, candidate.Name)
+			}
+			if math.Abs(candidate.Error-(exp.delta-dict[name])) > 1e-9 {
+				t.Errorf("Expected error %f, got %f", exp.delta-dict[name], candidate.Error)
+			}
+		}
+	}
+
+	if annotations := seq.AnnotateMassShifts(dict, 0.001); len(annotations[1].Candidates) != 1 {
+		t.Errorf("Expected only Phospho within 0.001 Da, got %v", annotations[1].Candidates)
+	}
+
+	named, _ := FromProforma("PEPS[Phospho]TIDE")
+	if annotations := named.AnnotateMassShifts(dict, 0.02); len(annotations) != 0 {
+		t.Errorf("Expected no annotations for named modifications, got %v", annotations)
+	}
+}
+
+func TestCountModificationsWithMass(t *testing.T) {
+	tests := []struct {
+		proforma    string
+		target      float64
+		toleranceDa float64
+		expected    int
+	}{
+		{"PEPS[Phospho]T[+79.966]IDE", 79.966, 0.01, 2},
+		{"PEPS[U:Phospho]T[+79.966]IDE", 79.966, 0.0001, 1},
+		{"[Acetyl]-PEPK[Acetyl]TIDE-[Amidated]", 42.0106, 0.001, 2},
+		{"PEPTIDE-[Amidated]", -0.984, 0.001, 1},
+		{"PRT(ESFRMS)[+19.0523]ISK", 19.0523, 0.001, 1},
+		{"EM[Oxidation#g1]EVT[#g1]S", 15.9949, 0.001, 1},
+		{"{Phospho}[Phospho]?PEPTIDE", 79.966, 0.01, 0},
+		{"PEPT[UnknownModification]IDE", 79.966, 0.01, 0},
+		{"PEPTIDE//PEPS[Phospho]K", 79.966, 0.01, 1},
+		{"PEPT[Phospho]IDE+PEPS[Phospho]K", 79.966, 0.01, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.proforma, func(t *testing.T) {
+			seq, err := FromProforma(tt.proforma)
+			if err != nil {
+				t.Fatalf("Failed to parse ProForma '%s': %v", tt.proforma, err)
+			}
+			count := seq.CountModificationsWithMass(tt.target, tt.toleranceDa)
+			if count != tt.expected {
+				t.Errorf("Expected %d, got %d", tt.expected, count)
+			}
+		})
+	}
+}
+
+func TestSequenceIsFullyLocalized(t *testing.T) {
+	tests := []struct {
+		proforma string
+		expected bool
+	}{
+		{"[Acetyl]-PEPT[Phospho]IDEK[XL:DSS#XL1]K[#XL1]-[Amidated]", true},
+		{"<[Carbamidomethyl]@C>{Glycan:Hex}PEPCTIDE", true},
+		{"PEPS{Phospho}TIDE", false},
+		{"EM[Oxidation#g1]EVT[#g1]S", false},
+		{"PRT(ESFRMS)[+19.0523]ISK", false},
+		{"[Phospho]?PEPTIDE", false},
+		{"(?DQ)NGTWEMESNENFEGYMK", false},
+		{"PEPT[Phospho]IDE//PEPS{Phospho}K", false},
+		{"PEPT[Phospho]IDE+PEPS{Phospho}K", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.proforma, func(t *testing.T) {
+			seq, err := FromProforma(tt.proforma)
+			if err != nil {
+				t.Fatalf("Failed to parse ProForma '%s': %v", tt.proforma, err)
+			}
+			if result := seq.IsFullyLocalized(); result != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestSequenceGetAllMassShifts(t *testing.T) {
+	tests := []struct {
+		proforma string
+		expected []float64
+	}{
+		{"[Acetyl]-PEPS[+79.966]M[Oxidation]K[Custom]", []float64{42.010565, 79.966, 15.994915}},
+		{"PEPS[Formula:HPO3]TIDE-[-0.984]", []float64{79.966331, -0.984}},
+		{"PRT(ESFRMS)[+19.0523]ISK", []float64{19.0523}},
+		{"EM[Oxidation#g1]EVT[#g1]S", []float64{15.994915}},
+		{"<[Carbamidomethyl]@C>{Hex}[Phospho]?PEPTIDE", []float64{}},
+		{"PEPS[+1]K//PEPT[+2]K", []float64{1, 2}},
+		{"PEPTIDE+PEPS[Phospho]K", []float64{79.966331}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.proforma, func(t *testing.T) {
+			seq, err := FromProforma(tt.proforma)
+			if err != nil {
+				t.Fatalf("Failed to parse ProForma '%s': %v", tt.proforma, err)
+			}
+			shifts := seq.GetAllMassShifts()
+			if len(shifts) != len(tt.expected) {
+				t.Fatalf("Expected %v, got %v", tt.expected, shifts)
+			}
+			for i := range shifts {
+				if math.Abs(shifts[i]-tt.expected[i]) > 1e-4 {
+					t.Errorf("Expected %v, got %v", tt.expected, shifts)
+				}
+			}
+		})
+	}
+}
+
+func TestSequenceGetResidueMasses(t *testing.T) {
+	seq, err := FromProforma("PEP[+79.966]TIDE")
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	masses, err := seq.GetResidueMasses()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(masses) != 7 {
+		t.Fatalf("Expected 7 residue masses, got %d", len(masses))
+	}
+	if math.Abs(masses[2]-(*seq.GetSeq()[2].GetMass()+79.966)) > 1e-9 {
+		t.Errorf("Expected residue 2 to include the mass shift, got %f", masses[2])
+	}
+	if math.Abs(masses[1]-*seq.GetSeq()[1].GetMass()) > 1e-9 {
+		t.Errorf("Expected unmodified residue mass, got %f", masses[1])
+	}
+
+	total := Water
+	for _, mass := range masses {
+		total += mass
+	}
+	if neutral, _ := seq.GetNeutralMass(); math.Abs(total-neutral) > 1e-6 {
+		t.Errorf("Expected residue masses plus water to give %f, got %f", neutral, total)
+	}
+
+	seq, _ = FromProforma("<[Carbamidomethyl]@C>PRT(ESC)[+1]K")
+	masses, err = seq.GetResidueMasses()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if math.Abs(masses[3]-(*seq.GetSeq()[3].GetMass()+1)) > 1e-9 {
+		t.Errorf("Expected the range modification on its first residue, got %f", masses[3])
+	}
+	if math.Abs(masses[5]-(*seq.GetSeq()[5].GetMass()+57.021464)) > 1e-6 {
+		t.Errorf("Expected the global modification on C, got %f", masses[5])
+	}
+
+	for _, proforma := range []string{"PEPT[UnknownModification]IDE", "<15N>PEPTIDE", "PEPTIDE//PEPTIDE"} {
+		seq, _ := FromProforma(proforma)
+		if _, err := seq.GetResidueMasses(); err == nil {
+			t.Errorf("Expected an error for '%s'", proforma)
+		}
+	}
+}
+
+func TestSequenceForEachResidue(t *testing.T) {
+	seq, err := FromProforma("PEPS[Phospho]T[+79.966]IDE")
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+
+	visited := ""
+	modCount := 0
+	seq.ForEachResidue(func(index int, aa *AminoAcid, mods []*Modification) bool {
+		if index != len(visited) {
+			t.Errorf("Expected index %d, got %d", len(visited), index)
+		}
+		visited += aa.GetValue()
+		modCount += len(mods)
+		return true
+	})
+	if visited != "PEPSTIDE" {
+		t.Errorf("Expected to visit 'PEPSTIDE', got '%s'", visited)
+	}
+	if modCount != 2 {
+		t.Errorf("Expected 2 modifications, got %d", modCount)
+	}
+
+	stoppedAt := -1
+	calls := 0
+	seq.ForEachResidue(func(index int, aa *AminoAcid, mods []*Modification) bool {
+		calls++
+		if len(mods) > 0 {
+			stoppedAt = index
+			return false
+		}
+		return true
+	})
+	if stoppedAt != 3 || calls != 4 {
+		t.Errorf("Expected to stop at index 3 after 4 calls, got index %d after %d calls", stoppedAt, calls)
+	}
+
+	seq.ForEachResidue(func(index int, aa *AminoAcid, mods []*Modification) bool {
+		if aa.GetValue() == "E" {
+			aa.AddModification(NewModification("Methyl", nil, nil, nil, "static", false, 0, 0.0, false,
+				nil, false, false, false, nil, false, false, nil, nil, nil, nil,
+				nil, nil, false, false, false))
+		}
+		return true
+	})
+	if seq.ToProforma() != "PE[Methyl]PS[Phospho]T[+79.966]IDE[Methyl]" {
+		t.Errorf("Expected residue mutation to be reflected, got '%s'", seq.ToProforma())
+	}
+}
+
+func TestRangeModificationRoundtrip(t *testing.T) {
+	testCases := []string{
+		"(PEP)[+79.966]TIDE",
+		"PRT(ESFRMS)[+19.0523]ISK",
+		"P(EP)[Oxidation][+1]TIDE",
+		"(PE[Phospho]P)[+79.966]TIDE",
+		"((PE)[+1]P)[+2]TIDE",
+		"PEPTID(E)[+1]",
+		"[Acetyl]-(PEP)[+79.966]TIDE-[Amidated]/2",
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc, func(t *testing.T) {
+			seq, err := FromProforma(tc)
+			if err != nil {
+				t.Fatalf("Failed to parse '%s': %v", tc, err)
+			}
+			if seq.ToProforma() != tc {
+				t.Errorf("Expected '%s', got '%s'", tc, seq.ToProforma())
+			}
+		})
+	}
+
+	seq, _ := FromProforma("(PEP)[+79.966]TIDE")
+	for i := 0; i < 3; i++ {
+		mods := seq.GetSeq()[i].GetMods()
+		if len(mods) != 1 || !mods[0].IsInRange() {
+			t.Fatalf("Expected range modification on residue %d", i)
+		}
+		if *mods[0].GetRangeStart() != 0 || *mods[0].GetRangeEnd() != 2 {
+			t.Errorf("Expected range 0-2, got %d-%d", *mods[0].GetRangeStart(), *mods[0].GetRangeEnd())
+		}
+	}
+}
+
+func TestSequenceGetOxoniumIons(t *testing.T) {
+	seq, err := FromProforma("{Glycan:NeuAc1Hex1}PEPN[Glycan:HexNAc1dHex1]TIDE")
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	ions := seq.GetOxoniumIons()
+
+	expected := map[string]float64{
+		"HexNAc":        204.086649,
+		"HexNAc-H2O":    186.076084,
+		"HexNAc-2H2O":   168.065520,
+		"HexNAc-CH6O3":  138.054955,
+		"Hex":           163.060100,
+		"Hex-H2O":       145.049535,
+		"NeuAc":         292.102693,
+		"NeuAc-H2O":     274.092128,
+		"dHex":          147.065185,
+		"HexHexNAc":     366.139472,
+		"HexNAc-C2H4O2": 144.065520,
+	}
+	found := make(map[string]bool)
+	for i, ion := range ions {
+		if i > 0 && ion.Mz < ions[i-1].Mz {
+			t.Errorf("Expected ions sorted by m/z, got '%s' after '%s'", ion.Name, ions[i-1].Name)
+		}
+		if found[ion.Name] {
+			t.Errorf("Expected ion '%s' to be listed once", ion.Name)
+		}
+		found[ion.Name] = true
+		if mz, ok := expected[ion.Name]; ok && math.Abs(ion.Mz-mz) > 1e-4 {
+			t.Errorf("Expected m/z %f for '%s', got %f", mz, ion.Name, ion.Mz)
+		}
+	}
+	for name := range expected {
+		if !found[name] {
+			t.Errorf("Expected oxonium ion '%s'", name)
+		}
+	}
+
+	for _, proforma := range []string{"PEPS[Phospho]TIDE", "N[GNO:G59626AS]K"} {
+		seq, err := FromProforma(proforma)
+		if err != nil {
+			t.Fatalf("Failed to parse '%s': %v", proforma, err)
+		}
+		if ions := seq.GetOxoniumIons(); ions == nil || len(ions) != 0 {
+			t.Errorf("Expected no oxonium ions for '%s', got %v", proforma, ions)
+		}
+	}
+}
+
+func TestSequenceGetNeutralLosses(t *testing.T) {
+	type expectedLoss struct {
+		position int
+		name     string
+		mass     float64
+	}
+	testCases := []struct {
+		name       string
+		proforma   string
+		labileMods []string
+		expected   []expectedLoss
+	}{
+		{
+			name:     "labile phospho",
+			proforma: "{Phospho}PEPTIDE",
+			expected: []expectedLoss{{-3, "Phospho", 79.966331}, {-3, "H3PO4", 97.976896}},
+		},
+		{
+			name:     "residue phospho not labile by default",
+			proforma: "PEPS[Phospho]TIDE",
+			expected: nil,
+		},
+		{
+			name:       "residue phospho named labile",
+			proforma:   "PEPS[Phospho]TIDE",
+			labileMods: []string{"phospho"},
+			expected:   []expectedLoss{{3, "Phospho", 79.966331}, {3, "H3PO4", 97.976896}},
+		},
+		{
+			name:     "labile glycan",
+			proforma: "{Glycan:HexNAc2Hex5}PEPTIDE",
+			expected: []expectedLoss{
+				{-3, "Hex", 162.0528234185},
+				{-3, "HexNAc", 203.079372520},
+				{-3, "HexNAc2Hex5", 2*203.079372520 + 5*162.0528234185},
+			},
+		},
+		{
+			name:       "residue glycan named labile",
+			proforma:   "N[Glycan:HexNAc1dHex1]K",
+			labileMods: []string{"Glycan"},
+			expected: []expectedLoss{
+				{0, "HexNAc", 203.079372520},
+				{0, "dHex", 146.057908799},
+				{0, "HexNAc1dHex1", 203.079372520 + 146.057908799},
+			},
+		},
+		{
+			name:     "single monosaccharide",
+			proforma: "{Glycan:Hex}PEPTIDE",
+			expected: []expectedLoss{{-3, "Hex", 162.0528234185}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			seq, err := FromProforma(tc.proforma)
+			if err != nil {
+				t.Fatalf("Failed to parse '%s': %v", tc.proforma, err)
+			}
+			losses := seq.GetNeutralLosses(tc.labileMods...)
+			if len(losses) != len(tc.expected) {
+				t.Fatalf("Expected %d losses, got %d: %v", len(tc.expected), len(losses), losses)
+			}
+			for i, expected := range tc.expected {
+				loss := losses[i]
+				if loss.Position != expected.position || loss.Name != expected.name {
+					t.Errorf("Expected loss '%s' at %d, got '%s' at %d", expected.name, expected.position, loss.Name, loss.Position)
+				}
+				if math.Abs(loss.Mass-expected.mass) > 1e-6 {
+					t.Errorf("Expected mass %f for '%s', got %f", expected.mass, expected.name, loss.Mass)
+				}
+			}
+		})
+	}
+}
+
+func TestSequenceStripModifications(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{"PEPTIDE", "PEPTIDE"},
+		{"<[+57.021]@C>[Acetyl]-PEPC[Phospho]IDE-[Amidated]/2", "PEPCIDE"},
+		{"[Phospho]?{Hex}(PEP)[+1]TIDE", "PEPTIDE"},
+		{"<13C>PEPC[Disulfide#XL1]TIDE//SEC[#XL1]", "PEPCTIDE//SEC"},
+		{"PEPT[Phospho]IDE/2+ANOTHER/3", "PEPTIDE+ANOTHER"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			seq, err := FromProforma(tc.input)
+			if err != nil {
+				t.Fatalf("Failed to parse '%s': %v", tc.input, err)
+			}
+			original := seq.ToProforma()
+			stripped := seq.StripModifications()
+			if stripped.ToProforma() != tc.expected {
+				t.Errorf("Expected '%s', got '%s'", tc.expected, stripped.ToProforma())
+			}
+			if stripped.GetCharge() != nil {
+				t.Errorf("Expected no charge, got %d", *stripped.GetCharge())
+			}
+			if seq.ToProforma() != original {
+				t.Errorf("Expected original to be unchanged, got '%s'", seq.ToProforma())
+			}
+		})
+	}
+
+	seq, _ := FromProforma("PEPT[Phospho]IDE")
+	stripped := seq.StripModifications()
+	stripped.GetSeq()[0].AddModification(NewModification("Methyl", nil, nil, nil, "static", false, 0, 0.0, false,
+		nil, false, false, false, nil, false, false, nil, nil, nil, nil,
+		nil, nil, false, false, false))
+	if seq.ToProforma() != "PEPT[Phospho]IDE" {
+		t.Errorf("Expected original to be unaffected, got '%s'", seq.ToProforma())
+	}
+}
+
+func TestUnknownTerminusModifications(t *testing.T) {
+	testCases := []struct {
+		input           string
+		unknownTerminus []string
+		unknownPosition int
+	}{
+		{"[Acetyl]?-PEPTIDE", []string{"Acetyl"}, 0},
+		{"[Acetyl][Methyl]?-PEPTIDE", []string{"Acetyl", "Methyl"}, 0},
+		{"[Phospho]?[Acetyl]?-PEPTIDE", []string{"Acetyl"}, 1},
+		{"[Amidated]?-{Hex}[Acetyl]-PEPTIDE/2", []string{"Amidated"}, 0},
+		{"[Phospho]?[Acetyl]-PEPTIDE", nil, 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			seq, err := FromProforma(tc.input)
+			if err != nil {
+				t.Fatalf("Failed to parse '%s': %v", tc.input, err)
+			}
+			if seq.ToStrippedString() != "PEPTIDE" {
+				t.Errorf("Expected 'PEPTIDE', got '%s'", seq.ToStrippedString())
+			}
+
+			terminusMods := seq.GetMods()[-5]
+			if len(terminusMods) != len(tc.unknownTerminus) {
+				t.Fatalf("Expected %d unknown terminus modifications, got %d", len(tc.unknownTerminus), len(terminusMods))
+			}
+			for i, value := range tc.unknownTerminus {
+				if terminusMods[i].GetValue() != value {
+					t.Errorf("Expected '%s', got '%s'", value, terminusMods[i].GetValue())
+				}
+				if terminusMods[i].GetModType() != "terminal" {
+					t.Errorf("Expected mod type 'terminal', got '%s'", terminusMods[i].GetModType())
+				}
+			}
+			if len(seq.GetMods()[-4]) != tc.unknownPosition {
+				t.Errorf("Expected %d unknown position modifications, got %d", tc.unknownPosition, len(seq.GetMods()[-4]))
+			}
+
+			if seq.ToProforma() != tc.input {
+				t.Errorf("Expected '%s', got '%s'", tc.input, seq.ToProforma())
+			}
+		})
+	}
+
+	seq, _ := FromProforma("[Acetyl]?-PEPTIDE")
+	locations := seq.GetModificationsByType("terminal")
+	if len(locations) != 1 || locations[0].Position != -5 || locations[0].Side != TerminalSideUnknownTerminus {
+		t.Errorf("Expected one unknown terminus location, got %v", locations)
+	}
+}
+
+func TestSequenceClone(t *testing.T) {
+	inputs := []string{
+		"PEPT[Phospho]IDE",
+		"<[+57.021]@C>[Acetyl]-PEPC[Phospho]IDE-[Amidated]/2",
+		"[Phospho]?{Hex}(PEP)[+79.966]TIDE",
+		"PEPC[Disulfide#XL1]TIDE//SEC[#XL1]",
+		"PEPT[Phospho]IDE/2+ANOTHER/3",
+	}
+
+	for _, input := range inputs {
+		t.Run(input, func(t *testing.T) {
+			seq, err := FromProforma(input)
+			if err != nil {
+				t.Fatalf("Failed to parse '%s': %v", input, err)
+			}
+			original := seq.ToProforma()
+			clone := seq.Clone()
+			if clone.ToProforma() != original {
+				t.Errorf("Expected clone '%s', got '%s'", original, clone.ToProforma())
+			}
+
+			clone.GetSeq()[1].AddModification(NewModification("Methyl", nil, nil, nil, "static", false, 0, 14.01565, false,
+				nil, false, false, false, nil, false, false, nil, nil, nil, nil,
+				nil, nil, false, false, false))
+			clone.SetCharge(IntPtr(5))
+			if seq.ToProforma() != original {
+				t.Errorf("Expected original to be unchanged, got '%s'", seq.ToProforma())
+			}
+		})
+	}
+
+	seq, _ := FromProforma("(PEP)[+79.966]TIDE")
+	clone := seq.Clone()
+	if clone.GetSeq()[0].GetMods()[0] == seq.GetSeq()[0].GetMods()[0] {
+		t.Error("Expected range modification to be copied")
+	}
+	if clone.GetSeq()[0].GetMods()[0] != clone.GetSeq()[2].GetMods()[0] {
+		t.Error("Expected range modification to stay shared between residues in the clone")
+	}
+
+	multi, _ := FromProforma("PEPTIDE//SEQUENCE")
+	multiClone := multi.Clone()
+	if multiClone.Chain(0).GetSeq()[0] != multiClone.GetSeq()[0] {
+		t.Error("Expected the first chain of the clone to share the residues of the clone")
+	}
+	if multiClone.Chain(0).GetSeq()[0] == multi.Chain(0).GetSeq()[0] {
+		t.Error("Expected the first chain to be copied")
+	}
+	if multiClone.GetChains()[1] == multi.GetChains()[1] {
+		t.Error("Expected chains to be copied")
+	}
+
+	global, _ := FromProforma("<[U:Carbamidomethyl]@C>PEPC")
+	globalClone := global.Clone()
+	globalClone.GetGlobalMods()[0].SetMass(Float64Ptr(99))
+	globalClone.CanonicalizeSources()
+	if global.ToProforma() != "<[U:Carbamidomethyl]@C>PEPC" {
+		t.Errorf("Expected editing the clone's global modification to leave the original unchanged, got '%s'", global.ToProforma())
+	}
+
+	custom, _ := FromProforma("PEPTIDE")
+	position := 1
+	custom.GetSeq()[1] = &AminoAcid{BaseBlock: NewBaseBlock("Hyp", &position, false, nil), mods: []*Modification{}}
+	if customClone := custom.Clone(); customClone.GetLength() != 7 || customClone.GetSeq()[1].GetValue() != "Hyp" {
+		t.Errorf("Expected the clone to keep every residue, got '%s'", customClone.ToStrippedString())
+	}
+}
+
+func TestAlignSequences(t *testing.T) {
+	testCases := []struct {
+		name     string
+		a        string
+		b        string
+		expected []AlignOp
+	}{
+		{
+			name: "identical",
+			a:    "PEP",
+			b:    "P[Phospho]EP",
+			expected: []AlignOp{
+				{AlignMatch, 0, 0, "P", "P"},
+				{AlignMatch, 1, 1, "E", "E"},
+				{AlignMatch, 2, 2, "P", "P"},
+			},
+		},
+		{
+			name: "substitution and insertion",
+			a:    "PEPTIDEK",
+			b:    "PEPSIDERK",
+			expected: []AlignOp{
+				{AlignMatch, 0, 0, "P", "P"},
+				{AlignMatch, 1, 1, "E", "E"},
+				{AlignMatch, 2, 2, "P", "P"},
+				{AlignSubstitute, 3, 3, "T", "S"},
+				{AlignMatch, 4, 4, "I", "I"},
+				{AlignMatch, 5, 5, "D", "D"},
+				{AlignMatch, 6, 6, "E", "E"},
+				{AlignInsert, -1, 7, "", "R"},
+				{AlignMatch, 7, 8, "K", "K"},
+			},
+		},
+		{
+			name: "missed cleavage",
+			a:    "PEPTIDEKAAR",
+			b:    "PEPTIDEK",
+			expected: []AlignOp{
+				{AlignMatch, 0, 0, "P", "P"},
+				{AlignMatch, 1, 1, "E", "E"},
+				{AlignMatch, 2, 2, "P", "P"},
+				{AlignMatch, 3, 3, "T", "T"},
+				{AlignMatch, 4, 4, "I", "I"},
+				{AlignMatch, 5, 5, "D", "D"},
+				{AlignMatch, 6, 6, "E", "E"},
+				{AlignMatch, 7, 7, "K", "K"},
+				{AlignDelete, 8, -1, "A", ""},
+				{AlignDelete, 9, -1, "A", ""},
+				{AlignDelete, 10, -1, "R", ""},
+			},
+		},
+		{
+			name: "all substituted",
+			a:    "PEP",
+			b:    "KLM",
+			expected: []AlignOp{
+				{AlignSubstitute, 0, 0, "P", "K"},
+				{AlignSubstitute, 1, 1, "E", "L"},
+				{AlignSubstitute, 2, 2, "P", "M"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			a, _ := FromProforma(tc.a)
+			b, _ := FromProforma(tc.b)
+			ops := AlignSequences(a, b)
+			if len(ops) != len(tc.expected) {
+				t.Fatalf("Expected %d operations, got %d: %v", len(tc.expected), len(ops), ops)
+			}
+			for i, expected := range tc.expected {
+				if ops[i] != expected {
+					t.Errorf("Expected operation %d to be %v, got %v", i, expected, ops[i])
+				}
+			}
+		})
+	}
+
+	empty := NewSequence("", nil, false, "right", nil, nil, nil, nil, nil, nil, nil, nil)
+	seq, _ := FromProforma("PEP")
+	ops := AlignSequences(empty, seq)
+	if len(ops) != 3 || ops[0].Kind != AlignInsert || ops[2].PositionB != 2 {
+		t.Errorf("Expected 3 insertions, got %v", ops)
+	}
+	ops = AlignSequences(seq, empty)
+	if len(ops) != 3 || ops[0].Kind != AlignDelete || ops[2].PositionA != 2 {
+		t.Errorf("Expected 3 deletions, got %v", ops)
+	}
+}
+
+func TestSequenceRemoveModificationAt(t *testing.T) {
+	seq, err := FromProforma("[Phospho]?{Hex}[Acetyl]-PEPS[Phospho][Methyl]IDE-[Amidated]")
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+
+	steps := []struct {
+		pos      int
+		value    string
+		removed  bool
+		expected string
+	}{
+		{3, "Phospho", true, "[Phospho]?{Hex}[Acetyl]-PEPS[Methyl]IDE-[Amidated]"},
+		{3, "Phospho", false, "[Phospho]?{Hex}[Acetyl]-PEPS[Methyl]IDE-[Amidated]"},
+		{2, "Methyl", false, "[Phospho]?{Hex}[Acetyl]-PEPS[Methyl]IDE-[Amidated]"},
+		{-1, "Acetyl", true, "[Phospho]?{Hex}PEPS[Methyl]IDE-[Amidated]"},
+		{-2, "Amidated", true, "[Phospho]?{Hex}PEPS[Methyl]IDE"},
+		{-3, "Hex", true, "[Phospho]?PEPS[Methyl]IDE"},
+		{-4, "Phospho", true, "PEPS[Methyl]IDE"},
+		{-1, "Acetyl", false, "PEPS[Methyl]IDE"},
+		{7, "Methyl", false, "PEPS[Methyl]IDE"},
+	}
+
+	for _, step := range steps {
+		if removed := seq.RemoveModificationAt(step.pos, step.value); removed != step.removed {
+			t.Errorf("RemoveModificationAt(%d, '%s'): expected %v, got %v", step.pos, step.value, step.removed, removed)
+		}
+		if seq.ToProforma() != step.expected {
+			t.Errorf("RemoveModificationAt(%d, '%s'): expected '%s', got '%s'", step.pos, step.value, step.expected, seq.ToProforma())
+		}
+	}
+
+	ranged, _ := FromProforma("PE(PTI)[+79.966]DE")
+	if !ranged.RemoveModificationAt(3, "+79.966") {
+		t.Fatal("Expected range modification to be removed")
+	}
+	if ranged.ToProforma() != "PEPTIDE" {
+		t.Errorf("Expected range modification to be removed from every residue, got '%s'", ranged.ToProforma())
+	}
+}
+
+func TestSequenceFirstLastResidue(t *testing.T) {
+	testCases := []struct {
+		input string
+		first string
+		last  string
+	}{
+		{"PEPTIDEK", "P", "K"},
+		{"[Acetyl]-S[Phospho]EQR-[Amidated]", "S", "R"},
+		{"K", "K", "K"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			seq, err := FromProforma(tc.input)
+			if err != nil {
+				t.Fatalf("Failed to parse '%s': %v", tc.input, err)
+			}
+			if seq.FirstResidue().GetValue() != tc.first {
+				t.Errorf("Expected first residue '%s', got '%s'", tc.first, seq.FirstResidue().GetValue())
+			}
+			if seq.LastResidue().GetValue() != tc.last {
+				t.Errorf("Expected last residue '%s', got '%s'", tc.last, seq.LastResidue().GetValue())
+			}
+		})
+	}
+
+	empty := NewSequence("", nil, false, "right", nil, nil, nil, nil, nil, nil, nil, nil)
+	if empty.FirstResidue() != nil || empty.LastResidue() != nil {
+		t.Error("Expected nil residues for an empty sequence")
+	}
+}
+
+func TestSequenceReplaceResidue(t *testing.T) {
+	seq, err := FromProforma("[Acetyl]-P[+1]EPTIDE")
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	if err := seq.ReplaceResidue(0, "A"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if seq.ToStrippedString() != "AEPTIDE" {
+		t.Errorf("Expected 'AEPTIDE', got '%s'", seq.ToStrippedString())
+	}
+	if seq.ToProforma() != "[Acetyl]-A[+1]EPTIDE" {
+		t.Errorf("Expected '[Acetyl]-A[+1]EPTIDE', got '%s'", seq.ToProforma())
+	}
+	residue := seq.GetSeq()[0]
+	if residue.GetMass() == nil || *residue.GetMass() != AAMass["A"] {
+		t.Errorf("Expected mass %f for the replaced residue, got %v", AAMass["A"], residue.GetMass())
+	}
+	if residue.GetPosition() == nil || *residue.GetPosition() != 0 {
+		t.Errorf("Expected the replaced residue to keep position 0")
+	}
+
+	ranged, _ := FromProforma("PE(PTI)[+1]DE")
+	if err := ranged.ReplaceResidue(3, "S"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if ranged.ToProforma() != "PE(PSI)[+1]DE" {
+		t.Errorf("Expected 'PE(PSI)[+1]DE', got '%s'", ranged.ToProforma())
+	}
+
+	if err := seq.ReplaceResidue(0, "Z"); err == nil {
+		t.Error("Expected error for unknown amino acid")
+	}
+	if err := seq.ReplaceResidue(seq.GetLength(), "A"); err == nil {
+		t.Error("Expected error for out-of-range position")
+	}
+	if seq.ToStrippedString() != "AEPTIDE" {
+		t.Errorf("Expected failed replacements to leave 'AEPTIDE', got '%s'", seq.ToStrippedString())
+	}
+}
+
+func TestSequenceInsertDeleteResidue(t *testing.T) {
+	seq, err := FromProforma("[Acetyl]-PEPS[Phospho]IDE-[Amidated]")
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+
+	oxidation := NewModification("Oxidation", nil, nil, nil, "static", false, 0, 15.994915, false,
+		nil, false, false, false, nil, false, false, nil, nil, nil, nil,
+		nil, nil, false, false, false)
+	steps := []struct {
+		name     string
+		apply    func() error
+		expected string
+	}{
+		{"insert before modified residue", func() error { return seq.InsertResidue(3, "T", nil) }, "[Acetyl]-PEPTS[Phospho]IDE-[Amidated]"},
+		{"insert at start", func() error { return seq.InsertResidue(0, "M", []*Modification{oxidation}) }, "[Acetyl]-M[Oxidation]PEPTS[Phospho]IDE-[Amidated]"},
+		{"insert at end", func() error { return seq.InsertResidue(seq.GetLength(), "K", nil) }, "[Acetyl]-M[Oxidation]PEPTS[Phospho]IDEK-[Amidated]"},
+		{"delete before modified residue", func() error { return seq.DeleteResidue(4) }, "[Acetyl]-M[Oxidation]PEPS[Phospho]IDEK-[Amidated]"},
+		{"delete modified residue", func() error { return seq.DeleteResidue(4) }, "[Acetyl]-M[Oxidation]PEPIDEK-[Amidated]"},
+	}
+
+	for _, step := range steps {
+		if err := step.apply(); err != nil {
+			t.Fatalf("%s: unexpected error: %v", step.name, err)
+		}
+		if seq.ToProforma() != step.expected {
+			t.Errorf("%s: expected '%s', got '%s'", step.name, step.expected, seq.ToProforma())
+		}
+		if seq.GetLength() != len(seq.GetSeq()) {
+			t.Errorf("%s: expected length %d, got %d", step.name, len(seq.GetSeq()), seq.GetLength())
+		}
+		for i, aa := range seq.GetSeq() {
+			if aa.GetPosition() == nil || *aa.GetPosition() != i {
+				t.Errorf("%s: expected residue %d to have position %d", step.name, i, i)
+			}
+		}
+		if _, err := FromProforma(seq.ToProforma()); err != nil {
+			t.Errorf("%s: output '%s' does not parse: %v", step.name, seq.ToProforma(), err)
+		}
+	}
+
+	if err := seq.InsertResidue(-1, "A", nil); err == nil {
+		t.Error("Expected error for negative insert position")
+	}
+	if err := seq.InsertResidue(1, "Z", nil); err == nil {
+		t.Error("Expected error for unknown amino acid")
+	}
+	if err := seq.DeleteResidue(seq.GetLength()); err == nil {
+		t.Error("Expected error for out-of-range delete position")
+	}
+
+	ranged, _ := FromProforma("PE(PTI)[+1]DE")
+	rangeSteps := []struct {
+		name     string
+		apply    func() error
+		expected string
+	}{
+		{"insert inside range", func() error { return ranged.InsertResidue(3, "K", nil) }, "PE(PKTI)[+1]DE"},
+		{"insert before range", func() error { return ranged.InsertResidue(2, "A", nil) }, "PEA(PKTI)[+1]DE"},
+		{"delete inside range", func() error { return ranged.DeleteResidue(3) }, "PEA(KTI)[+1]DE"},
+		{"delete before range", func() error { return ranged.DeleteResidue(0) }, "EA(KTI)[+1]DE"},
+	}
+	for _, step := range rangeSteps {
+		if err := step.apply(); err != nil {
+			t.Fatalf("%s: unexpected error: %v", step.name, err)
+		}
+		if ranged.ToProforma() != step.expected {
+			t.Errorf("%s: expected '%s', got '%s'", step.name, step.expected, ranged.ToProforma())
+		}
+	}
+	for i := 0; i < 3; i++ {
+		if err := ranged.DeleteResidue(2); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if ranged.ToProforma() != "EADE" {
+		t.Errorf("Expected range modification to be removed with its residues, got '%s'", ranged.ToProforma())
+	}
+}
+
+func TestSequenceGetAdducts(t *testing.T) {
+	tests := []struct {
+		proforma string
+		expected []Adduct
+		charge   int
+	}{
+		{"PEPTIDE/1[+Na+]", []Adduct{{Count: 1, Ion: "Na", Charge: 1}}, 1},
+		{"PEPTIDE/2[+2Na+]", []Adduct{{Count: 2, Ion: "Na", Charge: 1}}, 2},
+		{"PEPTIDE/-1[-H+]", []Adduct{{Count: -1, Ion: "H", Charge: 1}}, -1},
+		{"PEPTIDE/3[+2Na+,+H+]", []Adduct{{Count: 2, Ion: "Na", Charge: 1}, {Count: 1, Ion: "H", Charge: 1}}, 3},
+		{"PEPTIDE/-2[2I-]", []Adduct{{Count: 2, Ion: "I", Charge: -1}}, -2},
+		{"PEPTIDE/-1[+e-]", []Adduct{{Count: 1, Ion: "e", Charge: -1}}, -1},
+		{"PEPTIDE/3[+Fe+3]", []Adduct{{Count: 1, Ion: "Fe", Charge: 3}}, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.proforma, func(t *testing.T) {
+			seq, err := FromProforma(tt.proforma)
+			if err != nil {
+				t.Fatalf("Failed to parse: %v", err)
+			}
+			adducts := seq.GetAdducts()
+			if len(adducts) != len(tt.expected) {
+				t.Fatalf("Expected %v, got %v", tt.expected, adducts)
+			}
+			total := 0
+			for i, adduct := range adducts {
+				if adduct != tt.expected[i] {
+					t.Errorf("Expected %+v, got %+v", tt.expected[i], adduct)
+				}
+				total += adduct.TotalCharge()
+			}
+			if total != tt.charge || *seq.GetCharge() != tt.charge {
+				t.Errorf("Expected adduct charge %d to match charge %d", total, *seq.GetCharge())
+			}
+			if seq.ToProforma() != tt.proforma {
+				t.Errorf("Expected '%s', got '%s'", tt.proforma, seq.ToProforma())
+			}
+		})
+	}
+
+	for adduct, expected := range map[Adduct]string{
+		{Count: 2, Ion: "Na", Charge: 1}: "+2Na+",
+		{Count: -1, Ion: "H", Charge: 1}: "-H+",
+		{Count: 1, Ion: "Fe", Charge: 3}: "+Fe+3",
+		{Count: 2, Ion: "I", Charge: -1}: "+2I-",
+	} {
+		if adduct.String() != expected {
+			t.Errorf("Expected '%s', got '%s'", expected, adduct.String())
+		}
+	}
+
+	seq, _ := FromProforma("PEPTIDE/2")
+	if adducts := seq.GetAdducts(); adducts != nil {
+		t.Errorf("Expected no adducts, got %v", adducts)
+	}
+	if _, err := ParseIonicSpecies("+Na+,oops"); err == nil {
+		t.Error("Expected error for an invalid adduct")
+	}
+}
+
+func TestSequenceGetModificationAt(t *testing.T) {
+	seq, err := FromProforma("[Phospho]?{Glycan:Hex}[Acetyl]-PEPS[Phospho]TIDE-[Amidated]")
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+
+	tests := []struct {
+		pos      int
+		expected []string
+	}{
+		{3, []string{"Phospho"}},
+		{0, nil},
+		{-1, []string{"Acetyl"}},
+		{-2, []string{"Amidated"}},
+		{-3, []string{"Hex"}},
+		{-4, []string{"Phospho"}},
+		{-5, nil},
+		{8, nil},
+		{-6, nil},
+	}
+
+	for _, tt := range tests {
+		mods := seq.GetModificationAt(tt.pos)
+		if len(mods) != len(tt.expected) {
+			t.Errorf("Position %d: expected %d modifications, got %d", tt.pos, len(tt.expected), len(mods))
+			continue
+		}
+		for i, mod := range mods {
+			if mod.GetValue() != tt.expected[i] {
+				t.Errorf("Position %d: expected '%s', got '%s'", tt.pos, tt.expected[i], mod.GetValue())
+			}
+		}
+	}
+}
+
+func TestSequenceDeduplicateModifications(t *testing.T) {
+	tests := []struct {
+		proforma string
+		removed  int
+		expected string
+	}{
+		{"PEPS[Phospho][Phospho]TIDE", 1, "PEPS[Phospho]TIDE"},
+		{"[Acetyl][Acetyl]-PEPS[Phospho][Oxidation][Phospho]TIDE", 2, "[Acetyl]-PEPS[Phospho][Oxidation]TIDE"},
+		{"PEPS[Phospho]T[Phospho]IDE", 0, "PEPS[Phospho]T[Phospho]IDE"},
+		{"{Hex}{Hex}PEPTIDE", 0, "{Hex}{Hex}PEPTIDE"},
+		{"PEPS[Phospho][Phospho]TIDE//AS[Phospho][Phospho]K", 2, "PEPS[Phospho]TIDE//AS[Phospho]K"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.proforma, func(t *testing.T) {
+			seq, err := FromProforma(tt.proforma)
+			if err != nil {
+				t.Fatalf("Failed to parse: %v", err)
+			}
+			if removed := seq.DeduplicateModifications(); removed != tt.removed {
+				t.Errorf("Expected %d removed, got %d", tt.removed, removed)
+			}
+			if seq.ToProforma() != tt.expected {
+				t.Errorf("Expected '%s', got '%s'", tt.expected, seq.ToProforma())
+			}
+			if removed := seq.DeduplicateModifications(); removed != 0 {
+				t.Errorf("Expected nothing left to remove, got %d", removed)
+			}
+		})
+	}
+}
+
+func TestSequenceGetEffectiveCharge(t *testing.T) {
+	tests := []struct {
+		proforma string
+		charge   *int
+	}{
+		{"PEPTIDE", nil},
+		{"PEPTIDE/2", IntPtr(2)},
+		{"PEPTIDE/0", IntPtr(0)},
+		{"PEPTIDE/2[+2Na+]", IntPtr(2)},
+		{"PEPTIDE/0[+2Na+,+H+]", IntPtr(3)},
+		{"PEPTIDE/0[+Na+,-H+]", IntPtr(0)},
+		{"PEPTIDE/0[+e-]", IntPtr(-1)},
+		{"PEPTIDE/0[not an adduct]", IntPtr(0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.proforma, func(t *testing.T) {
+			seq, err := FromProforma(tt.proforma)
+			if err != nil {
+				t.Fatalf("Failed to parse: %v", err)
+			}
+			charge := seq.GetEffectiveCharge()
+			if (charge == nil) != (tt.charge == nil) || (charge != nil && *charge != *tt.charge) {
+				t.Errorf("Expected charge %v, got %v", tt.charge, charge)
+			}
+		})
+	}
+
+	species := "+2Na+"
+	seq := NewSequence("PEPTIDE", nil, true, "right", nil, nil, nil, nil, &species, nil, nil, nil)
+	if seq.GetCharge() != nil {
+		t.Errorf("Expected no charge, got %d", *seq.GetCharge())
+	}
+	if charge := seq.GetEffectiveCharge(); charge == nil || *charge != 2 {
+		t.Errorf("Expected charge 2 from the ionic species, got %v", charge)
+	}
+}
+
+func TestSequenceWithChargeAndIonicSpecies(t *testing.T) {
+	tests := []struct {
+		proforma string
+		expected string
+	}{
+		{"PEPT[Phospho]IDE", "PEPT[Phospho]IDE/3[+2Na+,+H+]"},
+		{"PEPTIDE/2", "PEPTIDE/3[+2Na+,+H+]"},
+		{"PEPTIDE/2+ANOTHER/4", "PEPTIDE/3[+2Na+,+H+]+ANOTHER/4"},
+		{"PEPTIDE/2//SEQUENCE", "PEPTIDE/3[+2Na+,+H+]//SEQUENCE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.proforma, func(t *testing.T) {
+			base, err := FromProforma(tt.proforma)
+			if err != nil {
+				t.Fatalf("Failed to parse: %v", err)
+			}
+			variant := base.WithCharge(3).WithIonicSpecies("+2Na+,+H+")
+			if variant.ToProforma() != tt.expected {
+				t.Errorf("Expected '%s', got '%s'", tt.expected, variant.ToProforma())
+			}
+			if base.ToProforma() != tt.proforma {
+				t.Errorf("Expected the original to stay '%s', got '%s'", tt.proforma, base.ToProforma())
+			}
+			if charge := variant.GetPeptidoforms()[0].GetCharge(); charge == nil || *charge != 3 {
+				t.Errorf("Expected the first peptidoform to have charge 3, got %v", charge)
+			}
+		})
+	}
+
+	base, _ := FromProforma("PEPTIDE")
+	variant := base.WithCharge(2)
+	if variant.GetSeq()[0] != base.GetSeq()[0] {
+		t.Error("Expected the residues to be shared with the original")
+	}
+}
+
+func TestSequenceComplementaryPair(t *testing.T) {
+	tests := []struct {
+		proforma string
+		position int
+		charge   int
+	}{
+		{"PEPTIDE", 3, 1},
+		{"PEPTIDE", 6, 2},
+		{"[Acetyl]-PEPS[Phospho]TIDE-[Amidated]", 3, 1},
+		{"[Acetyl]-PEPS[Phospho]TIDE-[Amidated]", 4, 2},
+		{"<[Carbamidomethyl]@C>PEPTCDE", 5, 1},
+		{"PRT(ESFRMS)[+19.0523]ISK", 3, 2},
+		{"PEPK[U:+138.068#XL1]TIDEK[#XL1]", 2, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.proforma, func(t *testing.T) {
+			seq, err := FromProforma(tt.proforma)
+			if err != nil {
+				t.Fatalf("Failed to parse: %v", err)
+			}
+			b, y, err := seq.ComplementaryPair(tt.position, tt.charge)
+			if err != nil {
+				t.Fatalf("Failed to compute the pair: %v", err)
+			}
+			mass, _ := seq.GetNeutralMass()
+			if math.Abs(b.NeutralMass+y.NeutralMass-mass) > 1e-9 {
+				t.Errorf("Expected the pair to add up to %f, got %f", mass, b.NeutralMass+y.NeutralMass)
+			}
+			if b.Type != FragmentIonB || y.Type != FragmentIonY || b.Number+y.Number != seq.GetLength() {
+				t.Errorf("Expected b%d and y%d, got %s%d and %s%d", tt.position, seq.GetLength()-tt.position, b.Type, b.Number, y.Type, y.Number)
+			}
+			z := float64(tt.charge)
+			if math.Abs(b.Mz*z+y.Mz*z-2*z*Proton-mass) > 1e-9 {
+				t.Errorf("Expected m/z values consistent with charge %d, got %f and %f", tt.charge, b.Mz, y.Mz)
+			}
+		})
+	}
+
+	seq, _ := FromProforma("PEPTIDE")
+	b, y, _ := seq.ComplementaryPair(3, 1)
+	if math.Abs(b.Mz-324.155397) > 1e-5 || math.Abs(y.Mz-477.219121) > 1e-5 {
+		t.Errorf("Expected b3 324.1554 and y4 477.2191, got %f and %f", b.Mz, y.Mz)
+	}
+
+	for _, tc := range []struct {
+		proforma string
+		position int
+		charge   int
+	}{
+		{"PEPTIDE", 0, 1},
+		{"PEPTIDE", 7, 1},
+		{"PEPTIDE", 3, 0},
+		{"{Hex}PEPTIDE", 3, 1},
+		{"PRT(ESFRMS)[+19.0523]ISK", 5, 1},
+		{"PEPK[U:+138.068#XL1]TIDEK[#XL1]", 5, 1},
+		{"<15N>PEPTIDE", 3, 1},
+		{"PEPTIDE//ELVIS", 3, 1},
+	} {
+		seq, _ := FromProforma(tc.proforma)
+		if _, _, err := seq.ComplementaryPair(tc.position, tc.charge); err == nil {
+			t.Errorf("Expected an error for '%s' at %d with charge %d", tc.proforma, tc.position, tc.charge)
+		}
+	}
+}
+
+func TestSequenceIsChargeVariantSet(t *testing.T) {
+	tests := []struct {
+		proforma        string
+		expected        bool
+		expectedCharges []int
+	}{
+		{"PEPTIDE/2+PEPTIDE/3", true, []int{2, 3}},
+		{"<[Carbamidomethyl]@C>PEPT[Phospho]CK/2+PEPT[Phospho]CK/3+PEPT[Phospho]CK/4", true, []int{2, 3, 4}},
+		{"PEPTIDE/2[+Na+]+PEPTIDE/2", true, []int{2, 2}},
+		{"PEPTIDE/2+PEPT[Phospho]IDE/3", false, []int{2, 3}},
+		{"PEPTIDE/2+ANOTHER/3", false, []int{2, 3}},
+		{"PEPTIDE/2+PEPTIDE/2", false, []int{2, 2}},
+		{"PEPTIDE+PEPTIDE", false, []int{}},
+		{"PEPTIDE/2", false, []int{2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.proforma, func(t *testing.T) {
+			seq, err := FromProforma(tt.proforma)
+			if err != nil {
+				t.Fatalf("Failed to parse ProForma '%s': %v", tt.proforma, err)
+			}
+			if result := seq.IsChargeVariantSet(); result != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, result)
+			}
+			charges := seq.GetChargeStates()
+			if len(charges) != len(tt.expectedCharges) {
+				t.Fatalf("Expected charges %v, got %v", tt.expectedCharges, charges)
+			}
+			for i := range charges {
+				if charges[i] != tt.expectedCharges[i] {
+					t.Errorf("Expected charges %v, got %v", tt.expectedCharges, charges)
+				}
+			}
+			if result := seq.ToProforma(); result != tt.proforma {
+				t.Errorf("Expected '%s', got '%s'", tt.proforma, result)
+			}
+		})
+	}
+}
+
+func TestSequenceToMzTabPSMFields(t *testing.T) {
+	tests := []struct {
+		proforma      string
+		sequence      string
+		modifications string
+		charge        string
+	}{
+		{"[Acetyl]-PEPS[Phospho]TM[+15.995]IDE/2", "PEPSTMIDE", "0-UNIMOD:1,4-UNIMOD:21,6-CHEMMOD:+15.995", "2"},
+		{"PEPTIDE", "PEPTIDE", "null", "null"},
+		{"S[UNIMOD:21]EK[M:MOD:00064]K-[Amidated]", "SEKK", "1-UNIMOD:21,3-MOD:00064,5-UNIMOD:2", "null"},
+		{"<[Carbamidomethyl]@C>PEPCTCK/3", "PEPCTCK", "4-UNIMOD:4,6-UNIMOD:4", "3"},
+		{"EM[Oxidation#g1]EVT[#g1]S", "EMEVTS", "2|5-UNIMOD:35", "null"},
+		{"PRT(ESF)[+19.0523]ISK", "PRTESFISK", "4|5|6-CHEMMOD:+19.0523", "null"},
+		{"[Phospho]?PEPS[Formula:HPO3]K", "PEPSK", "UNIMOD:21,4-CHEMMOD:+79.966331", "null"},
+		{"PEPK[XL:DSS#XL1]K[#XL1]T[Custom]{Glycan:Hex}", "PEPKKT", "null", "null"},
+		{"PEP[+21]TIDE", "PEPTIDE", "3-CHEMMOD:+21", "null"},
+		{"(PEP)[+1]TIDE", "PEPTIDE", "1|2|3-CHEMMOD:+1", "null"},
+		{"PEP[U:+35]TIDE", "PEPTIDE", "3-CHEMMOD:+35", "null"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.proforma, func(t *testing.T) {
+			seq, err := FromProforma(tt.proforma)
+			if err != nil {
+				t.Fatalf("Failed to parse ProForma '%s': %v", tt.proforma, err)
+			}
+			fields := seq.ToMzTabPSMFields()
+			if fields["sequence"] != tt.sequence {
+				t.Errorf("Expected sequence '%s', got '%s'", tt.sequence, fields["sequence"])
+			}
+			if fields["modifications"] != tt.modifications {
+				t.Errorf("Expected modifications '%s', got '%s'", tt.modifications, fields["modifications"])
+			}
+			if fields["charge"] != tt.charge {
+				t.Errorf("Expected charge '%s', got '%s'", tt.charge, fields["charge"])
+			}
+		})
+	}
+}
+
+func TestAmbiguityGroupWithoutScoreRoundtrip(t *testing.T) {
+	for _, proforma := range []string{"EM[Oxidation#g1]EVT[#g1]S", "EM[#g1]EVT[U:Oxidation#g1]S"} {
+		seq, err := FromProforma(proforma)
+		if err != nil {
+			t.Fatalf("Failed to parse ProForma '%s': %v", proforma, err)
+		}
+		if result := seq.ToProforma(); result != proforma {
+			t.Errorf("Expected '%s', got '%s'", proforma, result)
+		}
+		for _, pos := range []int{1, 4} {
+			mod := seq.GetSeq()[pos].GetMods()[0]
+			if group := mod.GetAmbiguityGroup(); group == nil || *group != "g1" {
+				t.Errorf("Expected ambiguity group 'g1' at position %d, got %v", pos, group)
+			}
+			if mod.GetCrosslinkID() != nil {
+				t.Errorf("Expected no crosslink ID at position %d, got '%s'", pos, *mod.GetCrosslinkID())
+			}
+		}
+	}
+}
+
+func TestParseMzTabModifications(t *testing.T) {
+	tests := []struct {
+		sequence string
+		modStr   string
+		expected string
+	}{
+		{"PEPTIDE", "0-UNIMOD:1,4-UNIMOD:21", "[UNIMOD:1]-PEPT[UNIMOD:21]IDE"},
+		{"EMEVTSM", "2|5-UNIMOD:35,7-UNIMOD:35", "EM[UNIMOD:35#g1]EVT[#g1]SM[UNIMOD:35]"},
+		{"PEPTIDE", "3[MS,MS:1001876, modification probability, 0.8]-UNIMOD:21,8-UNIMOD:2", "PEP[UNIMOD:21]TIDE-[UNIMOD:2]"},
+		{"PEPTIDE", "CHEMMOD:-18.010565,2-MOD:00046,5-CHEMMOD:+15.995,6-CHEMMOD:H3PO4", "[-18.010565]?PE[MOD:00046]PTI[+15.995]D[Formula:H3PO4]E"},
+		{"PEPTIDE", "null", "PEPTIDE"},
+		{"PEPTIDE", "", "PEPTIDE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.modStr, func(t *testing.T) {
+			mods, err := ParseMzTabModifications(len(tt.sequence), tt.modStr)
+			if err != nil {
+				t.Fatalf("Failed to parse mzTab modifications '%s': %v", tt.modStr, err)
+			}
+			seq := NewSequence(tt.sequence, mods, true, "right", nil, nil, nil, nil, nil, nil, nil, nil)
+			if result := seq.ToProforma(); result != tt.expected {
+				t.Errorf("Expected '%s', got '%s'", tt.expected, result)
+			}
+		})
+	}
+
+	// Modifications exported to mzTab read back to the same column
+	seq, _ := FromProforma("[Acetyl]-EM[Oxidation#g1]EVT[#g1]SC[Carbamidomethyl]K[+8.014]")
+	column := seq.ToMzTabPSMFields()["modifications"]
+	mods, err := ParseMzTabModifications(seq.GetLength(), column)
+	if err != nil {
+		t.Fatalf("Failed to parse mzTab modifications '%s': %v", column, err)
+	}
+	imported := NewSequence(seq.ToStrippedString(), mods, true, "right", nil, nil, nil, nil, nil, nil, nil, nil)
+	if result := imported.ToMzTabPSMFields()["modifications"]; result != column {
+		t.Errorf("Expected '%s', got '%s'", column, result)
+	}
+
+	for _, modStr := range []string{"9-UNIMOD:1", "x-UNIMOD:1", "3-[MS, MS:1001524, fragment neutral loss, 63.998285]", "3-Phospho"} {
+		if _, err := ParseMzTabModifications(7, modStr); err == nil {
+			t.Errorf("Expected an error for '%s'", modStr)
+		}
+	}
+}
+
+func TestSequenceCanonicalizeSources(t *testing.T) {
+	tests := []struct {
+		proforma string
+		long     string
+	}{
+		{"PEPS[U:Phospho|M:MOD:00046]TIDE", "PEPS[Unimod:Phospho|PSI-MOD:MOD:00046]TIDE"},
+		{"<[U:Carbamidomethyl]@C>[U:Acetyl]-PEPC[R:AA0038]K[X:DSS#XL1]K[#XL1]N[G:G00001NT]",
+			"<[Unimod:Carbamidomethyl]@C>[Unimod:Acetyl]-PEPC[RESID:AA0038]K[XL-MOD:DSS#XL1]K[#XL1]N[GNO:G00001NT]"},
+		{"PEPS[U:Phospho#g1]T[#g1]K//PEPK[U:Acetyl]", "PEPS[Unimod:Phospho#g1]T[#g1]K//PEPK[Unimod:Acetyl]"},
+		{"PEPS[U:Phospho]K/2+PEPT[U:Phospho]K/3", "PEPS[Unimod:Phospho]K/2+PEPT[Unimod:Phospho]K/3"},
+		{"PEPS[UNIMOD:21|Formula:HPO3|Obs:+79.966]K", "PEPS[UNIMOD:21|Formula:HPO3|Obs:+79.966]K"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.proforma, func(t *testing.T) {
+			seq, err := FromProforma(tt.proforma)
+			if err != nil {
+				t.Fatalf("Failed to parse ProForma '%s': %v", tt.proforma, err)
+			}
+			seq.CanonicalizeSources()
+			if result := seq.ToProforma(); result != tt.long {
+				t.Fatalf("Expected '%s', got '%s'", tt.long, result)
+			}
+
+			reparsed, err := FromProforma(tt.long)
+			if err != nil {
+				t.Fatalf("Failed to parse canonical ProForma '%s': %v", tt.long, err)
+			}
+			if result := reparsed.ToProforma(); result != tt.long {
+				t.Errorf("Expected '%s', got '%s'", tt.long, result)
+			}
+			reparsed.CanonicalizeSourcesWith(ShortSourceNames)
+			if result := reparsed.ToProforma(); result != tt.proforma {
+				t.Errorf("Expected '%s', got '%s'", tt.proforma, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package sequal
+
+import (
+	"strconv"
+	"strings"
+)
+
+// UnimodEntry represents a single Unimod modification with its accession, name, and
+// monoisotopic mass delta
+type UnimodEntry struct {
+	Accession int
+	Name      string
+	MonoMass  float64
+}
+
+// UnimodTable is a minimal embedded table of commonly used Unimod modifications keyed by accession
+var UnimodTable = map[int]*UnimodEntry{
+	1:    {Accession: 1, Name: "Acetyl", MonoMass: 42.010565},
+	2:    {Accession: 2, Name: "Amidated", MonoMass: -0.984016},
+	3:    {Accession: 3, Name: "Biotin", MonoMass: 226.077598},
+	4:    {Accession: 4, Name: "Carbamidomethyl", MonoMass: 57.021464},
+	5:    {Accession: 5, Name: "Carbamyl", MonoMass: 43.005814},
+	6:    {Accession: 6, Name: "Carboxymethyl", MonoMass: 58.005479},
+	7:    {Accession: 7, Name: "Deamidated", MonoMass: 0.984016},
+	21:   {Accession: 21, Name: "Phospho", MonoMass: 79.966331},
+	23:   {Accession: 23, Name: "Dehydrated", MonoMass: -18.010565},
+	26:   {Accession: 26, Name: "Pyro-carbamidomethyl", MonoMass: 39.994915},
+	27:   {Accession: 27, Name: "Glu->pyro-Glu", MonoMass: -18.010565},
+	28:   {Accession: 28, Name: "Gln->pyro-Glu", MonoMass: -17.026549},
+	34:   {Accession: 34, Name: "Methyl", MonoMass: 14.01565},
+	35:   {Accession: 35, Name: "Oxidation", MonoMass: 15.994915},
+	36:   {Accession: 36, Name: "Dimethyl", MonoMass: 28.0313},
+	37:   {Accession: 37, Name: "Trimethyl", MonoMass: 42.04695},
+	40:   {Accession: 40, Name: "Sulfo", MonoMass: 79.956815},
+	41:   {Accession: 41, Name: "Hex", MonoMass: 162.052824},
+	43:   {Accession: 43, Name: "HexNAc", MonoMass: 203.079373},
+	45:   {Accession: 45, Name: "Myristoyl", MonoMass: 210.198366},
+	47:   {Accession: 47, Name: "Palmitoyl", MonoMass: 238.229666},
+	58:   {Accession: 58, Name: "Propionyl", MonoMass: 56.026215},
+	64:   {Accession: 64, Name: "Succinyl", MonoMass: 100.016044},
+	121:  {Accession: 121, Name: "GG", MonoMass: 114.042927},
+	122:  {Accession: 122, Name: "Formyl", MonoMass: 27.994915},
+	214:  {Accession: 214, Name: "iTRAQ4plex", MonoMass: 144.102063},
+	259:  {Accession: 259, Name: "Label:13C(6)15N(2)", MonoMass: 8.014199},
+	267:  {Accession: 267, Name: "Label:13C(6)15N(4)", MonoMass: 10.008269},
+	312:  {Accession: 312, Name: "Cysteinyl", MonoMass: 119.004099},
+	385:  {Accession: 385, Name: "Ammonia-loss", MonoMass: -17.026549},
+	737:  {Accession: 737, Name: "TMT6plex", MonoMass: 229.162932},
+	747:  {Accession: 747, Name: "Malonyl", MonoMass: 86.000394},
+	2016: {Accession: 2016, Name: "TMTpro", MonoMass: 304.207146},
+}
+
+// unimodByName indexes UnimodTable by lower-cased modification name
+var unimodByName = buildUnimodNameIndex()
+
+// buildUnimodNameIndex builds the case-insensitive name lookup for UnimodTable
+func buildUnimodNameIndex() map[string]*UnimodEntry {
+	index := make(map[string]*UnimodEntry, len(UnimodTable))
+	for _, entry := range UnimodTable {
+		index[strings.ToLower(entry.Name)] = entry
+	}
+	return index
+}
+
+// ResolveUnimod looks up a modification in the embedded Unimod table.
+// The value may be a bare name, or a name or accession with a "U:", "UNIMOD:" or "Unimod:"
+// prefix, so "U:21", "UNIMOD:21", "U:Phospho" and "Phospho" all resolve to the same entry.
+// Names are matched case-insensitively. An accession needs its prefix, so a bare number such
+// as "21" and a mass shift such as "+21" do not resolve.
+//
+// Example:
+//
+//	entry, ok := sequal.ResolveUnimod("UNIMOD:21")
+//	fmt.Println(ok, entry.Name, entry.MonoMass) // true Phospho 79.966331
+func ResolveUnimod(value string) (*UnimodEntry, bool) {
+	value = strings.TrimSpace(value)
+
+	prefixed := false
+	if prefix, rest, found := strings.Cut(value, ":"); found {
+		switch strings.ToUpper(prefix) {
+		case "U", "UNIMOD":
+			value, prefixed = rest, true
+		}
+	}
+
+	if strings.HasPrefix(value, "+") || strings.HasPrefix(value, "-") {
+		return nil, false
+	}
+	if accession, err := strconv.Atoi(value); err == nil {
+		if !prefixed {
+			return nil, false
+		}
+		entry, ok := UnimodTable[accession]
+		return entry, ok
+	}
+
+	entry, ok := unimodByName[strings.ToLower(value)]
+	return entry, ok
+}
+
+// resolveUnimodValue resolves a modification or pipe value split from its source, as held by
+// Modification and PipeValue, restoring the source prefix an accession needs
+func resolveUnimodValue(source *string, value string) (*UnimodEntry, bool) {
+	if source != nil {
+		if !isUnimodSource(source) {
+			return nil, false
+		}
+		value = *source + ":" + value
+	}
+	return ResolveUnimod(value)
+}
+
+// isUnimodSource reports whether a modification source is absent or refers to Unimod
+func isUnimodSource(source *string) bool {
+	if source == nil {
+		return true
+	}
+	switch strings.ToUpper(*source) {
+	case "U", "UNIMOD":
+		return true
+	}
+	return false
+}
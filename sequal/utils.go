@@ -93,6 +93,8 @@ func SplitChimericProforma(proformaStr string) []string {
 	var parts []string
 	currentPartStart := 0
 	bracketLevel := 0
+	// A global modification such as "<+57.021@C>" may hold a '+' outside any brackets
+	inGlobalMod := false
 	
 	for i, char := range proformaStr {
 		switch char {
@@ -102,8 +104,12 @@ func SplitChimericProforma(proformaStr string) []string {
 			if bracketLevel > 0 {
 				bracketLevel--
 			}
-		case '+':
+		case '<', '>':
 			if bracketLevel == 0 {
+				inGlobalMod = char == '<'
+			}
+		case '+':
+			if bracketLevel == 0 && !inGlobalMod {
 				// Found a separator '+' outside of any brackets
 				part := proformaStr[currentPartStart:i]
 				if len(part) > 0 {
@@ -133,11 +139,7 @@ func DeepCopyModifications(mods []*Modification) []*Modification {
 	
 	result := make([]*Modification, len(mods))
 	for i, mod := range mods {
-		if mod != nil {
-			// Create a copy of the modification
-			result[i] = &Modification{}
-			*result[i] = *mod
-		}
+		result[i] = mod.Clone()
 	}
 	
 	return result
@@ -0,0 +1,352 @@
+package sequal
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// ValidationSeverity represents how serious a validation issue is
+type ValidationSeverity string
+
+// Constants for validation issue severities
+const (
+	ValidationSeverityError   ValidationSeverity = "error"
+	ValidationSeverityWarning ValidationSeverity = "warning"
+)
+
+// ValidationIssue describes a single problem found while validating a sequence.
+// Position is the residue index the issue refers to, or -1 and -2 for the N- and
+// C-terminus, following the same convention as the modifications map.
+type ValidationIssue struct {
+	Severity ValidationSeverity
+	Chain    int
+	Position int
+	Message  string
+}
+
+// String returns a string representation of the validation issue
+func (vi ValidationIssue) String() string {
+	return fmt.Sprintf("%s at chain %d position %d: %s", vi.Severity, vi.Chain, vi.Position, vi.Message)
+}
+
+// unimodResidueSpecificity maps Unimod accessions to the residues they are expected on
+var unimodResidueSpecificity = map[int]map[string]bool{
+	4:   {"C": true, "D": true, "E": true, "H": true, "K": true, "S": true, "T": true, "Y": true, "U": true},
+	7:   {"N": true, "Q": true, "R": true, "F": true},
+	21:  {"S": true, "T": true, "Y": true, "H": true, "D": true, "C": true, "R": true, "K": true, "E": true},
+	27:  {"E": true},
+	28:  {"Q": true},
+	35:  {"M": true, "W": true, "C": true, "H": true, "Y": true, "F": true, "P": true, "K": true, "R": true, "U": true},
+	121: {"K": true, "C": true, "S": true, "T": true},
+}
+
+// Validate checks the sequence for modifications that are inconsistent with the residues
+// or positions they are attached to. It reports:
+//   - modifications on residues they are not expected on (e.g. Phospho on G), as warnings
+//   - terminal modifications attached to internal residues, as errors
+//   - gap modifications on residues other than X, as errors
+//   - crosslink references with no matching crosslink definition, as errors
+//   - crosslink IDs defined more than once with different reagents, as errors; a repeated
+//     identical definition is reported as a warning since it should be written as a reference
+//   - pipe separated values of one modification implying masses more than 0.01 Da apart,
+//     such as "[U:Phospho|U:+42.011]", as warnings
+//
+// For multi-chain sequences all chains are checked and crosslinks may be defined in any chain.
+// For chimeric sequences all peptidoforms are checked, and Chain is the index of the
+// peptidoform.
+// An empty result means no problems were found.
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("PEPG[Phospho]C[#XL1]")
+//	for _, issue := range seq.Validate() {
+//		fmt.Println(issue)
+//	}
+func (s *Sequence) Validate() []ValidationIssue {
+	issues := make([]ValidationIssue, 0)
+
+	chains := s.partsOrSelf()
+
+	type crosslinkSite struct {
+		chain    int
+		position int
+		id       string
+		reagent  string
+	}
+	definedCrosslinks := make(map[string]crosslinkSite)
+	var refs []crosslinkSite
+
+	// A crosslink on a range is shared by every residue of the range and is collected once
+	collected := make(map[*Modification]bool)
+	collectCrosslinks := func(chainIndex int, position int, mod *Modification) {
+		id := mod.GetCrosslinkID()
+		if id == nil || collected[mod] {
+			return
+		}
+		collected[mod] = true
+		if mod.IsCrosslinkRef() {
+			refs = append(refs, crosslinkSite{chain: chainIndex, position: position, id: *id})
+			return
+		}
+
+		reagent, _, _ := strings.Cut(mod.GetValue(), "#")
+		first, defined := definedCrosslinks[*id]
+		if !defined {
+			definedCrosslinks[*id] = crosslinkSite{chain: chainIndex, position: position, id: *id, reagent: reagent}
+			return
+		}
+		if first.reagent != reagent {
+			issues = append(issues, ValidationIssue{
+				Severity: ValidationSeverityError,
+				Chain:    chainIndex,
+				Position: position,
+				Message: fmt.Sprintf("crosslink '#%s' defined as '%s' conflicts with definition '%s' at chain %d position %d",
+					*id, reagent, first.reagent, first.chain, first.position),
+			})
+		} else {
+			issues = append(issues, ValidationIssue{
+				Severity: ValidationSeverityWarning,
+				Chain:    chainIndex,
+				Position: position,
+				Message:  fmt.Sprintf("crosslink '#%s' is defined more than once, use '[#%s]' for references", *id, *id),
+			})
+		}
+	}
+
+	for chainIndex, chain := range chains {
+		terminalPositions := make([]int, 0, len(chain.mods))
+		for pos := range chain.mods {
+			terminalPositions = append(terminalPositions, pos)
+		}
+		sort.Ints(terminalPositions)
+		for _, pos := range terminalPositions {
+			for _, mod := range chain.mods[pos] {
+				collectCrosslinks(chainIndex, pos, mod)
+				issues = append(issues, validateSourceMasses(chainIndex, pos, mod)...)
+			}
+		}
+
+		for i, aa := range chain.seq {
+			for _, mod := range aa.GetMods() {
+				collectCrosslinks(chainIndex, i, mod)
+				issues = append(issues, validateResidueModification(chainIndex, i, len(chain.seq), aa, mod)...)
+				issues = append(issues, validateSourceMasses(chainIndex, i, mod)...)
+			}
+		}
+	}
+
+	for _, ref := range refs {
+		if _, defined := definedCrosslinks[ref.id]; !defined {
+			issues = append(issues, ValidationIssue{
+				Severity: ValidationSeverityError,
+				Chain:    ref.chain,
+				Position: ref.position,
+				Message:  fmt.Sprintf("crosslink reference '#%s' has no definition", ref.id),
+			})
+		}
+	}
+
+	return issues
+}
+
+// validateResidueModification checks a single modification against the residue it is attached to
+func validateResidueModification(chainIndex int, position int, seqLength int, aa *AminoAcid, mod *Modification) []ValidationIssue {
+	var issues []ValidationIssue
+	residue := aa.GetValue()
+
+	if mod.GetModType() == "terminal" && position != 0 && position != seqLength-1 {
+		issues = append(issues, ValidationIssue{
+			Severity: ValidationSeverityError,
+			Chain:    chainIndex,
+			Position: position,
+			Message:  fmt.Sprintf("terminal modification '%s' on non-terminal residue '%s'", mod.GetValue(), residue),
+		})
+	}
+
+	if mod.GetModType() == "gap" && residue != "X" {
+		issues = append(issues, ValidationIssue{
+			Severity: ValidationSeverityError,
+			Chain:    chainIndex,
+			Position: position,
+			Message:  fmt.Sprintf("gap modification '%s' on residue '%s', expected 'X'", mod.GetValue(), residue),
+		})
+	}
+
+	if mod.GetModificationValue() == nil || mod.IsCrosslinkRef() || mod.IsAmbiguityRef() || !isUnimodSource(mod.GetSource()) {
+		return issues
+	}
+
+	if entry, ok := resolveUnimodValue(mod.GetSource(), mod.GetValue()); ok {
+		if sites, known := unimodResidueSpecificity[entry.Accession]; known && !sites[residue] {
+			issues = append(issues, ValidationIssue{
+				Severity: ValidationSeverityWarning,
+				Chain:    chainIndex,
+				Position: position,
+				Message:  fmt.Sprintf("modification '%s' is not expected on residue '%s'", entry.Name, residue),
+			})
+		}
+	}
+
+	return issues
+}
+
+// sourceMassTolerance is the mass difference in Daltons above which two pipe separated
+// values of one modification are reported as describing different modifications
+const sourceMassTolerance = 0.01
+
+// validateSourceMasses checks that the pipe separated values of a modification that imply a
+// mass (mass shifts, formulas and Unimod names or accessions) agree with each other
+func validateSourceMasses(chainIndex int, position int, mod *Modification) []ValidationIssue {
+	mv := mod.GetModificationValue()
+	if mv == nil {
+		return nil
+	}
+
+	var issues []ValidationIssue
+	var firstLabel string
+	var firstMass *float64
+	for _, pv := range mv.GetPipeValues() {
+		mass := pipeValueMass(pv)
+		if mass == nil {
+			continue
+		}
+		label := pv.GetValue()
+		if source := pv.GetSource(); source != nil {
+			label = *source + ":" + label
+		}
+		if firstMass == nil {
+			firstLabel, firstMass = label, mass
+			continue
+		}
+		if math.Abs(*mass-*firstMass) > sourceMassTolerance {
+			issues = append(issues, ValidationIssue{
+				Severity: ValidationSeverityWarning,
+				Chain:    chainIndex,
+				Position: position,
+				Message: fmt.Sprintf("modification values '%s' (%.4f) and '%s' (%.4f) imply different masses",
+					firstLabel, *firstMass, label, *mass),
+			})
+		}
+	}
+	return issues
+}
+
+// pipeValueMass returns the mass implied by a single pipe value, or nil when it has none or
+// the mass cannot be resolved
+func pipeValueMass(pv *PipeValue) *float64 {
+	switch pv.GetType() {
+	case PipeValueTypeMass:
+		return pv.GetMass()
+	case PipeValueTypeFormula:
+		return pv.GetChargedMass()
+	case PipeValueTypeSynonym:
+		if !isUnimodSource(pv.GetSource()) {
+			return nil
+		}
+		if entry, ok := resolveUnimodValue(pv.GetSource(), pv.GetValue()); ok {
+			return &entry.MonoMass
+		}
+	}
+	return nil
+}
+
+// ConflictKind describes why modifications on the same residue conflict
+type ConflictKind string
+
+// Constants for the conflicts reported by FindConflictingModifications
+const (
+	ConflictSameMass        ConflictKind = "same-mass"
+	ConflictCrosslinkBranch ConflictKind = "crosslink-branch"
+)
+
+// conflictMassTolerance is the mass difference in Daltons under which two static
+// modifications are considered to have the same mass
+const conflictMassTolerance = 0.001
+
+// ConflictReport describes modifications on one residue that should not be combined.
+// Chain and Position identify the residue as in ValidationIssue; for chimeric sequences
+// Chain is the index of the peptidoform.
+type ConflictReport struct {
+	Kind          ConflictKind
+	Chain         int
+	Position      int
+	Modifications []*Modification
+	Message       string
+}
+
+// FindConflictingModifications reports residues carrying modifications that should not be
+// combined. Two combinations are conflicts:
+//   - two static modifications whose resolved masses are equal within 0.001 Da, such as
+//     "S[Phospho][+79.966]", which usually means the same modification was written twice
+//   - a crosslink (definition or reference) together with a branch (definition or reference),
+//     since ProForma links a residue to another peptidoform in only one way
+//
+// Modifications whose mass cannot be resolved are not compared. All chains of a multi-chain
+// sequence and all peptidoforms of a chimeric one are checked. An empty result means no
+// conflicts were found.
+//
+// Example:
+//
+//	seq, _ := sequal.FromProforma("PEPS[Phospho][+79.966]IDE")
+//	for _, conflict := range seq.FindConflictingModifications() {
+//		fmt.Println(conflict.Position, conflict.Message)
+//	}
+//	// 3 modifications 'Phospho' and '+79.966' on residue 'S' have the same mass
+func (s *Sequence) FindConflictingModifications() []ConflictReport {
+	conflicts := make([]ConflictReport, 0)
+
+	for chainIndex, chain := range s.partsOrSelf() {
+		for i, aa := range chain.seq {
+			mods := aa.GetMods()
+
+			for a := 0; a < len(mods); a++ {
+				if mods[a].GetModType() != "static" {
+					continue
+				}
+				massA := mods[a].GetResolvedMass()
+				if massA == nil {
+					continue
+				}
+				for b := a + 1; b < len(mods); b++ {
+					if mods[b].GetModType() != "static" {
+						continue
+					}
+					if massB := mods[b].GetResolvedMass(); massB != nil && math.Abs(*massA-*massB) <= conflictMassTolerance {
+						conflicts = append(conflicts, ConflictReport{
+							Kind:          ConflictSameMass,
+							Chain:         chainIndex,
+							Position:      i,
+							Modifications: []*Modification{mods[a], mods[b]},
+							Message: fmt.Sprintf("modifications '%s' and '%s' on residue '%s' have the same mass",
+								mods[a].ToProforma(), mods[b].ToProforma(), aa.GetValue()),
+						})
+					}
+				}
+			}
+
+			var crosslink, branch *Modification
+			for _, mod := range mods {
+				if crosslink == nil && mod.HasCrosslink() {
+					crosslink = mod
+				}
+				if branch == nil && mod.HasBranch() {
+					branch = mod
+				}
+			}
+			if crosslink != nil && branch != nil {
+				conflicts = append(conflicts, ConflictReport{
+					Kind:          ConflictCrosslinkBranch,
+					Chain:         chainIndex,
+					Position:      i,
+					Modifications: []*Modification{crosslink, branch},
+					Message: fmt.Sprintf("crosslink '%s' and branch '%s' on the same residue '%s'",
+						crosslink.ToProforma(), branch.ToProforma(), aa.GetValue()),
+				})
+			}
+		}
+	}
+
+	return conflicts
+}